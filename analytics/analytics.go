@@ -0,0 +1,176 @@
+// Package analytics computes statistics over Dark Throne war history.
+//
+// Every function takes the war history and the ID of the player whose point of view is used,
+// usually the assumed player returned by GetPlayerByIndex or AssumePlayer.
+package analytics
+
+import (
+	"sort"
+	"time"
+
+	DarkThroneApi "github.com/Rihoj/DarkThroneApi"
+)
+
+// OpponentRecord summarises the wars fought against a single opponent.
+type OpponentRecord struct {
+	OpponentID string
+	Wins       int
+	Losses     int
+	GoldGained int // Gold stolen from the opponent
+	GoldLost   int // Gold the opponent stole from us
+}
+
+// Wars returns the number of wars fought against the opponent.
+func (r OpponentRecord) Wars() int {
+	return r.Wins + r.Losses
+}
+
+// WinRate returns the fraction of wars won, or 0 if none were fought.
+func (r OpponentRecord) WinRate() float64 {
+	if r.Wars() == 0 {
+		return 0
+	}
+	return float64(r.Wins) / float64(r.Wars())
+}
+
+// WinRateByOpponent groups the wars playerID took part in by opponent.
+func WinRateByOpponent(history []DarkThroneApi.WarHistory, playerID string) map[string]*OpponentRecord {
+	records := make(map[string]*OpponentRecord)
+	for _, w := range history {
+		opponent := w.OpponentOf(playerID)
+		if opponent == "" {
+			continue
+		}
+		r, ok := records[opponent]
+		if !ok {
+			r = &OpponentRecord{OpponentID: opponent}
+			records[opponent] = r
+		}
+		if w.OutcomeFor(playerID) == DarkThroneApi.WarOutcomeVictory {
+			r.Wins++
+		} else {
+			r.Losses++
+		}
+		if w.IsAttackerVictor {
+			if w.AttackerID == playerID {
+				r.GoldGained += w.GoldStolen
+			} else {
+				r.GoldLost += w.GoldStolen
+			}
+		}
+	}
+	return records
+}
+
+// GoldPerTurn returns the gold stolen by playerID's attacks divided by the attack turns they used.
+// It returns 0 if playerID has not attacked.
+func GoldPerTurn(history []DarkThroneApi.WarHistory, playerID string) float64 {
+	gold, turns := 0, 0
+	for _, w := range history {
+		if w.AttackerID != playerID {
+			continue
+		}
+		turns += w.AttackTurnsUsed
+		if w.IsAttackerVictor {
+			gold += w.GoldStolen
+		}
+	}
+	if turns == 0 {
+		return 0
+	}
+	return float64(gold) / float64(turns)
+}
+
+// AttackerCount is the number of times a player attacked us.
+type AttackerCount struct {
+	AttackerID string
+	Attacks    int
+	Victories  int
+	GoldStolen int
+	LastAttack time.Time
+}
+
+// TopAttackers returns the players who attacked playerID most often, most frequent first.
+// Ties are broken by gold stolen. If n is positive, at most n attackers are returned.
+func TopAttackers(history []DarkThroneApi.WarHistory, playerID string, n int) []AttackerCount {
+	counts := make(map[string]*AttackerCount)
+	for _, w := range history {
+		if w.DefenderID != playerID || w.AttackerID == "" {
+			continue
+		}
+		c, ok := counts[w.AttackerID]
+		if !ok {
+			c = &AttackerCount{AttackerID: w.AttackerID}
+			counts[w.AttackerID] = c
+		}
+		c.Attacks++
+		if w.IsAttackerVictor {
+			c.Victories++
+			c.GoldStolen += w.GoldStolen
+		}
+		if at := w.Time(); at.After(c.LastAttack) {
+			c.LastAttack = at
+		}
+	}
+	result := make([]AttackerCount, 0, len(counts))
+	for _, c := range counts {
+		result = append(result, *c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Attacks != result[j].Attacks {
+			return result[i].Attacks > result[j].Attacks
+		}
+		if result[i].GoldStolen != result[j].GoldStolen {
+			return result[i].GoldStolen > result[j].GoldStolen
+		}
+		return result[i].AttackerID < result[j].AttackerID
+	})
+	if n > 0 && len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
+
+// RevengeCandidate is a player who beat us and has not been attacked back since.
+type RevengeCandidate struct {
+	PlayerID        string
+	GoldStolen      int // Gold stolen from us since our last attack on them
+	LastDefeat      time.Time
+	DefeatsSuffered int
+}
+
+// RevengeCandidates returns players who successfully attacked playerID and whom playerID
+// has not attacked since, ordered by the gold they took.
+func RevengeCandidates(history []DarkThroneApi.WarHistory, playerID string) []RevengeCandidate {
+	sorted := append([]DarkThroneApi.WarHistory(nil), history...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time().Before(sorted[j].Time()) })
+
+	candidates := make(map[string]*RevengeCandidate)
+	for _, w := range sorted {
+		switch {
+		case w.AttackerID == playerID:
+			// We struck back, so any grudge against the defender is settled
+			delete(candidates, w.DefenderID)
+		case w.DefenderID == playerID && w.IsAttackerVictor:
+			c, ok := candidates[w.AttackerID]
+			if !ok {
+				c = &RevengeCandidate{PlayerID: w.AttackerID}
+				candidates[w.AttackerID] = c
+			}
+			c.GoldStolen += w.GoldStolen
+			c.DefeatsSuffered++
+			c.LastDefeat = w.Time()
+		}
+	}
+	result := make([]RevengeCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		result = append(result, *c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].GoldStolen != result[j].GoldStolen {
+			return result[i].GoldStolen > result[j].GoldStolen
+		}
+		return result[i].PlayerID < result[j].PlayerID
+	})
+	return result
+}
@@ -0,0 +1,72 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	DarkThroneApi "github.com/Rihoj/DarkThroneApi"
+)
+
+var base = time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func war(attacker, defender string, won bool, gold, turns, hour int) DarkThroneApi.WarHistory {
+	return DarkThroneApi.WarHistory{
+		AttackerID:       attacker,
+		DefenderID:       defender,
+		IsAttackerVictor: won,
+		GoldStolen:       gold,
+		AttackTurnsUsed:  turns,
+		CreatedAt:        base.Add(time.Duration(hour) * time.Hour),
+	}
+}
+
+var history = []DarkThroneApi.WarHistory{
+	war("me", "a", true, 100, 10, 1),
+	war("me", "a", false, 0, 10, 2),
+	war("b", "me", true, 300, 10, 3),
+	war("b", "me", true, 200, 10, 4),
+	war("c", "me", true, 50, 10, 5),
+	war("me", "c", true, 80, 10, 6),
+	war("a", "me", false, 0, 10, 7),
+	war("x", "y", true, 999, 10, 8),
+}
+
+func TestWinRateByOpponent(t *testing.T) {
+	records := WinRateByOpponent(history, "me")
+	a := records["a"]
+	if a.Wins != 2 || a.Losses != 1 || a.GoldGained != 100 {
+		t.Errorf("unexpected record for a: %+v", a)
+	}
+	if b := records["b"]; b.WinRate() != 0 || b.GoldLost != 500 {
+		t.Errorf("unexpected record for b: %+v", b)
+	}
+	if _, ok := records["x"]; ok {
+		t.Error("wars without us must be ignored")
+	}
+}
+
+func TestGoldPerTurn(t *testing.T) {
+	if got := GoldPerTurn(history, "me"); got != 6 {
+		t.Errorf("expected 6 gold per turn, got %v", got)
+	}
+	if got := GoldPerTurn(history, "nobody"); got != 0 {
+		t.Errorf("expected 0, got %v", got)
+	}
+}
+
+func TestTopAttackers(t *testing.T) {
+	top := TopAttackers(history, "me", 2)
+	if len(top) != 2 || top[0].AttackerID != "b" || top[0].Attacks != 2 || top[0].GoldStolen != 500 {
+		t.Fatalf("unexpected top attackers: %+v", top)
+	}
+	if top[1].AttackerID != "c" {
+		t.Errorf("expected c second on gold stolen, got %+v", top[1])
+	}
+}
+
+func TestRevengeCandidates(t *testing.T) {
+	candidates := RevengeCandidates(history, "me")
+	if len(candidates) != 1 || candidates[0].PlayerID != "b" || candidates[0].DefeatsSuffered != 2 {
+		t.Errorf("unexpected candidates: %+v", candidates)
+	}
+}
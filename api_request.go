@@ -2,35 +2,112 @@ package DarkThroneApi
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"reflect"
+	"strings"
+	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ApiRequestConfig holds configuration for API requests, such as the base URL and logger.
 type ApiRequestConfig struct {
-	BaseURL string
-	Logger  *slog.Logger
+	BaseURL   string
+	Logger    *slog.Logger
+	Cache     *ResponseCache // Optional cache for GET responses
+	Coalescer *Coalescer     // Optional deduplication of identical in-flight GET requests
+	Metrics   *Metrics       // Optional request instrumentation
+	// CircuitBreaker fails requests fast while their rate-limit group keeps failing. Leave nil to disable.
+	CircuitBreaker *CircuitBreaker
+	// StrictDecoding rejects responses with unknown or missing required fields with a *SchemaError.
+	StrictDecoding bool
+	// DriftDetector collects differences between responses and their Go types. Leave nil to disable.
+	DriftDetector *DriftDetector
+	// TracerProvider enables OpenTelemetry spans for each request. Leave nil to disable tracing.
+	TracerProvider trace.TracerProvider
 }
 
 // ApiRequest represents an API request with generic request and response types.
 type ApiRequest[Req any, Resp any] struct {
 	Method   string
-	Endpoint string
+	Endpoint string     // Path relative to the base URL; segments must already be escaped
+	Query    url.Values // Optional query parameters, merged with any query in Endpoint
 	Headers  map[string]string
 	Body     Req
 	Config   *ApiRequestConfig // Optional config for request-level settings
+	// RateLimitGroup selects the rate-limit bucket; requests without a group share the default bucket.
+	RateLimitGroup string
+	// Name is the registry name of the endpoint, used to look up per-endpoint settings such as cache TTLs.
+	Name string
+	// Idempotent marks requests that do not change game state. Successful non-GET requests that are
+	// not idempotent invalidate the response cache.
+	Idempotent bool
+}
+
+// StatusError is returned when the API answers with a non-OK HTTP status.
+type StatusError struct {
+	StatusCode int
+	Status     string
 }
 
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("Non-OK HTTP status: %s", e.Status)
+}
+
+const defaultRateLimitGroup = "default"
+
+// overallRequestsPerRateLimit caps how many requests all groups together may send per doRequestRateLimit.
+// It keeps the client at one request per interval overall, so groups only change which requests wait.
+const overallRequestsPerRateLimit = 1
+
 var (
-	doRequestRateLimit = time.Second // Minimum delay between requests
-	lastRequestTime    time.Time
+	doRequestRateLimit = time.Second // Minimum delay between requests in the same group
+	rateLimitMu        sync.Mutex
+	lastRequestTimes   = map[string]time.Time{}
+	lastRequestTime    time.Time // Last slot reserved by any group
 )
 
+// waitForRateLimit reserves the next request slot for group and sleeps until it is reached or ctx is done.
+// A slot respects both the group's delay and the overall cap shared by every group.
+// It is safe for concurrent use; each caller is given its own slot.
+func waitForRateLimit(ctx context.Context, group string) error {
+	if group == "" {
+		group = defaultRateLimitGroup
+	}
+	rateLimitMu.Lock()
+	slot := time.Now()
+	if last, ok := lastRequestTimes[group]; ok && last.Add(doRequestRateLimit).After(slot) {
+		slot = last.Add(doRequestRateLimit)
+	}
+	if next := lastRequestTime.Add(doRequestRateLimit / overallRequestsPerRateLimit); next.After(slot) {
+		slot = next
+	}
+	lastRequestTimes[group] = slot
+	lastRequestTime = slot
+	rateLimitMu.Unlock()
+	wait := time.Until(slot)
+	if wait <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // isZeroValue checks if a value is the zero value for its type.
 func isZeroValue[T any](v T) bool {
 	return reflect.ValueOf(v).IsZero()
@@ -71,45 +148,131 @@ func (req *ApiRequest[Req, Resp]) logError(msg string, err error) {
 }
 
 // GetUrl constructs the full URL for the API request.
+// It returns an empty string if the base URL is missing or invalid.
 func (req *ApiRequest[Req, Resp]) GetUrl() string {
-	if req.Config == nil || req.Config.BaseURL == "" {
+	u, err := req.buildURL()
+	if err != nil {
 		return ""
 	}
-	return fmt.Sprintf("%s/%s", req.Config.BaseURL, req.Endpoint)
+	return u.String()
+}
+
+// buildURL joins the escaped endpoint path onto the base URL and encodes the query parameters.
+func (req *ApiRequest[Req, Resp]) buildURL() (*url.URL, error) {
+	if req.Config == nil || req.Config.BaseURL == "" {
+		return nil, fmt.Errorf("ApiRequest.Config.BaseURL is required")
+	}
+	base, err := url.Parse(req.Config.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	endpointPath, rawQuery, _ := strings.Cut(req.Endpoint, "?")
+	u := base.JoinPath(endpointPath)
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint query: %w", err)
+	}
+	for k, values := range req.Query {
+		for _, v := range values {
+			query.Add(k, v)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u, nil
 }
 
 // DoRequest executes the API request and returns the response or an error.
 func (req ApiRequest[Req, Resp]) DoRequest() (Resp, error) {
+	return req.DoRequestContext(context.Background())
+}
+
+// DoRequestContext executes the API request like DoRequest, aborting the rate-limit wait
+// and the HTTP call when ctx is cancelled.
+func (req ApiRequest[Req, Resp]) DoRequestContext(ctx context.Context) (Resp, error) {
+	start := time.Now()
+	ctx, span := req.startRequestSpan(ctx)
+	resp, err := req.doRequest(ctx)
+	endRequestSpan(span, err)
+	req.metrics().observeRequest(req.metricsEndpoint(), err, time.Since(start))
+	return resp, err
+}
+
+// doRequest serves the request from the cache, the coalescer or the network.
+func (req *ApiRequest[Req, Resp]) doRequest(ctx context.Context) (Resp, error) {
 	var zero Resp
-	// Rate limiting: ensure a minimum delay between requests
-	now := time.Now()
-	if !lastRequestTime.IsZero() {
-		elapsed := now.Sub(lastRequestTime)
-		if elapsed < doRequestRateLimit {
-			time.Sleep(doRequestRateLimit - elapsed)
+	req.logRequest("Executing API request")
+	requestURL, err := req.buildURL()
+	if err != nil {
+		req.logError("Request Error", err)
+		return zero, err
+	}
+
+	key := requestKey(req.Method, requestURL.String(), req.Headers["Authorization"])
+	if cache := req.responseCache(); cache != nil {
+		if cached, ok := cache.Backend.Get(key); ok && time.Now().Before(cached.ExpiresAt) {
+			req.logResponse("API response served from cache", zero)
+			return req.decode(cached.Body)
 		}
 	}
-	lastRequestTime = time.Now()
 
-	req.logRequest("Executing API request")
-	if req.Config == nil || req.Config.BaseURL == "" {
-		errorString := fmt.Errorf("ApiRequest.Config.BaseURL is required")
-		req.logError("Request Error", errorString)
-		return zero, errorString
+	var body []byte
+	if coalescer := req.coalescer(); coalescer != nil {
+		body, err = coalescer.do(ctx, key, func(ctx context.Context) ([]byte, error) { return req.send(ctx, requestURL, key) })
+	} else {
+		body, err = req.send(ctx, requestURL, key)
+	}
+	if err != nil {
+		return zero, err
+	}
+	return req.decode(body)
+}
+
+// send performs the HTTP round trip and returns the response body.
+// When caching is enabled it revalidates stale entries and stores fresh responses.
+func (req *ApiRequest[Req, Resp]) send(ctx context.Context, requestURL *url.URL, key string) ([]byte, error) {
+	breaker := req.circuitBreaker()
+	admitted, err := breaker.allow(req.RateLimitGroup)
+	if err != nil {
+		req.logError("Request rejected", err)
+		return nil, err
+	}
+
+	// Rate limiting: ensure a minimum delay between requests
+	waitStart := time.Now()
+	err = waitForRateLimit(ctx, req.RateLimitGroup)
+	req.metrics().observeRateLimitWait(req.RateLimitGroup, time.Since(waitStart))
+	if err != nil {
+		breaker.release(req.RateLimitGroup, admitted)
+		return nil, err
+	}
+
+	body, err := req.transmit(ctx, requestURL, key)
+	breaker.record(ctx, req.RateLimitGroup, admitted, err)
+	return body, err
+}
+
+// transmit performs the HTTP round trip.
+func (req *ApiRequest[Req, Resp]) transmit(ctx context.Context, requestURL *url.URL, key string) ([]byte, error) {
+	var zero Resp
+	cache := req.responseCache()
+	var cached CacheEntry
+	hasCached := false
+	if cache != nil {
+		cached, hasCached = cache.Backend.Get(key)
 	}
 
 	var bodyReader io.Reader
 	if !isZeroValue(req.Body) {
 		if data, err := json.Marshal(req.Body); err != nil {
-			return zero, err
+			return nil, err
 		} else {
 			bodyReader = bytes.NewBuffer(data)
 		}
 	}
 
-	httpReq, err := http.NewRequest(req.Method, req.GetUrl(), bodyReader)
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, requestURL.String(), bodyReader)
 	if err != nil {
-		return zero, err
+		return nil, err
 	}
 
 	if _, ok := req.Headers["Content-Type"]; !ok {
@@ -123,25 +286,127 @@ func (req ApiRequest[Req, Resp]) DoRequest() (Resp, error) {
 	for k, v := range req.Headers {
 		httpReq.Header.Set(k, v)
 	}
+	injectTraceContext(ctx, httpReq.Header)
+	if hasCached {
+		// Revalidate the stale entry instead of downloading it again
+		if cached.ETag != "" {
+			httpReq.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			httpReq.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
 
+	attempt := mutationAttemptFrom(ctx)
+	if attempt != nil {
+		attempt.sent = true
+	}
 	client := &http.Client{}
 	resp, err := client.Do(httpReq)
 	req.logResponse("API request response", zero)
 	if err != nil {
-		return zero, err
+		return nil, err
 	}
 	defer resp.Body.Close()
+	if attempt != nil {
+		attempt.responded = true
+		attempt.echoed = resp.Header.Get(idempotencyKeyHeader) == attempt.key
+	}
+
+	if resp.StatusCode == http.StatusNotModified && hasCached {
+		cached.ExpiresAt = time.Now().Add(cache.ttl(req.Name))
+		cache.Backend.Set(key, cached)
+		return cached.Body, nil
+	}
 
 	if resp.StatusCode != http.StatusOK {
-		req.logError("Non-OK HTTP status", fmt.Errorf("Non-OK HTTP status: %s", resp.Status))
-		return zero, fmt.Errorf("Non-OK HTTP status: %s", resp.Status)
+		statusErr := &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+		req.logError("Non-OK HTTP status", statusErr)
+		return nil, statusErr
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return zero, err
+		return nil, err
 	}
 
+	if cache != nil {
+		now := time.Now()
+		cache.Backend.Set(key, CacheEntry{
+			Body:         body,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			StoredAt:     now,
+			ExpiresAt:    now.Add(cache.ttl(req.Name)),
+		})
+	} else if req.invalidatesCache() {
+		req.Config.Cache.Invalidate()
+	}
+	return body, nil
+}
+
+// requestKey identifies a request by method, URL and auth identity.
+// The token is hashed so it never appears in cache backends or logs.
+func requestKey(method, url, authorization string) string {
+	identity := sha256.Sum256([]byte(authorization))
+	return fmt.Sprintf("%s %s %s", method, url, hex.EncodeToString(identity[:8]))
+}
+
+// coalescer returns the configured coalescer if identical in-flight copies of this request may be shared.
+// Only GET requests are coalesced.
+func (req *ApiRequest[Req, Resp]) coalescer() *Coalescer {
+	if req.Config == nil || req.Config.Coalescer == nil || req.Method != http.MethodGet {
+		return nil
+	}
+	return req.Config.Coalescer
+}
+
+// circuitBreaker returns the configured circuit breaker, or nil.
+func (req *ApiRequest[Req, Resp]) circuitBreaker() *CircuitBreaker {
+	if req.Config == nil {
+		return nil
+	}
+	return req.Config.CircuitBreaker
+}
+
+// metrics returns the configured metrics registry, or nil.
+func (req *ApiRequest[Req, Resp]) metrics() *Metrics {
+	if req.Config == nil {
+		return nil
+	}
+	return req.Config.Metrics
+}
+
+// metricsEndpoint returns the endpoint label for metrics. Unnamed requests share one label
+// so that IDs in paths do not create a series per player.
+func (req *ApiRequest[Req, Resp]) metricsEndpoint() string {
+	if req.Name != "" {
+		return req.Name
+	}
+	return "unnamed"
+}
+
+// responseCache returns the configured cache if this request may be served from it.
+func (req *ApiRequest[Req, Resp]) responseCache() *ResponseCache {
+	if req.Config == nil || req.Config.Cache == nil || req.Config.Cache.Backend == nil {
+		return nil
+	}
+	if req.Method != http.MethodGet || req.Config.Cache.ttl(req.Name) <= 0 {
+		return nil
+	}
+	return req.Config.Cache
+}
+
+// invalidatesCache reports whether a successful response to this request makes cached state stale.
+func (req *ApiRequest[Req, Resp]) invalidatesCache() bool {
+	if req.Config == nil || req.Config.Cache == nil || req.Config.Cache.Backend == nil {
+		return false
+	}
+	return req.Method != http.MethodGet && !req.Idempotent
+}
+
+// decode unmarshals a response body into the response type.
+func (req *ApiRequest[Req, Resp]) decode(body []byte) (Resp, error) {
 	var result Resp
 	resultType := reflect.TypeOf(result)
 	var unmarshalTarget any = &result
@@ -161,6 +426,18 @@ func (req ApiRequest[Req, Resp]) DoRequest() (Resp, error) {
 			}
 		}
 	}
-	err = json.Unmarshal(body, unmarshalTarget)
-	return result, err
+	if err := json.Unmarshal(body, unmarshalTarget); err != nil {
+		return result, err
+	}
+	if req.Config == nil || (!req.Config.StrictDecoding && req.Config.DriftDetector == nil) {
+		return result, nil
+	}
+	drift := findDrift(req.metricsEndpoint(), body, resultType)
+	req.Config.DriftDetector.observe(drift)
+	if req.Config.StrictDecoding && len(drift) > 0 {
+		err := &SchemaError{Endpoint: req.metricsEndpoint(), Drift: drift}
+		req.logError("Response does not match schema", err)
+		return result, err
+	}
+	return result, nil
 }
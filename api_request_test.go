@@ -3,6 +3,7 @@ package DarkThroneApi
 import (
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 )
 
@@ -36,6 +37,17 @@ func TestApiRequest_GetUrl(t *testing.T) {
 	}
 }
 
+func TestApiRequest_GetUrl_Query(t *testing.T) {
+	req := ApiRequest[string, string]{
+		Endpoint: "players?page=1",
+		Query:    url.Values{"pageSize": {"10"}, "name": {"a&b"}},
+		Config:   &ApiRequestConfig{BaseURL: "http://localhost/api/"},
+	}
+	if got := req.GetUrl(); got != "http://localhost/api/players?name=a%26b&page=1&pageSize=10" {
+		t.Errorf("unexpected url: %s", got)
+	}
+}
+
 func TestApiRequest_DoRequest_Success(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
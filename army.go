@@ -0,0 +1,208 @@
+package DarkThroneApi
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// ArmyObjective weights unit stats when comparing army compositions.
+type ArmyObjective struct {
+	Offense float64
+	Defense float64
+	Income  float64
+}
+
+// Common objectives.
+var (
+	ObjectiveOffense = ArmyObjective{Offense: 1}
+	ObjectiveDefense = ArmyObjective{Defense: 1}
+	ObjectiveIncome  = ArmyObjective{Income: 1}
+)
+
+// Score returns the weighted value of stats.
+func (o ArmyObjective) Score(stats UnitStats) float64 {
+	return o.Offense*float64(stats.Offense) + o.Defense*float64(stats.Defense) + o.Income*float64(stats.Income)
+}
+
+// ArmyOptions are the constraints of OptimizeArmy.
+type ArmyOptions struct {
+	// Citizens is the number of untrained citizens available; each trained unit takes one.
+	Citizens int
+	// ReserveGold is gold that must be left unspent.
+	ReserveGold int
+	// AllowUntrain lets the optimizer untrain low-value units to free citizens for better ones.
+	// Untraining is assumed to refund no gold.
+	AllowUntrain bool
+}
+
+// ArmyPlan is the change that moves a player's army to the recommended composition.
+// Untrain is applied before Train.
+type ArmyPlan struct {
+	Train   TrainUnitsRequest
+	Untrain UntrainUnitsRequest
+	Cost    int
+	// Before and After are the army stats around the plan; the scores weigh them by the objective.
+	Before, After           UnitStats
+	ScoreBefore, ScoreAfter float64
+}
+
+// ErrNoUsefulUnits is returned when no unit available to the player adds value under the objective.
+var ErrNoUsefulUnits = errors.New("no unit type adds value under the objective")
+
+// OptimizeArmy recommends what player should train, and optionally untrain, to maximize objective
+// with the gold on hand and the citizens in opts.
+//
+// Training is a knapsack with a gold and a citizen constraint. The solver starts from the best
+// vertex of its linear relaxation, which uses at most two unit types, rounds it down, and fills what
+// is left greedily. With AllowUntrain it then swaps the lowest-value owned units for better ones
+// while gold remains.
+func (c *UnitCatalog) OptimizeArmy(player Player, objective ArmyObjective, opts ArmyOptions) (ArmyPlan, error) {
+	var useful []UnitDefinition
+	for _, u := range c.Units {
+		if u.AvailableTo(player.Race) && objective.Score(u.Stats) > 0 {
+			useful = append(useful, u)
+		}
+	}
+	if len(useful) == 0 {
+		return ArmyPlan{}, ErrNoUsefulUnits
+	}
+	gold := max(player.Gold-opts.ReserveGold, 0)
+	citizens := max(opts.Citizens, 0)
+
+	train := solveTraining(useful, objective, gold, citizens)
+	untrain := map[UnitType]int{}
+	if opts.AllowUntrain {
+		spent := 0
+		for _, u := range useful {
+			spent += train[u.Type] * u.Cost
+		}
+		c.swapUnits(player.Units, useful, objective, gold-spent, train, untrain)
+	}
+
+	plan := ArmyPlan{
+		Train:   TrainUnitsRequest{PlayerID: player.ID},
+		Untrain: UntrainUnitsRequest{PlayerID: player.ID},
+		Before:  c.ArmyStats(player.Units),
+	}
+	plan.After = plan.Before
+	for _, u := range c.Units {
+		if n := untrain[u.Type]; n > 0 {
+			plan.Untrain.Units = append(plan.Untrain.Units, UnitRequest{UnitType: u.Type, Quantity: n})
+			plan.After = plan.After.Add(u.Stats.Scale(-n))
+		}
+		if n := train[u.Type]; n > 0 {
+			plan.Train.Units = append(plan.Train.Units, UnitRequest{UnitType: u.Type, Quantity: n})
+			plan.After = plan.After.Add(u.Stats.Scale(n))
+			plan.Cost += n * u.Cost
+		}
+	}
+	plan.ScoreBefore = objective.Score(plan.Before)
+	plan.ScoreAfter = objective.Score(plan.After)
+	return plan, nil
+}
+
+// solveTraining returns how many of each unit to train with gold and citizens.
+func solveTraining(units []UnitDefinition, objective ArmyObjective, gold, citizens int) map[UnitType]int {
+	best, bestScore := map[UnitType]int{}, -1.0
+	consider := func(counts map[UnitType]int) {
+		fillGreedy(units, objective, counts, gold, citizens)
+		if score := countsScore(units, objective, counts); score > bestScore {
+			best, bestScore = counts, score
+		}
+	}
+	for i, a := range units {
+		consider(map[UnitType]int{a.Type: maxAffordable(a.Cost, gold, citizens)})
+		for _, b := range units[i+1:] {
+			if a.Cost == b.Cost {
+				continue
+			}
+			// Both constraints binding: x + y = citizens and a.Cost*x + b.Cost*y = gold.
+			x := float64(gold-b.Cost*citizens) / float64(a.Cost-b.Cost)
+			if x < 0 || x > float64(citizens) {
+				continue
+			}
+			xa := int(math.Floor(x))
+			consider(map[UnitType]int{a.Type: xa, b.Type: maxAffordable(b.Cost, gold-a.Cost*xa, citizens-xa)})
+		}
+	}
+	return best
+}
+
+// fillGreedy adds the highest-scoring units that still fit into the gold and citizens left over by counts.
+func fillGreedy(units []UnitDefinition, objective ArmyObjective, counts map[UnitType]int, gold, citizens int) {
+	for _, u := range units {
+		gold -= counts[u.Type] * u.Cost
+		citizens -= counts[u.Type]
+	}
+	byScore := append([]UnitDefinition(nil), units...)
+	sort.SliceStable(byScore, func(i, j int) bool {
+		return objective.Score(byScore[i].Stats) > objective.Score(byScore[j].Stats)
+	})
+	for _, u := range byScore {
+		if n := maxAffordable(u.Cost, gold, citizens); n > 0 {
+			counts[u.Type] += n
+			gold -= n * u.Cost
+			citizens -= n
+		}
+	}
+}
+
+// swapUnits untrains owned units worth less than the best unit affordable with the remaining gold
+// and trains that unit in their place, updating train and untrain.
+func (c *UnitCatalog) swapUnits(owned []Unit, useful []UnitDefinition, objective ArmyObjective, gold int, train, untrain map[UnitType]int) {
+	type holding struct {
+		def   UnitDefinition
+		count int
+	}
+	var holdings []holding
+	for _, u := range owned {
+		// Never untrain a type the plan also trains.
+		if def, ok := c.Unit(u.UnitType); ok && u.Quantity > 0 && train[u.UnitType] == 0 {
+			holdings = append(holdings, holding{def, u.Quantity})
+		}
+	}
+	sort.SliceStable(holdings, func(i, j int) bool {
+		return objective.Score(holdings[i].def.Stats) < objective.Score(holdings[j].def.Stats)
+	})
+	for i := range holdings {
+		h := &holdings[i]
+		for h.count > 0 {
+			var best UnitDefinition
+			found := false
+			for _, u := range useful {
+				if u.Cost <= gold && objective.Score(u.Stats) > objective.Score(h.def.Stats) &&
+					(!found || objective.Score(u.Stats) > objective.Score(best.Stats)) {
+					best, found = u, true
+				}
+			}
+			if !found {
+				return
+			}
+			n := min(h.count, maxAffordable(best.Cost, gold, h.count))
+			untrain[h.def.Type] += n
+			train[best.Type] += n
+			gold -= n * best.Cost
+			h.count -= n
+		}
+	}
+}
+
+// maxAffordable returns how many units costing cost fit into gold and citizens.
+func maxAffordable(cost, gold, citizens int) int {
+	if citizens <= 0 || gold < 0 {
+		return 0
+	}
+	if cost <= 0 {
+		return citizens
+	}
+	return min(gold/cost, citizens)
+}
+
+func countsScore(units []UnitDefinition, objective ArmyObjective, counts map[UnitType]int) float64 {
+	score := 0.0
+	for _, u := range units {
+		score += float64(counts[u.Type]) * objective.Score(u.Stats)
+	}
+	return score
+}
@@ -0,0 +1,92 @@
+package DarkThroneApi
+
+import (
+	"errors"
+	"testing"
+)
+
+func unitCounts(units []UnitRequest) map[UnitType]int {
+	counts := map[UnitType]int{}
+	for _, u := range units {
+		counts[u.UnitType] += u.Quantity
+	}
+	return counts
+}
+
+func TestOptimizeArmy_Constraints(t *testing.T) {
+	tests := []struct {
+		name     string
+		player   Player
+		opts     ArmyOptions
+		want     map[UnitType]int
+		wantCost int
+	}{
+		{"gold bound", Player{Gold: 1000, Race: "human"}, ArmyOptions{Citizens: 100}, map[UnitType]int{"knight": 2}, 1000},
+		{"citizens bound", Player{Gold: 10000, Race: "human"}, ArmyOptions{Citizens: 3}, map[UnitType]int{"knight": 3}, 1500},
+		{"both bound", Player{Gold: 1300, Race: "human"}, ArmyOptions{Citizens: 5}, map[UnitType]int{"knight": 2, "soldier": 1}, 1200},
+		{"race restricted", Player{Gold: 1000, Race: "elf"}, ArmyOptions{Citizens: 100}, map[UnitType]int{"soldier": 5}, 1000},
+		{"reserve", Player{Gold: 1000, Race: "human"}, ArmyOptions{Citizens: 100, ReserveGold: 600}, map[UnitType]int{"soldier": 2}, 400},
+	}
+	for _, tt := range tests {
+		plan, err := testUnitCatalog.OptimizeArmy(tt.player, ObjectiveOffense, tt.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got := unitCounts(plan.Train.Units)
+		if len(got) != len(tt.want) || plan.Cost != tt.wantCost {
+			t.Errorf("%s: train = %v (cost %d), want %v (cost %d)", tt.name, got, plan.Cost, tt.want, tt.wantCost)
+			continue
+		}
+		for unitType, n := range tt.want {
+			if got[unitType] != n {
+				t.Errorf("%s: train = %v, want %v", tt.name, got, tt.want)
+			}
+		}
+		if len(plan.Untrain.Units) != 0 {
+			t.Errorf("%s: unexpected untrain %v", tt.name, plan.Untrain.Units)
+		}
+	}
+}
+
+func TestOptimizeArmy_Untrain(t *testing.T) {
+	player := Player{ID: "p1", Gold: 1000, Race: "human", Units: []Unit{{UnitType: "worker", Quantity: 3}}}
+	plan, err := testUnitCatalog.OptimizeArmy(player, ObjectiveOffense, ArmyOptions{AllowUntrain: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := unitCounts(plan.Untrain.Units); got["worker"] != 2 || len(got) != 1 {
+		t.Errorf("untrain = %v, want 2 workers", got)
+	}
+	if got := unitCounts(plan.Train.Units); got["knight"] != 2 || len(got) != 1 {
+		t.Errorf("train = %v, want 2 knights", got)
+	}
+	if plan.Train.PlayerID != "p1" || plan.Untrain.PlayerID != "p1" {
+		t.Error("requests should carry the player ID")
+	}
+	if plan.Before != (UnitStats{Income: 15}) || plan.After != (UnitStats{Offense: 20, Defense: 4, Income: 5}) {
+		t.Errorf("before = %+v, after = %+v", plan.Before, plan.After)
+	}
+	if plan.ScoreBefore != 0 || plan.ScoreAfter != 20 {
+		t.Errorf("scores = %v -> %v", plan.ScoreBefore, plan.ScoreAfter)
+	}
+
+	plan, _ = testUnitCatalog.OptimizeArmy(player, ObjectiveOffense, ArmyOptions{})
+	if len(plan.Untrain.Units) != 0 || len(plan.Train.Units) != 0 {
+		t.Errorf("without citizens or untraining nothing should change: %+v", plan)
+	}
+}
+
+func TestOptimizeArmy_WeightedAndErrors(t *testing.T) {
+	mix := ArmyObjective{Offense: 1, Income: 1}
+	plan, err := testUnitCatalog.OptimizeArmy(Player{Gold: 300, Race: "elf"}, mix, ArmyOptions{Citizens: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Per gold a worker is worth 5/100 and a soldier 3/200, so three workers win.
+	if got := unitCounts(plan.Train.Units); got["worker"] != 3 {
+		t.Errorf("train = %v, want 3 workers", got)
+	}
+	if _, err := testUnitCatalog.OptimizeArmy(Player{Race: "elf"}, ObjectiveDefense, ArmyOptions{}); !errors.Is(err, ErrNoUsefulUnits) {
+		t.Errorf("err = %v, want ErrNoUsefulUnits", err)
+	}
+}
@@ -0,0 +1,338 @@
+// Package audit keeps a tamper-evident, append-only record of mutating actions as hash-chained NDJSON.
+//
+// Every line is one Entry. Its Hash covers the entry and the hash of the line before it, so editing,
+// removing, inserting or reordering lines breaks the chain and is reported by Verify. Truncating the
+// end of the file cannot be detected from the file alone; keep the last hash elsewhere to check for it.
+// A final line without a newline, left by a crash while an entry was written, is not part of the chain:
+// Verify and Query skip it and Open removes it.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Phase tells whether an entry was written before or after the request was sent.
+type Phase string
+
+const (
+	// PhaseIntent is written before a request is sent; a request is never sent if its intent cannot be recorded.
+	PhaseIntent Phase = "intent"
+	// PhaseOutcome records how a request ended.
+	PhaseOutcome Phase = "outcome"
+)
+
+// Entry is one line of the audit log.
+type Entry struct {
+	Seq  uint64    `json:"seq"`
+	Time time.Time `json:"time"`
+	// Account is the email the client logged in with, and PlayerID the player it acted as.
+	Account  string `json:"account,omitempty"`
+	PlayerID string `json:"playerId,omitempty"`
+	// Action is the endpoint name, such as "bank.deposit".
+	Action string `json:"action"`
+	Phase  Phase  `json:"phase"`
+	// Key links the intent and outcome entries of one request.
+	Key string `json:"key,omitempty"`
+	// Payload is the request body with secrets redacted.
+	Payload json.RawMessage `json:"payload,omitempty"`
+	// Outcome is set on outcome entries, for example "done", "failed", "ambiguous" or "simulated".
+	Outcome string `json:"outcome,omitempty"`
+	Error   string `json:"error,omitempty"`
+	// Response is the redacted response body of a request that succeeded.
+	Response json.RawMessage `json:"response,omitempty"`
+	// PrevHash is the Hash of the previous entry, empty for the first one.
+	PrevHash string `json:"prevHash"`
+	Hash     string `json:"hash"`
+}
+
+// hash returns the hex SHA-256 of e with its Hash cleared.
+func (e Entry) hash() (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// redactedFields are payload keys whose values are never written, matched case-insensitively.
+var redactedFields = []string{"password", "confirmpassword", "token", "secret"}
+
+// Redacted replaces the value of every secret field in payload, at any depth, with "[REDACTED]".
+// Payloads that are not JSON objects or arrays are returned unchanged.
+func Redacted(payload []byte) json.RawMessage {
+	var v any
+	if err := json.Unmarshal(payload, &v); err != nil {
+		return payload
+	}
+	data, err := json.Marshal(redact(v))
+	if err != nil {
+		return payload
+	}
+	return data
+}
+
+func redact(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, field := range v {
+			if slices.Contains(redactedFields, strings.ToLower(k)) {
+				v[k] = "[REDACTED]"
+			} else {
+				v[k] = redact(field)
+			}
+		}
+	case []any:
+		for i := range v {
+			v[i] = redact(v[i])
+		}
+	}
+	return v
+}
+
+// Log appends entries to an audit log file. It is safe for concurrent use.
+type Log struct {
+	mu   sync.Mutex
+	f    *os.File
+	seq  uint64
+	last string
+	torn []byte
+}
+
+// Open opens the audit log at path for appending, creating it if needed, and continues its chain.
+// An incomplete final line, left by a crash during Append, is removed first; see Torn.
+func Open(path string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	l := &Log{f: f}
+	if l.torn, err = dropTornTail(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("repairing audit log %s: %w", path, err)
+	}
+	if err := scan(f, func(_ int, e Entry) error {
+		l.seq, l.last = e.Seq, e.Hash
+		return nil
+	}); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("reading audit log %s: %w", path, err)
+	}
+	return l, nil
+}
+
+// dropTornTail truncates f after its last newline and returns the bytes it removed. Append writes each
+// entry and its newline at once, so anything after the last newline is an entry cut short.
+func dropTornTail(f *os.File) ([]byte, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	end := info.Size()
+	buf := make([]byte, 4096)
+	for start := end; start > 0; {
+		n := min(int64(len(buf)), start)
+		start -= n
+		if _, err := f.ReadAt(buf[:n], start); err != nil {
+			return nil, err
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			return truncateAt(f, start+int64(i)+1, end)
+		}
+	}
+	return truncateAt(f, 0, end)
+}
+
+// truncateAt truncates f to size bytes and returns the removed bytes up to end.
+func truncateAt(f *os.File, size, end int64) ([]byte, error) {
+	if size == end {
+		return nil, nil
+	}
+	tail := make([]byte, end-size)
+	if _, err := f.ReadAt(tail, size); err != nil {
+		return nil, err
+	}
+	return tail, f.Truncate(size)
+}
+
+// Torn returns the incomplete final line that Open removed, or nil if the log ended cleanly.
+// It is the start of an entry whose Append was interrupted, so its request may not have been recorded.
+func (l *Log) Torn() []byte {
+	return l.torn
+}
+
+// Append completes e with its sequence number, time (if zero), previous hash and hash, writes it
+// and syncs the file. It returns the entry as written.
+func (l *Log) Append(e Entry) (Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e.Seq = l.seq + 1
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Time = e.Time.UTC()
+	e.PrevHash = l.last
+	hash, err := e.hash()
+	if err != nil {
+		return Entry{}, err
+	}
+	e.Hash = hash
+	line, err := json.Marshal(e)
+	if err != nil {
+		return Entry{}, err
+	}
+	if _, err := l.f.Write(append(line, '\n')); err != nil {
+		return Entry{}, err
+	}
+	if err := l.f.Sync(); err != nil {
+		return Entry{}, err
+	}
+	l.seq, l.last = e.Seq, e.Hash
+	return e, nil
+}
+
+// LastHash returns the hash of the last entry, which can be stored elsewhere to detect truncation.
+func (l *Log) LastHash() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.last
+}
+
+// Close closes the log file.
+func (l *Log) Close() error {
+	return l.f.Close()
+}
+
+// ErrTampered matches every *TamperError with errors.Is.
+var ErrTampered = errors.New("audit log has been tampered with")
+
+// TamperError reports the first line of an audit log that breaks the hash chain.
+type TamperError struct {
+	Line   int
+	Reason string
+}
+
+func (e *TamperError) Error() string {
+	return fmt.Sprintf("%s: line %d: %s", ErrTampered, e.Line, e.Reason)
+}
+
+// Is reports whether target is ErrTampered.
+func (e *TamperError) Is(target error) bool {
+	return target == ErrTampered
+}
+
+// Verify checks the hash chain of the log read from r and returns the number of entries.
+// It returns a *TamperError for the first entry that was altered, removed, inserted or reordered.
+func Verify(r io.Reader) (int, error) {
+	count := 0
+	var prev Entry
+	err := scan(r, func(line int, e Entry) error {
+		switch {
+		case e.Seq != prev.Seq+1:
+			return &TamperError{Line: line, Reason: fmt.Sprintf("sequence %d follows %d", e.Seq, prev.Seq)}
+		case e.PrevHash != prev.Hash:
+			return &TamperError{Line: line, Reason: "previous hash does not match"}
+		}
+		hash, err := e.hash()
+		if err != nil {
+			return err
+		}
+		if hash != e.Hash {
+			return &TamperError{Line: line, Reason: "entry hash does not match its contents"}
+		}
+		prev = e
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// VerifyFile is Verify for the log at path.
+func VerifyFile(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return Verify(f)
+}
+
+// Filter selects entries in Query. Zero fields match everything.
+type Filter struct {
+	PlayerID string
+	// Actions lists the endpoint names to keep, such as "bank.deposit".
+	Actions []string
+	Phase   Phase
+	Since   time.Time
+	Until   time.Time
+}
+
+// Match reports whether e passes the filter.
+func (f Filter) Match(e Entry) bool {
+	return (f.PlayerID == "" || e.PlayerID == f.PlayerID) &&
+		(len(f.Actions) == 0 || slices.Contains(f.Actions, e.Action)) &&
+		(f.Phase == "" || e.Phase == f.Phase) &&
+		(f.Since.IsZero() || !e.Time.Before(f.Since)) &&
+		(f.Until.IsZero() || e.Time.Before(f.Until))
+}
+
+// Query returns the entries of the log read from r that match f, in log order.
+// It does not verify the chain; call Verify first when the log may have been tampered with.
+func Query(r io.Reader, f Filter) ([]Entry, error) {
+	var entries []Entry
+	err := scan(r, func(_ int, e Entry) error {
+		if f.Match(e) {
+			entries = append(entries, e)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// QueryFile is Query for the log at path.
+func QueryFile(path string, f Filter) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Query(file, f)
+}
+
+// scan decodes each non-empty line of r and passes it to fn with its 1-based line number.
+// A final line without a newline is an entry that Append did not finish writing, and is skipped.
+func scan(r io.Reader, fn func(line int, e Entry) error) error {
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(data, &e); err != nil {
+			return &TamperError{Line: line, Reason: fmt.Sprintf("invalid entry: %v", err)}
+		}
+		if err := fn(line, e); err != nil {
+			return err
+		}
+	}
+}
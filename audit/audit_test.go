@@ -0,0 +1,150 @@
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeLog(t *testing.T, entries ...Entry) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.ndjson")
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if _, err := l.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func sampleEntries() []Entry {
+	return []Entry{
+		{PlayerID: "p1", Action: "bank.deposit", Phase: PhaseIntent, Key: "k1", Payload: []byte(`{"amount":100}`)},
+		{PlayerID: "p1", Action: "bank.deposit", Phase: PhaseOutcome, Key: "k1", Outcome: "done"},
+		{PlayerID: "p2", Action: "training.train", Phase: PhaseIntent, Key: "k2"},
+		{PlayerID: "p2", Action: "training.train", Phase: PhaseOutcome, Key: "k2", Outcome: "failed", Error: "boom"},
+	}
+}
+
+func TestLog_AppendContinuesChainAfterReopen(t *testing.T) {
+	entries := sampleEntries()
+	path := writeLog(t, entries[:2]...)
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := l.LastHash()
+	e, err := l.Append(entries[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+	if e.Seq != 3 || e.PrevHash != last || e.Hash == "" {
+		t.Errorf("appended entry = %+v", e)
+	}
+	if n, err := VerifyFile(path); err != nil || n != 3 {
+		t.Errorf("VerifyFile = %d, %v", n, err)
+	}
+}
+
+func TestOpen_DropsTornFinalLine(t *testing.T) {
+	entries := sampleEntries()
+	path := writeLog(t, entries[:2]...)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	torn := `{"seq":3,"action":"bank.dep`
+	f.WriteString(torn)
+	f.Close()
+
+	if n, err := VerifyFile(path); err != nil || n != 2 {
+		t.Errorf("VerifyFile before reopening = %d, %v, want the torn line skipped", n, err)
+	}
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(l.Torn()) != torn {
+		t.Errorf("Torn() = %q, want %q", l.Torn(), torn)
+	}
+	e, err := l.Append(entries[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+	if e.Seq != 3 {
+		t.Errorf("appended seq = %d, want 3", e.Seq)
+	}
+	if n, err := VerifyFile(path); err != nil || n != 3 {
+		t.Errorf("VerifyFile = %d, %v", n, err)
+	}
+}
+
+func TestVerify_DetectsTampering(t *testing.T) {
+	data, err := os.ReadFile(writeLog(t, sampleEntries()...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	join := func(lines ...string) string { return strings.Join(lines, "\n") + "\n" }
+
+	for name, tc := range map[string]struct {
+		log  string
+		line int
+	}{
+		"edited":    {join(lines[0], strings.Replace(lines[1], `"done"`, `"failed"`, 1), lines[2], lines[3]), 2},
+		"removed":   {join(lines[0], lines[2], lines[3]), 2},
+		"reordered": {join(lines[0], lines[2], lines[1], lines[3]), 2},
+		"garbage":   {join(lines[0], "{not json", lines[2]), 2},
+	} {
+		_, err := Verify(strings.NewReader(tc.log))
+		var tamper *TamperError
+		if !errors.As(err, &tamper) || !errors.Is(err, ErrTampered) || tamper.Line != tc.line {
+			t.Errorf("%s: err = %v, want tampering at line %d", name, err, tc.line)
+		}
+	}
+	if n, err := Verify(strings.NewReader(join(lines...))); err != nil || n != 4 {
+		t.Errorf("untouched log: %d, %v", n, err)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	got := string(Redacted([]byte(`{"name":"Nova","password":"hunter2","nested":[{"Token":"abc"}]}`)))
+	if strings.Contains(got, "hunter2") || strings.Contains(got, "abc") || !strings.Contains(got, "Nova") {
+		t.Errorf("Redacted = %s", got)
+	}
+	if got := string(Redacted([]byte(`"plain"`))); got != `"plain"` {
+		t.Errorf("Redacted(string) = %s", got)
+	}
+}
+
+func TestQuery_FiltersByPlayerAndAction(t *testing.T) {
+	path := writeLog(t, sampleEntries()...)
+	entries, err := QueryFile(path, Filter{PlayerID: "p1", Actions: []string{"bank.deposit"}, Phase: PhaseOutcome})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Key != "k1" || entries[0].Outcome != "done" {
+		t.Errorf("entries = %+v", entries)
+	}
+	entries, _ = QueryFile(path, Filter{Actions: []string{"training.train", "attack"}})
+	if len(entries) != 2 {
+		t.Errorf("got %d training entries, want 2", len(entries))
+	}
+	data, _ := os.ReadFile(path)
+	if entries, _ := Query(bytes.NewReader(data), Filter{Until: time.Now().Add(-time.Hour)}); len(entries) != 0 {
+		t.Errorf("Until filter kept %d entries", len(entries))
+	}
+}
@@ -0,0 +1,50 @@
+package DarkThroneApi
+
+import (
+	"fmt"
+
+	"github.com/Rihoj/DarkThroneApi/audit"
+)
+
+// auditIntent records that a mutating request is about to be sent. Without an audit log it does nothing.
+func (d *DarkThroneApi) auditIntent(name endpointName, playerID, key string, payload []byte) error {
+	if _, err := d.appendAudit(name, playerID, audit.Entry{Phase: audit.PhaseIntent, Key: key, Payload: audit.Redacted(payload)}); err != nil {
+		return fmt.Errorf("writing audit log: %w", err)
+	}
+	return nil
+}
+
+// auditOutcome records how a mutating request ended. A failure to write is logged, since the request has already been sent.
+// response is the body of a successful request, or nil.
+func (d *DarkThroneApi) auditOutcome(name endpointName, playerID, key string, payload, response []byte, outcome string, err error) {
+	e := audit.Entry{Phase: audit.PhaseOutcome, Key: key, Outcome: outcome}
+	if response != nil {
+		e.Response = audit.Redacted(response)
+	}
+	if key == "" {
+		// No intent entry carries the payload.
+		e.Payload = audit.Redacted(payload)
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	if _, werr := d.appendAudit(name, playerID, e); werr != nil {
+		d.config.Logger.Error("Writing audit log failed", "endpoint", name, "key", key, "error", werr)
+	}
+}
+
+// appendAudit fills in who acted and appends e to the configured audit log.
+func (d *DarkThroneApi) appendAudit(name endpointName, playerID string, e audit.Entry) (audit.Entry, error) {
+	if d.config == nil || d.config.AuditLog == nil {
+		return e, nil
+	}
+	d.state.mu.Lock()
+	e.Account = d.state.account
+	if playerID == "" {
+		playerID = d.state.assumed
+	}
+	d.state.mu.Unlock()
+	e.PlayerID = playerID
+	e.Action = string(name)
+	return d.config.AuditLog.Append(e)
+}
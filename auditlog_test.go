@@ -0,0 +1,76 @@
+package DarkThroneApi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Rihoj/DarkThroneApi/audit"
+)
+
+func TestAuditLog_RecordsMutations(t *testing.T) {
+	var posts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		switch r.URL.Path {
+		case "/players":
+			w.Write([]byte(`{"id":"p9","name":"Nova"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "audit.ndjson")
+	log, err := audit.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := newTestApi(t, server.URL)
+	api.token = "t"
+	api.config.AuditLog = log
+	api.state.account = "me@example.com"
+	api.rememberAssumed(Player{ID: "p1"})
+
+	if _, err := api.CreatePlayer(CreatePlayerRequest{Name: "Nova", Race: "elf", Password: "hunter2"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.DepositGold(BankDepositRequest{PlayerID: "p1", Amount: 10}); err == nil {
+		t.Fatal("expected the deposit to fail")
+	}
+	log.Close()
+
+	entries, err := audit.QueryFile(path, audit.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("got %d entries, want an intent and an outcome per request", len(entries))
+	}
+	create, outcome := entries[0], entries[1]
+	if create.Account != "me@example.com" || create.PlayerID != "p1" || create.Action != "players.create" || create.Phase != audit.PhaseIntent {
+		t.Errorf("intent = %+v", create)
+	}
+	if strings.Contains(string(create.Payload), "hunter2") {
+		t.Errorf("payload was not redacted: %s", create.Payload)
+	}
+	if outcome.Key != create.Key || outcome.Outcome != "done" || !strings.Contains(string(outcome.Response), `"p9"`) {
+		t.Errorf("outcome = %+v", outcome)
+	}
+	if failed := entries[3]; failed.Outcome != "failed" || failed.Error == "" {
+		t.Errorf("deposit outcome = %+v", failed)
+	}
+	if n, err := audit.VerifyFile(path); err != nil || n != 4 {
+		t.Errorf("VerifyFile = %d, %v", n, err)
+	}
+
+	// A request whose intent cannot be recorded is not sent.
+	sent := posts
+	if _, err := api.DepositGold(BankDepositRequest{PlayerID: "p1", Amount: 10}); err == nil || !strings.Contains(err.Error(), "audit log") {
+		t.Errorf("err = %v, want an audit log error", err)
+	}
+	if posts != sent {
+		t.Error("the request was sent without an audit entry")
+	}
+}
@@ -1,50 +1,37 @@
 package DarkThroneApi
 
-// BankDepositRequest represents the payload to deposit gold.
-type BankDepositRequest struct {
-	PlayerID string `json:"playerId"`
-	Amount   int    `json:"amount"`
-}
-
-// BankWithdrawRequest represents the payload to withdraw gold.
-type BankWithdrawRequest struct {
-	PlayerID string `json:"playerId"`
-	Amount   int    `json:"amount"`
-}
-
-// BankResponse represents the response from a bank operation.
-type BankResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	Balance int    `json:"balance"`
-}
+import (
+	"context"
+	"time"
+)
 
 // DepositGold deposits gold into the bank.
+//
+// The API cannot re-read a bank balance, so after ErrAmbiguousOutcome a retry of the same deposit
+// usually cannot tell whether the first one went through. Unless the server honours idempotency keys,
+// the retry keeps returning ErrAmbiguousOutcome until the entry is settled by hand with
+// Journal().Resolve.
 func (d *DarkThroneApi) DepositGold(req BankDepositRequest) (BankResponse, error) {
-	response, err := ApiRequest[BankDepositRequest, BankResponse]{
-		Method:   "POST",
-		Endpoint: "bank/deposit",
-		Headers:  d.getAuthHeaders(),
-		Body:     req,
-		Config:   d.apiConfig,
-	}.DoRequest()
+	response, err := mutate(context.Background(), d, epBankDeposit, req.PlayerID, req, d.bankDepositAPI)
 	if err != nil {
 		return BankResponse{}, err
 	}
+	d.rememberBankBalance(req.PlayerID, response.Balance)
+	d.applyGold(req.PlayerID, -req.Amount)
+	d.publish(GoldDeposited{PlayerID: req.PlayerID, Amount: req.Amount, Balance: response.Balance, At: time.Now()})
 	return response, nil
 }
 
 // WithdrawGold withdraws gold from the bank.
+// Like DepositGold, an ambiguous withdrawal must be settled with Journal().Resolve before it is retried,
+// unless the server honours idempotency keys.
 func (d *DarkThroneApi) WithdrawGold(req BankWithdrawRequest) (BankResponse, error) {
-	response, err := ApiRequest[BankWithdrawRequest, BankResponse]{
-		Method:   "POST",
-		Endpoint: "bank/withdraw",
-		Headers:  d.getAuthHeaders(),
-		Body:     req,
-		Config:   d.apiConfig,
-	}.DoRequest()
+	response, err := mutate(context.Background(), d, epBankWithdraw, req.PlayerID, req, d.bankWithdrawAPI)
 	if err != nil {
 		return BankResponse{}, err
 	}
+	d.rememberBankBalance(req.PlayerID, response.Balance)
+	d.applyGold(req.PlayerID, req.Amount)
+	d.publish(GoldWithdrawn{PlayerID: req.PlayerID, Amount: req.Amount, Balance: response.Balance, At: time.Now()})
 	return response, nil
 }
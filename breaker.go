@@ -0,0 +1,227 @@
+package DarkThroneApi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting the API while a rate-limit group's circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of one rate-limit group's circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets requests through and counts consecutive failures.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects requests until the cool-down has passed.
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of probe requests through to test whether the API recovered.
+	CircuitHalfOpen
+)
+
+// String returns "closed", "open" or "half-open".
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreakerConfig configures a CircuitBreaker. Zero values select the defaults.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the circuit. Defaults to 5.
+	FailureThreshold int
+	// CoolDown is how long the circuit stays open before probing. Defaults to 30 seconds.
+	CoolDown time.Duration
+	// HalfOpenRequests is the number of concurrent probe requests allowed while half-open. Defaults to 1.
+	HalfOpenRequests int
+}
+
+// CircuitBreaker fails requests fast while the API is failing. Each rate-limit group has its own circuit.
+// Server errors, network errors and timeouts count as failures; client errors such as 404 do not.
+type CircuitBreaker struct {
+	config CircuitBreakerConfig
+	now    func() time.Time
+
+	mu     sync.Mutex
+	groups map[string]*circuit
+}
+
+// circuit is the state of one group.
+type circuit struct {
+	state    CircuitState
+	failures int // consecutive failures while closed
+	openedAt time.Time
+	probes   int // probe requests in flight while half-open
+	rejected uint64
+}
+
+// CircuitStats reports the state of one group's circuit.
+type CircuitStats struct {
+	Group    string
+	State    CircuitState
+	Rejected uint64
+}
+
+// NewCircuitBreaker creates a circuit breaker with every group closed.
+func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 5
+	}
+	if config.CoolDown <= 0 {
+		config.CoolDown = 30 * time.Second
+	}
+	if config.HalfOpenRequests <= 0 {
+		config.HalfOpenRequests = 1
+	}
+	return &CircuitBreaker{config: config, now: time.Now, groups: make(map[string]*circuit)}
+}
+
+// circuit returns the circuit for group, creating it if needed. b.mu must be held.
+func (b *CircuitBreaker) circuit(group string) *circuit {
+	if group == "" {
+		group = defaultRateLimitGroup
+	}
+	c, ok := b.groups[group]
+	if !ok {
+		c = &circuit{}
+		b.groups[group] = c
+	}
+	return c
+}
+
+// State returns the state of group's circuit.
+func (b *CircuitBreaker) State(group string) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.effectiveState(b.circuit(group))
+}
+
+// effectiveState reports an open circuit whose cool-down has passed as half-open. b.mu must be held.
+func (b *CircuitBreaker) effectiveState(c *circuit) CircuitState {
+	if c.state == CircuitOpen && b.now().Sub(c.openedAt) >= b.config.CoolDown {
+		return CircuitHalfOpen
+	}
+	return c.state
+}
+
+// Stats returns the state of every group that has made requests, sorted by group.
+func (b *CircuitBreaker) Stats() []CircuitStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats := make([]CircuitStats, 0, len(b.groups))
+	for group, c := range b.groups {
+		stats = append(stats, CircuitStats{Group: group, State: b.effectiveState(c), Rejected: c.rejected})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Group < stats[j].Group })
+	return stats
+}
+
+// admission is handed out by allow for each request it lets through and passed back to record or release.
+type admission struct {
+	// probe is set when the request took a probe slot of a half-open circuit.
+	probe bool
+}
+
+// allow reports whether a request in group may be sent, moving an open circuit to half-open
+// once the cool-down has passed. It is a no-op on a nil breaker.
+func (b *CircuitBreaker) allow(group string) (admission, error) {
+	if b == nil {
+		return admission{}, nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuit(group)
+	if c.state == CircuitOpen {
+		remaining := b.config.CoolDown - b.now().Sub(c.openedAt)
+		if remaining > 0 {
+			c.rejected++
+			return admission{}, fmt.Errorf("%w for %s requests; retry in %s", ErrCircuitOpen, group, remaining.Round(time.Second))
+		}
+		// Probes from an earlier half-open period that are still in flight keep their slots.
+		c.state = CircuitHalfOpen
+	}
+	if c.state == CircuitHalfOpen {
+		if c.probes >= b.config.HalfOpenRequests {
+			c.rejected++
+			return admission{}, fmt.Errorf("%w for %s requests; waiting for a probe request", ErrCircuitOpen, group)
+		}
+		c.probes++
+		return admission{probe: true}, nil
+	}
+	return admission{}, nil
+}
+
+// record updates group's circuit with the outcome of a request that allow let through as a.
+// Only probes decide a half-open circuit; requests admitted while it was closed only count while it still is.
+func (b *CircuitBreaker) record(ctx context.Context, group string, a admission, err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuit(group)
+	failed := isBreakerFailure(ctx, err)
+	if a.probe {
+		c.probes--
+		if c.state != CircuitHalfOpen {
+			return
+		}
+		switch {
+		case failed:
+			c.state = CircuitOpen
+			c.openedAt = b.now()
+		case !isCallerCancellation(ctx, err):
+			c.state = CircuitClosed
+			c.failures = 0
+		}
+		return
+	}
+	if c.state != CircuitClosed {
+		return
+	}
+	if !failed {
+		c.failures = 0
+		return
+	}
+	c.failures++
+	if c.failures >= b.config.FailureThreshold {
+		c.state = CircuitOpen
+		c.openedAt = b.now()
+	}
+}
+
+// release returns the probe slot, if any, that allow gave a request that was never sent.
+func (b *CircuitBreaker) release(group string, a admission) {
+	if b == nil || !a.probe {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.circuit(group).probes--
+}
+
+// isBreakerFailure reports whether err suggests the API itself is failing.
+func isBreakerFailure(ctx context.Context, err error) bool {
+	if err == nil || isCallerCancellation(ctx, err) {
+		return false
+	}
+	_, class := classifyError(err)
+	return class == errorClassServer || class == errorClassNetwork || class == errorClassTimeout
+}
+
+// isCallerCancellation reports whether err was caused by the caller cancelling its context.
+// Deadlines are not cancellations: a request that times out counts against the API.
+func isCallerCancellation(ctx context.Context, err error) bool {
+	return errors.Is(ctx.Err(), context.Canceled) && errors.Is(err, context.Canceled)
+}
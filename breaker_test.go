@@ -0,0 +1,162 @@
+package DarkThroneApi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker_Transitions(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 2, CoolDown: time.Minute})
+	b.now = func() time.Time { return now }
+	ctx := context.Background()
+	serverErr := &StatusError{StatusCode: 503, Status: "503 Service Unavailable"}
+
+	// Client errors do not trip the breaker
+	for i := 0; i < 3; i++ {
+		a, _ := b.allow("read")
+		b.record(ctx, "read", a, &StatusError{StatusCode: 404, Status: "404 Not Found"})
+	}
+	if b.State("read") != CircuitClosed {
+		t.Fatalf("state = %s after client errors", b.State("read"))
+	}
+
+	for i := 0; i < 2; i++ {
+		a, err := b.allow("read")
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.record(ctx, "read", a, serverErr)
+	}
+	if _, err := b.allow("read"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("allow = %v, want ErrCircuitOpen", err)
+	}
+	if _, err := b.allow("write"); err != nil {
+		t.Errorf("groups must be independent, got %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	if b.State("read") != CircuitHalfOpen {
+		t.Fatalf("state = %s after cool-down", b.State("read"))
+	}
+	probe, err := b.allow("read")
+	if err != nil || !probe.probe {
+		t.Fatalf("probe rejected: %v", err)
+	}
+	if _, err := b.allow("read"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("second concurrent probe allowed: %v", err)
+	}
+	b.record(ctx, "read", probe, serverErr)
+	if b.State("read") != CircuitOpen {
+		t.Fatalf("failed probe must reopen, state = %s", b.State("read"))
+	}
+
+	now = now.Add(time.Minute)
+	probe, _ = b.allow("read")
+	b.record(ctx, "read", probe, nil)
+	if b.State("read") != CircuitClosed {
+		t.Fatalf("successful probe must close, state = %s", b.State("read"))
+	}
+	stats := b.Stats()
+	if len(stats) != 2 || stats[0].Group != "read" || stats[0].Rejected != 2 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestCircuitBreaker_IgnoresCallerCancellation(t *testing.T) {
+	b := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	a, _ := b.allow("read")
+	b.record(ctx, "read", a, context.Canceled)
+	if b.State("read") != CircuitClosed {
+		t.Errorf("state = %s, cancellation must not count as a failure", b.State("read"))
+	}
+}
+
+func TestCircuitBreaker_OnlyProbesDecideHalfOpen(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, CoolDown: time.Minute})
+	b.now = func() time.Time { return now }
+	ctx := context.Background()
+	serverErr := &StatusError{StatusCode: 503, Status: "503 Service Unavailable"}
+
+	// A request admitted while closed is still in flight when the circuit opens and cools down.
+	slow, _ := b.allow("read")
+	tripping, _ := b.allow("read")
+	b.record(ctx, "read", tripping, serverErr)
+	now = now.Add(time.Minute)
+	probe, err := b.allow("read")
+	if err != nil || !probe.probe {
+		t.Fatalf("probe rejected: %v", err)
+	}
+
+	// Its success neither closes the circuit nor frees the probe slot.
+	b.record(ctx, "read", slow, nil)
+	if b.State("read") != CircuitHalfOpen {
+		t.Fatalf("state = %s, a non-probe request must not decide a half-open circuit", b.State("read"))
+	}
+	if _, err := b.allow("read"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("second probe allowed: %v", err)
+	}
+
+	// An unsent probe gives its slot back once.
+	b.release("read", probe)
+	probe, err = b.allow("read")
+	if err != nil || !probe.probe {
+		t.Fatalf("probe rejected after release: %v", err)
+	}
+	b.record(ctx, "read", probe, nil)
+	if b.State("read") != CircuitClosed {
+		t.Fatalf("successful probe must close, state = %s", b.State("read"))
+	}
+	b.mu.Lock()
+	probes := b.circuit("read").probes
+	b.mu.Unlock()
+	if probes != 0 {
+		t.Errorf("probes = %d, want 0", probes)
+	}
+}
+
+func TestCircuitBreaker_FailsFastInPipeline(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	api := newTestApi(t, server.URL)
+	api.token = "token"
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 2, CoolDown: time.Hour})
+	metrics := NewMetrics()
+	metrics.breaker = breaker
+	api.apiConfig.CircuitBreaker = breaker
+	api.apiConfig.Metrics = metrics
+
+	for i := 0; i < 5; i++ {
+		api.FetchPlayerByID("p1")
+	}
+	if hits.Load() != 2 {
+		t.Errorf("server was hit %d times, want 2", hits.Load())
+	}
+	if _, err := api.FetchPlayerByID("p1"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("err = %v, want ErrCircuitOpen", err)
+	}
+
+	var b strings.Builder
+	metrics.WriteTo(&b)
+	for _, want := range []string{
+		`darkthrone_circuit_breaker_state{group="read"} 1`,
+		`darkthrone_circuit_breaker_rejections_total{group="read"} 4`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("exposition is missing %q:\n%s", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package DarkThroneApi
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CacheEntry is a cached response body together with the validators needed to revalidate it.
+type CacheEntry struct {
+	Body         []byte    `json:"body"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	StoredAt     time.Time `json:"storedAt"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+// CacheBackend stores cache entries by key. Implementations must be safe for concurrent use.
+// Caching is best-effort, so backends swallow storage errors rather than failing requests.
+type CacheBackend interface {
+	Get(key string) (CacheEntry, bool)
+	Set(key string, entry CacheEntry)
+	Delete(key string)
+	Clear()
+}
+
+// ResponseCache is an opt-in cache for GET responses.
+// Entries are keyed by method, URL and the caller's token, so sessions never share data.
+type ResponseCache struct {
+	Backend CacheBackend
+	// DefaultTTL applies to GET endpoints without an entry in TTLs. Zero disables caching for them.
+	DefaultTTL time.Duration
+	// TTLs overrides DefaultTTL per endpoint name, e.g. "players.get" or "auth.current-user".
+	// A zero or negative TTL disables caching for that endpoint.
+	TTLs map[string]time.Duration
+}
+
+// ttl returns the time-to-live for responses of the named endpoint.
+func (c *ResponseCache) ttl(name string) time.Duration {
+	if ttl, ok := c.TTLs[name]; ok {
+		return ttl
+	}
+	return c.DefaultTTL
+}
+
+// Invalidate removes every cached response.
+// The client calls it automatically after successful mutating requests such as attacks, training, banking and assuming a player.
+func (c *ResponseCache) Invalidate() {
+	if c != nil && c.Backend != nil {
+		c.Backend.Clear()
+	}
+}
+
+// defaultMemoryCacheCapacity is used when NewMemoryCache is given a non-positive capacity.
+const defaultMemoryCacheCapacity = 256
+
+// MemoryCache is an in-memory CacheBackend that evicts the least recently used entry once full.
+type MemoryCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	items    map[string]*list.Element
+}
+
+type memoryCacheItem struct {
+	key   string
+	entry CacheEntry
+}
+
+// NewMemoryCache creates an in-memory LRU cache holding at most capacity entries.
+func NewMemoryCache(capacity int) *MemoryCache {
+	if capacity <= 0 {
+		capacity = defaultMemoryCacheCapacity
+	}
+	return &MemoryCache{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the entry stored under key and marks it as recently used.
+func (c *MemoryCache) Get(key string) (CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return CacheEntry{}, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*memoryCacheItem).entry, true
+}
+
+// Set stores entry under key, evicting the least recently used entry if the cache is full.
+func (c *MemoryCache) Set(key string, entry CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		el.Value.(*memoryCacheItem).entry = entry
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&memoryCacheItem{key: key, entry: entry})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*memoryCacheItem).key)
+	}
+}
+
+// Delete removes the entry stored under key.
+func (c *MemoryCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.order.Remove(el)
+		delete(c.items, key)
+	}
+}
+
+// Clear removes every entry.
+func (c *MemoryCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+}
+
+// Len returns the number of cached entries.
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// DiskCache is a CacheBackend that stores each entry as a JSON file in a directory,
+// so cached responses survive process restarts.
+type DiskCache struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewDiskCache creates a disk cache rooted at dir, creating the directory if needed.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+// path returns the file that holds the entry for key.
+func (c *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get reads the entry stored under key.
+func (c *DiskCache) Get(key string) (CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return CacheEntry{}, false
+	}
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return CacheEntry{}, false
+	}
+	return entry, true
+}
+
+// Set writes entry under key.
+func (c *DiskCache) Set(key string, entry CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	tmp := c.path(key) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return
+	}
+	_ = os.Rename(tmp, c.path(key))
+}
+
+// Delete removes the entry stored under key.
+func (c *DiskCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_ = os.Remove(c.path(key))
+}
+
+// Clear removes every cached entry from the directory.
+func (c *DiskCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	files, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		_ = os.Remove(f)
+	}
+}
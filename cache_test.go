@@ -0,0 +1,116 @@
+package DarkThroneApi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewMemoryCache(2)
+	c.Set("a", CacheEntry{Body: []byte("a")})
+	c.Set("b", CacheEntry{Body: []byte("b")})
+	c.Get("a")
+	c.Set("c", CacheEntry{Body: []byte("c")})
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Error("expected a to be kept")
+	}
+	if c.Len() != 2 {
+		t.Errorf("unexpected length: %d", c.Len())
+	}
+}
+
+func TestDiskCache_RoundTrip(t *testing.T) {
+	c, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c.Set("k", CacheEntry{Body: []byte(`{"id":"1"}`), ETag: `"v1"`})
+	entry, ok := c.Get("k")
+	if !ok || string(entry.Body) != `{"id":"1"}` || entry.ETag != `"v1"` {
+		t.Errorf("unexpected entry: %+v %v", entry, ok)
+	}
+	c.Clear()
+	if _, ok := c.Get("k"); ok {
+		t.Error("expected entry to be cleared")
+	}
+}
+
+func TestResponseCache_ServesFreshEntries(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Write([]byte(`{"id":"p1","gold":10}`))
+	}))
+	defer ts.Close()
+
+	d := newTestApi(t, ts.URL)
+	d.token = "tok"
+	d.apiConfig.Cache = &ResponseCache{Backend: NewMemoryCache(10), DefaultTTL: time.Minute}
+	for range 3 {
+		p, err := d.FetchPlayerByID("p1")
+		if err != nil || p.Gold != 10 {
+			t.Fatalf("unexpected result: %+v %v", p, err)
+		}
+	}
+	if hits.Load() != 1 {
+		t.Errorf("expected 1 request, got %d", hits.Load())
+	}
+}
+
+func TestResponseCache_RevalidatesWithETag(t *testing.T) {
+	var conditional atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditional.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"id":"p1","gold":10}`))
+	}))
+	defer ts.Close()
+
+	d := newTestApi(t, ts.URL)
+	d.token = "tok"
+	d.apiConfig.Cache = &ResponseCache{Backend: NewMemoryCache(10), TTLs: map[string]time.Duration{"players.get": time.Nanosecond}}
+	for range 2 {
+		time.Sleep(time.Millisecond)
+		p, err := d.FetchPlayerByID("p1")
+		if err != nil || p.Gold != 10 {
+			t.Fatalf("unexpected result: %+v %v", p, err)
+		}
+	}
+	if conditional.Load() != 1 {
+		t.Errorf("expected 1 conditional request, got %d", conditional.Load())
+	}
+}
+
+func TestResponseCache_InvalidatedByMutatingCall(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"success":true}`))
+	}))
+	defer ts.Close()
+
+	d := newTestApi(t, ts.URL)
+	d.token = "tok"
+	backend := NewMemoryCache(10)
+	d.apiConfig.Cache = &ResponseCache{Backend: backend, DefaultTTL: time.Minute}
+	if _, err := d.FetchPlayerByID("p1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if backend.Len() != 1 {
+		t.Fatalf("expected a cached entry, got %d", backend.Len())
+	}
+	if _, err := d.DepositGold(BankDepositRequest{PlayerID: "p1", Amount: 5}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if backend.Len() != 0 {
+		t.Errorf("expected cache to be invalidated, got %d entries", backend.Len())
+	}
+}
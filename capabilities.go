@@ -0,0 +1,259 @@
+package DarkThroneApi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Feature names an optional part of the API that not every server version provides.
+type Feature string
+
+const (
+	FeatureStructureUpgrades Feature = "structure-upgrades"
+	FeatureProficiencyPoints Feature = "proficiency-points"
+)
+
+// featureEndpoints maps each feature to the endpoint probed to detect it.
+var featureEndpoints = map[Feature]endpointName{
+	FeatureStructureUpgrades: epStructuresUpgrade,
+	FeatureProficiencyPoints: epProficiencyPointsSpend,
+}
+
+// ErrFeatureUnavailable matches every *FeatureUnavailableError with errors.Is.
+var ErrFeatureUnavailable = errors.New("feature is not available")
+
+// FeatureUnavailableError is returned when the server does not provide a feature. Once that is known,
+// the endpoint is no longer called.
+type FeatureUnavailableError struct {
+	Feature Feature
+}
+
+func (e *FeatureUnavailableError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrFeatureUnavailable, e.Feature)
+}
+
+// Is reports whether target is ErrFeatureUnavailable.
+func (e *FeatureUnavailableError) Is(target error) bool {
+	return target == ErrFeatureUnavailable
+}
+
+// Capabilities records which optional features the server provides.
+type Capabilities struct {
+	StructureUpgrades bool
+	ProficiencyPoints bool
+	// IdempotencyKeys is set once a response echoes the Idempotency-Key header of its request.
+	// Set it in configuration to retry ambiguous mutations with the same key without re-reading state.
+	IdempotencyKeys bool
+	// ProbedAt is when the capabilities were probed; zero if they were set by configuration.
+	ProbedAt time.Time
+}
+
+// Has reports whether feature is available.
+func (c Capabilities) Has(feature Feature) bool {
+	switch feature {
+	case FeatureStructureUpgrades:
+		return c.StructureUpgrades
+	case FeatureProficiencyPoints:
+		return c.ProficiencyPoints
+	}
+	return false
+}
+
+func (c *Capabilities) set(feature Feature, available bool) {
+	switch feature {
+	case FeatureStructureUpgrades:
+		c.StructureUpgrades = available
+	case FeatureProficiencyPoints:
+		c.ProficiencyPoints = available
+	}
+}
+
+// capabilityRecheck is how long a probe that found a feature missing is trusted before probing again.
+var capabilityRecheck = time.Hour
+
+// capabilityState holds the probed capabilities of a client.
+type capabilityState struct {
+	mu   sync.Mutex
+	caps *Capabilities
+	// idempotencyKeys is learned from responses rather than probed.
+	idempotencyKeys bool
+}
+
+// Capabilities returns the configured capabilities, or the last probed ones.
+// It reports false if neither is known yet.
+func (d *DarkThroneApi) Capabilities() (Capabilities, bool) {
+	if d.config != nil && d.config.Capabilities != nil {
+		return *d.config.Capabilities, true
+	}
+	d.capabilities.mu.Lock()
+	defer d.capabilities.mu.Unlock()
+	if d.capabilities.caps == nil {
+		return Capabilities{}, false
+	}
+	caps := *d.capabilities.caps
+	caps.IdempotencyKeys = d.capabilities.idempotencyKeys
+	return caps, true
+}
+
+// ErrCapabilitiesUnknown is returned when a probe answer does not say whether a feature exists,
+// such as an outage or an authorization failure. Nothing is recorded, so the next use probes again.
+var ErrCapabilitiesUnknown = errors.New("feature availability is unknown")
+
+// ProbeCapabilities asks the server which optional features it provides and remembers the result.
+// Each feature route is sent an OPTIONS request, which changes nothing on the server; see probeAnswer
+// for how answers are read. Capabilities set in Config take precedence over probed ones.
+func (d *DarkThroneApi) ProbeCapabilities(ctx context.Context) (Capabilities, error) {
+	caps := Capabilities{}
+	for feature, name := range featureEndpoints {
+		available, err := d.probeEndpoint(ctx, name)
+		if err != nil {
+			return Capabilities{}, fmt.Errorf("probing %s: %w", feature, err)
+		}
+		caps.set(feature, available)
+	}
+	caps.ProbedAt = time.Now()
+	d.capabilities.mu.Lock()
+	d.capabilities.caps = &caps
+	d.capabilities.mu.Unlock()
+	d.config.Logger.Info("Probed server capabilities", "structure_upgrades", caps.StructureUpgrades, "proficiency_points", caps.ProficiencyPoints)
+	return caps, nil
+}
+
+// probeEndpoint sends an OPTIONS request to the endpoint's route and reports whether the route exists.
+// The probe passes the circuit breaker, rate limit and metrics like any other request.
+// See probeAnswer for how the response is read; transport errors are returned as they are.
+func (d *DarkThroneApi) probeEndpoint(ctx context.Context, name endpointName) (bool, error) {
+	ep, err := lookupEndpoint(name)
+	if err != nil {
+		return false, err
+	}
+	headers, err := d.endpointHeaders(ep)
+	if err != nil {
+		return false, err
+	}
+	if err := d.health.Load().WaitUntilAvailable(ctx); err != nil {
+		return false, err
+	}
+	probe := &ApiRequest[struct{}, struct{}]{
+		Name:           string(name) + ".probe",
+		Endpoint:       ep.Path,
+		Method:         http.MethodOptions,
+		Headers:        headers,
+		RateLimitGroup: ep.RateLimitGroup,
+		Config:         d.apiConfig,
+	}
+	start := time.Now()
+	available, err := probe.sendProbe(ctx, ep)
+	probe.metrics().observeRequest(probe.metricsEndpoint(), err, time.Since(start))
+	return available, err
+}
+
+// sendProbe performs the OPTIONS round trip of probeEndpoint and reads the answer.
+func (req *ApiRequest[Req, Resp]) sendProbe(ctx context.Context, ep endpoint) (bool, error) {
+	breaker := req.circuitBreaker()
+	admitted, err := breaker.allow(req.RateLimitGroup)
+	if err != nil {
+		return false, err
+	}
+	waitStart := time.Now()
+	err = waitForRateLimit(ctx, req.RateLimitGroup)
+	req.metrics().observeRateLimitWait(req.RateLimitGroup, time.Since(waitStart))
+	if err != nil {
+		breaker.release(req.RateLimitGroup, admitted)
+		return false, err
+	}
+	probeURL, err := req.buildURL()
+	if err != nil {
+		breaker.release(req.RateLimitGroup, admitted)
+		return false, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, probeURL.String(), nil)
+	if err != nil {
+		breaker.release(req.RateLimitGroup, admitted)
+		return false, err
+	}
+	for k, v := range req.Headers {
+		httpReq.Header.Set(k, v)
+	}
+	injectTraceContext(ctx, httpReq.Header)
+	client := &http.Client{}
+	resp, err := client.Do(httpReq)
+	var available bool
+	if err == nil {
+		resp.Body.Close()
+		available, err = probeAnswer(ep, resp)
+	}
+	breaker.record(ctx, req.RateLimitGroup, admitted, err)
+	return available, err
+}
+
+// probeAnswer reads the response to an OPTIONS probe of ep.
+// A 2xx or a 400/422 validation answer means the route exists, a 404 or 501 that it does not, and a 405
+// is decided by whether the Allow header lists the endpoint's method. Any other status returns
+// ErrCapabilitiesUnknown, wrapping the *StatusError.
+func probeAnswer(ep endpoint, resp *http.Response) (bool, error) {
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300,
+		resp.StatusCode == http.StatusBadRequest, resp.StatusCode == http.StatusUnprocessableEntity:
+		return true, nil
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusNotImplemented:
+		return false, nil
+	case resp.StatusCode == http.StatusMethodNotAllowed:
+		for _, method := range strings.Split(resp.Header.Get("Allow"), ",") {
+			if strings.EqualFold(strings.TrimSpace(method), ep.Method) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return false, fmt.Errorf("%w: %s answered: %w", ErrCapabilitiesUnknown, ep.Path,
+		&StatusError{StatusCode: resp.StatusCode, Status: resp.Status})
+}
+
+// requireFeature returns a *FeatureUnavailableError unless the server provides feature.
+// Capabilities are probed on first use, and again once capabilityRecheck has passed while the feature is missing.
+func (d *DarkThroneApi) requireFeature(ctx context.Context, feature Feature) error {
+	caps, known := d.Capabilities()
+	if !known && d.dryRun() {
+		// Simulate optional features rather than depend on a server the dry run may not reach.
+		return nil
+	}
+	stale := known && !caps.ProbedAt.IsZero() && !caps.Has(feature) && time.Since(caps.ProbedAt) >= capabilityRecheck
+	if !known || stale {
+		var err error
+		if caps, err = d.ProbeCapabilities(ctx); err != nil {
+			return err
+		}
+	}
+	if !caps.Has(feature) {
+		return &FeatureUnavailableError{Feature: feature}
+	}
+	return nil
+}
+
+// featureResult returns err from a call to a feature's endpoint. A 404 or 501 means the server lacks the
+// feature after all, as when a CORS layer answers every OPTIONS probe with 2xx. That is recorded like a
+// probe that found the feature missing, and a *FeatureUnavailableError is returned instead.
+func (d *DarkThroneApi) featureResult(feature Feature, err error) error {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) ||
+		(statusErr.StatusCode != http.StatusNotFound && statusErr.StatusCode != http.StatusNotImplemented) {
+		return err
+	}
+	d.capabilities.mu.Lock()
+	// Without probed capabilities the configured ones apply, and they are left to the configuration.
+	if d.capabilities.caps != nil {
+		caps := *d.capabilities.caps
+		caps.set(feature, false)
+		caps.ProbedAt = time.Now()
+		d.capabilities.caps = &caps
+	}
+	d.capabilities.mu.Unlock()
+	d.config.Logger.Warn("Server does not provide feature", "feature", feature, "status", statusErr.Status)
+	return &FeatureUnavailableError{Feature: feature}
+}
@@ -0,0 +1,232 @@
+package DarkThroneApi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newCapabilityServer serves structures/upgrade and proficiency-points, answering OPTIONS probes and
+// real requests with the given statuses. Probes that succeed list POST in the Allow header.
+func newCapabilityServer(t *testing.T, structures, proficiency *atomic.Int32, probes *atomic.Int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := structures
+		if r.URL.Path == "/proficiency-points" {
+			status = proficiency
+		}
+		code := int(status.Load())
+		if r.Method == http.MethodOptions {
+			probes.Add(1)
+			if code == http.StatusOK {
+				w.Header().Set("Allow", "POST, OPTIONS")
+				code = http.StatusNoContent
+			}
+			w.WriteHeader(code)
+			return
+		}
+		if r.ContentLength == 0 {
+			t.Errorf("unexpected empty %s %s", r.Method, r.URL.Path)
+		}
+		if code != http.StatusOK {
+			w.WriteHeader(code)
+			return
+		}
+		w.Write([]byte(`{"success":true}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func statusOf(code int) *atomic.Int32 {
+	var v atomic.Int32
+	v.Store(int32(code))
+	return &v
+}
+
+func TestProbeCapabilities(t *testing.T) {
+	var probes atomic.Int32
+	server := newCapabilityServer(t, statusOf(http.StatusNotFound), statusOf(http.StatusBadRequest), &probes)
+	api := newTestApi(t, server.URL)
+	api.token = "t"
+
+	if _, known := api.Capabilities(); known {
+		t.Fatal("capabilities known before probing")
+	}
+	caps, err := api.ProbeCapabilities(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caps.StructureUpgrades || !caps.ProficiencyPoints || caps.ProbedAt.IsZero() {
+		t.Errorf("caps = %+v, want only proficiency points", caps)
+	}
+	if got, _ := api.Capabilities(); got != caps {
+		t.Errorf("Capabilities() = %+v, want %+v", got, caps)
+	}
+}
+
+func TestRequireFeature_ProbesOnceAndGates(t *testing.T) {
+	var probes atomic.Int32
+	server := newCapabilityServer(t, statusOf(http.StatusMethodNotAllowed), statusOf(http.StatusOK), &probes)
+	api := newTestApi(t, server.URL)
+	api.token = "t"
+
+	_, err := api.UpgradeStructure(UpgradeStructureRequest{StructureID: "s1", UpgradeLevel: 1})
+	var unavailable *FeatureUnavailableError
+	if !errors.Is(err, ErrFeatureUnavailable) || !errors.As(err, &unavailable) || unavailable.Feature != FeatureStructureUpgrades {
+		t.Fatalf("err = %v, want FeatureUnavailableError for structure upgrades", err)
+	}
+	resp, err := api.SpendProficiencyPoints(ProficiencyPointsRequest{PlayerID: "p1", PointsToSpend: 1, ProficiencyType: "strength"})
+	if err != nil || !resp.Success {
+		t.Fatalf("SpendProficiencyPoints = %+v, %v", resp, err)
+	}
+	if n := probes.Load(); n != 2 {
+		t.Errorf("probes = %d, want one per feature", n)
+	}
+}
+
+func TestRequireFeature_RechecksMissingFeatures(t *testing.T) {
+	previous := capabilityRecheck
+	capabilityRecheck = 0
+	t.Cleanup(func() { capabilityRecheck = previous })
+
+	var probes atomic.Int32
+	structures := statusOf(http.StatusNotImplemented)
+	server := newCapabilityServer(t, structures, statusOf(http.StatusOK), &probes)
+	api := newTestApi(t, server.URL)
+	api.token = "t"
+
+	if _, err := api.UpgradeStructure(UpgradeStructureRequest{StructureID: "s1", UpgradeLevel: 1}); !errors.Is(err, ErrFeatureUnavailable) {
+		t.Fatalf("err = %v, want ErrFeatureUnavailable", err)
+	}
+	structures.Store(http.StatusOK) // the game ships the feature
+	if _, err := api.UpgradeStructure(UpgradeStructureRequest{StructureID: "s1", UpgradeLevel: 1}); err != nil {
+		t.Fatalf("UpgradeStructure after release: %v", err)
+	}
+}
+
+func TestRequireFeature_ConfigOverride(t *testing.T) {
+	var probes atomic.Int32
+	server := newCapabilityServer(t, statusOf(http.StatusOK), statusOf(http.StatusOK), &probes)
+	api := newTestApi(t, server.URL)
+	api.token = "t"
+	api.config.Capabilities = &Capabilities{StructureUpgrades: true}
+
+	if _, err := api.UpgradeStructure(UpgradeStructureRequest{StructureID: "s1", UpgradeLevel: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.SpendProficiencyPoints(ProficiencyPointsRequest{PlayerID: "p1", PointsToSpend: 1, ProficiencyType: ProficiencyWealth}); !errors.Is(err, ErrFeatureUnavailable) {
+		t.Errorf("err = %v, want ErrFeatureUnavailable from the override", err)
+	}
+	if n := probes.Load(); n != 0 {
+		t.Errorf("probes = %d, want none with an override", n)
+	}
+}
+
+func TestProbeCapabilities_NetworkError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	api := newTestApi(t, server.URL)
+	api.token = "t"
+	if _, err := api.ProbeCapabilities(context.Background()); err == nil {
+		t.Fatal("expected an error when the server is unreachable")
+	}
+	if _, known := api.Capabilities(); known {
+		t.Error("a failed probe should not record capabilities")
+	}
+}
+
+func TestProbeCapabilities_UnknownAnswersAreNotCached(t *testing.T) {
+	for _, code := range []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusUnauthorized, http.StatusForbidden} {
+		var probes atomic.Int32
+		server := newCapabilityServer(t, statusOf(code), statusOf(http.StatusOK), &probes)
+		api := newTestApi(t, server.URL)
+		api.token = "t"
+
+		if _, err := api.ProbeCapabilities(context.Background()); !errors.Is(err, ErrCapabilitiesUnknown) {
+			t.Errorf("%d: err = %v, want ErrCapabilitiesUnknown", code, err)
+		}
+		if _, known := api.Capabilities(); known {
+			t.Errorf("%d: an unknown answer should not record capabilities", code)
+		}
+		if _, err := api.UpgradeStructure(UpgradeStructureRequest{StructureID: "s1", UpgradeLevel: 1}); !errors.Is(err, ErrCapabilitiesUnknown) {
+			t.Errorf("%d: UpgradeStructure err = %v, want ErrCapabilitiesUnknown", code, err)
+		}
+	}
+}
+
+func TestProbeEndpoint_MethodNotAllowedUsesAllowHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/structures/upgrade" {
+			w.Header().Set("Allow", "POST")
+		}
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer server.Close()
+	api := newTestApi(t, server.URL)
+	api.token = "t"
+
+	caps, err := api.ProbeCapabilities(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !caps.StructureUpgrades || caps.ProficiencyPoints {
+		t.Errorf("caps = %+v, want only structure upgrades", caps)
+	}
+}
+
+func TestUpgradeStructure_NotFoundAfterCORSProbeIsFeatureUnavailable(t *testing.T) {
+	var posts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			// A CORS preflight answer, given for every path.
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		posts.Add(1)
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+	api := newTestApi(t, server.URL)
+	api.token = "t"
+
+	for i := 0; i < 2; i++ {
+		_, err := api.UpgradeStructure(UpgradeStructureRequest{StructureID: "s1", UpgradeLevel: 1})
+		if !errors.Is(err, ErrFeatureUnavailable) {
+			t.Fatalf("call %d: err = %v, want ErrFeatureUnavailable", i, err)
+		}
+	}
+	if n := posts.Load(); n != 1 {
+		t.Errorf("posts = %d, want the missing feature remembered after one", n)
+	}
+	if caps, _ := api.Capabilities(); caps.StructureUpgrades || !caps.ProficiencyPoints {
+		t.Errorf("caps = %+v, want only proficiency points", caps)
+	}
+}
+
+func TestProbeCapabilities_UsesBreakerAndMetrics(t *testing.T) {
+	var probes atomic.Int32
+	server := newCapabilityServer(t, statusOf(http.StatusServiceUnavailable), statusOf(http.StatusOK), &probes)
+	api := newTestApi(t, server.URL)
+	api.token = "t"
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, CoolDown: time.Hour})
+	api.apiConfig.CircuitBreaker = breaker
+	api.apiConfig.Metrics = NewMetrics()
+
+	if _, err := api.ProbeCapabilities(context.Background()); !errors.Is(err, ErrCapabilitiesUnknown) {
+		t.Fatalf("err = %v, want ErrCapabilitiesUnknown", err)
+	}
+	if _, err := api.ProbeCapabilities(context.Background()); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("err = %v, want ErrCircuitOpen after a failed probe", err)
+	}
+	var b strings.Builder
+	api.apiConfig.Metrics.WriteTo(&b)
+	if !strings.Contains(b.String(), `endpoint="structures.upgrade.probe",status="503"`) {
+		t.Errorf("exposition is missing the probe:\n%s", b.String())
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Rihoj/DarkThroneApi/audit"
+)
+
+// runAudit implements "darkthrone audit verify|query".
+func runAudit(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: darkthrone audit verify|query [flags] FILE")
+	}
+	command := args[0]
+	fs := flag.NewFlagSet("audit "+command, flag.ContinueOnError)
+	player := fs.String("player", "", "only entries for this player ID (query)")
+	actions := fs.String("action", "", "comma-separated actions to keep, such as bank.deposit (query)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: darkthrone audit %s [flags] FILE", command)
+	}
+	path := fs.Arg(0)
+
+	switch command {
+	case "verify":
+		n, err := audit.VerifyFile(path)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s: %d entries, hash chain intact\n", path, n)
+		return nil
+	case "query":
+		filter := audit.Filter{PlayerID: *player}
+		if *actions != "" {
+			filter.Actions = strings.Split(*actions, ",")
+		}
+		entries, err := audit.QueryFile(path, filter)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(os.Stdout)
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown audit command %q", command)
+}
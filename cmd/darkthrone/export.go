@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	DarkThroneApi "github.com/Rihoj/DarkThroneApi"
+	"github.com/Rihoj/DarkThroneApi/audit"
+	"github.com/Rihoj/DarkThroneApi/export"
+)
+
+// runExport implements "darkthrone export <dataset>".
+func runExport(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: darkthrone export war-history|players|own-players|bank -format FORMAT -out FILE")
+	}
+	dataset := args[0]
+	fs := flag.NewFlagSet("export "+dataset, flag.ContinueOnError)
+	verbose := commonFlags(fs)
+	formatName := fs.String("format", "csv", "output format: csv, ndjson or sqlite")
+	out := fs.String("out", "", "output file (required)")
+	playerIndex := fs.Int("player-index", 0, "index of the player to assume for war history")
+	auditLog := fs.String("audit-log", "", "audit log to read bank transactions from (bank, required)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *out == "" {
+		return errors.New("-out is required")
+	}
+	format, err := export.ParseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+	if dataset == "bank" {
+		return exportBank(*auditLog, *out, format)
+	}
+
+	api, err := login(*verbose)
+	if err != nil {
+		return err
+	}
+
+	var added int
+	switch dataset {
+	case "war-history":
+		if _, err := api.GetPlayerByIndex(*playerIndex); err != nil {
+			return err
+		}
+		history, err := api.FetchAllWarHistory()
+		if err != nil {
+			return fmt.Errorf("failed to fetch war history: %w", err)
+		}
+		added, err = export.WarHistory(*out, format, history)
+		if err != nil {
+			return err
+		}
+	case "players":
+		players, err := crawlPlayers(api)
+		if err != nil {
+			return err
+		}
+		added, err = export.Players(*out, format, "players", players)
+		if err != nil {
+			return err
+		}
+	case "own-players":
+		players, err := api.GetPlayersForCurrentUser()
+		if err != nil {
+			return fmt.Errorf("failed to fetch own players: %w", err)
+		}
+		added, err = export.Players(*out, format, "own_players", players)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown export dataset %q", dataset)
+	}
+	fmt.Printf("exported %d %s records to %s\n", added, dataset, *out)
+	return nil
+}
+
+// exportBank exports the bank ledger recorded in an audit log. The API has no ledger endpoint,
+// so deposits and withdrawals are taken from the log a client writes with Config.AuditLog.
+func exportBank(auditLog, out string, format export.Format) error {
+	if auditLog == "" {
+		return errors.New("-audit-log is required for the bank dataset: the API has no bank ledger, so it is rebuilt from the client's audit log")
+	}
+	if _, err := audit.VerifyFile(auditLog); err != nil {
+		return err
+	}
+	entries, err := audit.QueryFile(auditLog, audit.Filter{Actions: []string{"bank.deposit", "bank.withdraw"}})
+	if err != nil {
+		return err
+	}
+	transactions, err := export.BankTransactionsFromAudit(entries)
+	if err != nil {
+		return err
+	}
+	added, err := export.BankLedger(out, format, transactions)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("exported %d bank records to %s\n", added, out)
+	return nil
+}
+
+// login creates a client and authenticates with the credentials from the environment.
+func login(verbose bool) (*DarkThroneApi.DarkThroneApi, error) {
+	email, password := os.Getenv("DARKTHRONE_EMAIL"), os.Getenv("DARKTHRONE_PASSWORD")
+	if email == "" || password == "" {
+		return nil, errors.New("DARKTHRONE_EMAIL and DARKTHRONE_PASSWORD must be set")
+	}
+	api := DarkThroneApi.New(&DarkThroneApi.Config{Logger: newLogger(verbose)})
+	if _, err := api.Login(DarkThroneApi.LoginRequest{Email: email, Password: password}); err != nil {
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+	return api, nil
+}
+
+// crawlPlayers walks every page of the players list.
+func crawlPlayers(api *DarkThroneApi.DarkThroneApi) ([]DarkThroneApi.Player, error) {
+	const pageSize = 100
+	var all []DarkThroneApi.Player
+	for page := 1; ; page++ {
+		players, err := api.FetchAllPlayers(page, pageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch players page %d: %w", page, err)
+		}
+		// Stop if the server ignored pagination and repeated the previous page
+		if len(players) > 0 && len(all) >= pageSize && all[len(all)-pageSize].ID == players[0].ID {
+			return all, nil
+		}
+		all = append(all, players...)
+		if len(players) < pageSize {
+			return all, nil
+		}
+	}
+}
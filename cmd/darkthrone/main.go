@@ -0,0 +1,69 @@
+// Command darkthrone is a command-line companion for the DarkThroneApi client.
+//
+// Usage:
+//
+//	darkthrone export war-history  -format csv|ndjson|sqlite -out FILE [-player-index N]
+//	darkthrone export players      -format csv|ndjson|sqlite -out FILE
+//	darkthrone export own-players  -format csv|ndjson|sqlite -out FILE
+//	darkthrone export bank         -format csv|ndjson|sqlite -out FILE -audit-log AUDIT
+//	darkthrone audit verify FILE
+//	darkthrone audit query [-player ID] [-action NAME,...] FILE
+//
+// The API has no bank ledger endpoint; the bank dataset is rebuilt from an audit log written by a
+// client configured with Config.AuditLog, and needs no credentials.
+//
+// Credentials are read from the DARKTHRONE_EMAIL and DARKTHRONE_PASSWORD environment variables.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "darkthrone:", err)
+		os.Exit(1)
+	}
+}
+
+// run dispatches to the subcommand named by the first argument.
+func run(args []string) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	switch args[0] {
+	case "export":
+		return runExport(args[1:])
+	case "audit":
+		return runAudit(args[1:])
+	case "help", "-h", "--help":
+		fmt.Println(usage)
+		return nil
+	}
+	return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+}
+
+const usage = `usage: darkthrone <command> [arguments]
+
+commands:
+  export war-history|players|own-players   export data to CSV, NDJSON or SQLite
+  export bank -audit-log AUDIT             export the bank ledger recorded in an audit log
+  audit verify|query FILE                  check or search an audit log`
+
+// newLogger returns the logger used by subcommands; verbose enables debug output.
+func newLogger(verbose bool) *slog.Logger {
+	level := slog.LevelWarn
+	if verbose {
+		level = slog.LevelDebug
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+}
+
+// commonFlags registers the flags shared by every subcommand.
+func commonFlags(fs *flag.FlagSet) (verbose *bool) {
+	return fs.Bool("v", false, "enable debug logging")
+}
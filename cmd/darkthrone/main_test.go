@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Rihoj/DarkThroneApi/audit"
+)
+
+func TestRun_UnknownCommand(t *testing.T) {
+	if err := run([]string{"bogus"}); err == nil || !strings.Contains(err.Error(), "unknown command") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRunExport_RequiresOut(t *testing.T) {
+	if err := run([]string{"export", "war-history", "-format", "csv"}); err == nil || !strings.Contains(err.Error(), "-out") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRunExport_RejectsUnknownFormat(t *testing.T) {
+	if err := run([]string{"export", "players", "-format", "xml", "-out", "x"}); err == nil || !strings.Contains(err.Error(), "format") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRunExport_BankRequiresAuditLog(t *testing.T) {
+	if err := run([]string{"export", "bank", "-out", "x"}); err == nil || !strings.Contains(err.Error(), "-audit-log") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRunExport_BankFromAuditLog(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.ndjson")
+	log, err := audit.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.Append(audit.Entry{Action: "bank.deposit", Phase: audit.PhaseIntent, Key: "k1", Payload: []byte(`{"amount":5}`)})
+	log.Append(audit.Entry{Action: "bank.deposit", Phase: audit.PhaseOutcome, Key: "k1", Outcome: "done", Response: []byte(`{"balance":5}`)})
+	log.Close()
+	out := filepath.Join(dir, "bank.csv")
+	if err := run([]string{"export", "bank", "-format", "csv", "-out", out, "-audit-log", path}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(out)
+	if !strings.Contains(string(data), "k1") {
+		t.Errorf("bank export = %q", data)
+	}
+}
+
+func TestRunAudit_VerifyRequiresFile(t *testing.T) {
+	if err := run([]string{"audit", "verify"}); err == nil || !strings.Contains(err.Error(), "FILE") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRunAudit_VerifyDetectsTampering(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.ndjson")
+	log, err := audit.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.Append(audit.Entry{Action: "bank.deposit", Phase: audit.PhaseIntent})
+	log.Close()
+	if err := run([]string{"audit", "verify", path}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	os.WriteFile(path, []byte(strings.Replace(string(data), "bank.deposit", "bank.withdraw", 1)), 0o600)
+	if err := run([]string{"audit", "verify", path}); !errors.Is(err, audit.ErrTampered) {
+		t.Errorf("err = %v, want ErrTampered", err)
+	}
+}
@@ -0,0 +1,80 @@
+package DarkThroneApi
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Coalescer deduplicates identical concurrent GET requests, in the style of singleflight.
+// While a request is in flight, callers with the same method, URL and auth identity wait for
+// it and share its response instead of issuing their own HTTP call and rate-limit slot.
+type Coalescer struct {
+	mu       sync.Mutex
+	calls    map[string]*coalescedCall
+	executed atomic.Int64
+	shared   atomic.Int64
+}
+
+type coalescedCall struct {
+	done chan struct{}
+	body []byte
+	err  error
+}
+
+// CoalescerStats reports how many requests were sent and how many were served from another caller's request.
+type CoalescerStats struct {
+	Executed int64
+	Shared   int64
+}
+
+// NewCoalescer creates an empty Coalescer.
+func NewCoalescer() *Coalescer {
+	return &Coalescer{calls: make(map[string]*coalescedCall)}
+}
+
+// Stats returns a snapshot of the coalescing counters.
+func (c *Coalescer) Stats() CoalescerStats {
+	return CoalescerStats{Executed: c.executed.Load(), Shared: c.shared.Load()}
+}
+
+// coalescedCallTimeout bounds a shared request, which no longer follows the context of any one caller.
+var coalescedCallTimeout = time.Minute
+
+// do runs fn once for all concurrent callers with the same key and returns its result to each of them.
+// The body is shared, so callers must treat it as read-only. fn runs with the values of the first
+// caller's ctx but not its cancellation, bounded by coalescedCallTimeout instead, so one caller giving
+// up does not fail the others. Each caller stops waiting when its own ctx is done.
+func (c *Coalescer) do(ctx context.Context, key string, fn func(context.Context) ([]byte, error)) ([]byte, error) {
+	c.mu.Lock()
+	call, ok := c.calls[key]
+	if ok {
+		c.shared.Add(1)
+	} else {
+		call = &coalescedCall{done: make(chan struct{})}
+		c.calls[key] = call
+		c.executed.Add(1)
+		go c.run(ctx, key, call, fn)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.body, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// run performs call for do and hands its result to the waiting callers.
+func (c *Coalescer) run(ctx context.Context, key string, call *coalescedCall, fn func(context.Context) ([]byte, error)) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), coalescedCallTimeout)
+	defer cancel()
+	call.body, call.err = fn(ctx)
+
+	c.mu.Lock()
+	delete(c.calls, key)
+	c.mu.Unlock()
+	close(call.done)
+}
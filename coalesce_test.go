@@ -0,0 +1,109 @@
+package DarkThroneApi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoalescer_SharesConcurrentGets(t *testing.T) {
+	var hits atomic.Int32
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		w.Write([]byte(`{"id":"p1","gold":7}`))
+	}))
+	defer ts.Close()
+
+	d := newTestApi(t, ts.URL)
+	d.token = "tok"
+	coalescer := NewCoalescer()
+	d.apiConfig.Coalescer = coalescer
+
+	const callers = 5
+	var wg sync.WaitGroup
+	results := make([]Player, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = d.FetchPlayerByID("p1")
+		}()
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for coalescer.Stats().Shared < callers-1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if hits.Load() != 1 {
+		t.Errorf("expected 1 request, got %d", hits.Load())
+	}
+	if stats := coalescer.Stats(); stats.Executed != 1 || stats.Shared != callers-1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	for _, p := range results {
+		if p.Gold != 7 {
+			t.Errorf("unexpected result: %+v", p)
+		}
+	}
+}
+
+func TestApiRequest_CoalescerOnlyForGet(t *testing.T) {
+	config := &ApiRequestConfig{BaseURL: "http://localhost", Coalescer: NewCoalescer()}
+	post := ApiRequest[struct{}, struct{}]{Method: "POST", Config: config}
+	if post.coalescer() != nil {
+		t.Error("POST requests must not be coalesced")
+	}
+	get := ApiRequest[struct{}, struct{}]{Method: "GET", Config: config}
+	if get.coalescer() == nil {
+		t.Error("expected GET requests to be coalesced")
+	}
+}
+
+func TestCoalescer_FirstCallerCancellingDoesNotFailOthers(t *testing.T) {
+	c := NewCoalescer()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	fn := func(ctx context.Context) ([]byte, error) {
+		close(started)
+		select {
+		case <-release:
+			return []byte("body"), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := c.do(first, "k", fn)
+		firstErr <- err
+	}()
+	<-started
+	second := make(chan []byte, 1)
+	go func() {
+		body, _ := c.do(context.Background(), "k", fn)
+		second <- body
+	}()
+	for c.Stats().Shared < 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("first caller err = %v, want context.Canceled", err)
+	}
+	close(release)
+	if body := <-second; string(body) != "body" {
+		t.Errorf("second caller body = %q, want the shared response", body)
+	}
+}
@@ -0,0 +1,339 @@
+package DarkThroneApi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// contractServer is a fake API that serves every operation in openapi.json. It validates each request
+// against the spec and answers with a response generated from, and validated against, the spec.
+type contractServer struct {
+	t      *testing.T
+	spec   map[string]any
+	mu     sync.Mutex
+	called map[string]bool
+	*httptest.Server
+}
+
+func newContractServer(t *testing.T) *contractServer {
+	t.Helper()
+	data, err := os.ReadFile("openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &contractServer{t: t, called: map[string]bool{}}
+	if err := json.Unmarshal(data, &s.spec); err != nil {
+		t.Fatal(err)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *contractServer) serve(w http.ResponseWriter, r *http.Request) {
+	op, ok := s.operation(r.Method, r.URL.Path)
+	if !ok {
+		s.t.Errorf("%s %s is not in the spec", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	id := op["operationId"].(string)
+	s.mu.Lock()
+	s.called[id] = true
+	s.mu.Unlock()
+
+	if _, secured := op["security"]; secured && !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		s.t.Errorf("%s: missing bearer token", id)
+	}
+	declared := map[string]bool{}
+	for _, p := range asSlice(op["parameters"]) {
+		param := p.(map[string]any)
+		if param["in"] == "query" {
+			declared[param["name"].(string)] = true
+		}
+	}
+	for key := range r.URL.Query() {
+		if !declared[key] {
+			s.t.Errorf("%s: undeclared query parameter %q", id, key)
+		}
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	if requestSchema := s.contentSchema(op["requestBody"]); requestSchema != nil {
+		var payload any
+		if err := json.Unmarshal(body, &payload); err != nil {
+			s.t.Errorf("%s: request body is not JSON: %v", id, err)
+		} else {
+			for _, problem := range s.validate(requestSchema, payload, "body") {
+				s.t.Errorf("%s: %s", id, problem)
+			}
+		}
+	} else if len(body) > 0 {
+		s.t.Errorf("%s: unexpected request body %s", id, body)
+	}
+
+	var response any = map[string]any{}
+	if responseSchema := s.contentSchema(asMap(asMap(op["responses"])["200"])); responseSchema != nil {
+		response = s.sample(responseSchema)
+		for _, problem := range s.validate(responseSchema, response, "response") {
+			s.t.Errorf("%s: generated %s", id, problem)
+		}
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// operation finds the spec operation for a request by matching path templates.
+func (s *contractServer) operation(method, path string) (map[string]any, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for template, item := range asMap(s.spec["paths"]) {
+		parts := strings.Split(strings.Trim(template, "/"), "/")
+		if len(parts) != len(segments) {
+			continue
+		}
+		match := true
+		for i, part := range parts {
+			if !strings.HasPrefix(part, "{") && part != segments[i] {
+				match = false
+				break
+			}
+		}
+		if op, ok := asMap(item)[strings.ToLower(method)]; match && ok {
+			return op.(map[string]any), true
+		}
+	}
+	return nil, false
+}
+
+// contentSchema returns the application/json schema of a request body or response object, if any.
+func (s *contractServer) contentSchema(object any) map[string]any {
+	media := asMap(asMap(asMap(object)["content"])["application/json"])
+	return asMap(media["schema"])
+}
+
+// resolve follows $ref and single-element allOf schemas.
+func (s *contractServer) resolve(schema map[string]any) map[string]any {
+	for {
+		if ref, ok := schema["$ref"].(string); ok {
+			name := strings.TrimPrefix(ref, "#/components/schemas/")
+			schema = asMap(asMap(asMap(s.spec["components"])["schemas"])[name])
+			continue
+		}
+		if all := asSlice(schema["allOf"]); len(all) == 1 {
+			schema = asMap(all[0])
+			continue
+		}
+		return schema
+	}
+}
+
+// validate checks value against schema and returns the problems found.
+// Objects without additionalProperties reject unknown properties.
+func (s *contractServer) validate(schema map[string]any, value any, path string) []string {
+	nullable, _ := schema["nullable"].(bool)
+	schema = s.resolve(schema)
+	if value == nil {
+		if nullable {
+			return nil
+		}
+		return []string{path + " is null"}
+	}
+	var problems []string
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return []string{path + " is not an object"}
+		}
+		for _, name := range asSlice(schema["required"]) {
+			if _, ok := object[name.(string)]; !ok {
+				problems = append(problems, fmt.Sprintf("%s.%s is required", path, name))
+			}
+		}
+		properties := asMap(schema["properties"])
+		additional := asMap(schema["additionalProperties"])
+		for key, v := range object {
+			if propertySchema, ok := properties[key]; ok {
+				problems = append(problems, s.validate(asMap(propertySchema), v, path+"."+key)...)
+			} else if additional != nil {
+				problems = append(problems, s.validate(additional, v, path+"."+key)...)
+			} else {
+				problems = append(problems, fmt.Sprintf("%s.%s is not in the schema", path, key))
+			}
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return []string{path + " is not an array"}
+		}
+		for i, item := range items {
+			problems = append(problems, s.validate(asMap(schema["items"]), item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return []string{path + " is not a string"}
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				problems = append(problems, path+" is not a date-time")
+			}
+		}
+		if enum := asSlice(schema["enum"]); len(enum) > 0 && !slices.Contains(enum, any(str)) {
+			problems = append(problems, fmt.Sprintf("%s %q is not one of %v", path, str, enum))
+		}
+	case "integer":
+		if n, ok := value.(float64); !ok || n != math.Trunc(n) {
+			problems = append(problems, path+" is not an integer")
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			problems = append(problems, path+" is not a number")
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			problems = append(problems, path+" is not a boolean")
+		}
+	}
+	return problems
+}
+
+// sample generates a value for schema with every property set.
+func (s *contractServer) sample(schema map[string]any) any {
+	schema = s.resolve(schema)
+	switch schema["type"] {
+	case "object":
+		object := map[string]any{}
+		for name, propertySchema := range asMap(schema["properties"]) {
+			object[name] = s.sample(asMap(propertySchema))
+		}
+		return object
+	case "array":
+		return []any{s.sample(asMap(schema["items"]))}
+	case "string":
+		if schema["format"] == "date-time" {
+			return "2025-01-02T03:04:05Z"
+		}
+		if enum := asSlice(schema["enum"]); len(enum) > 0 {
+			return enum[0]
+		}
+		return "sample"
+	case "integer", "number":
+		return 1.0
+	case "boolean":
+		return true
+	}
+	return nil
+}
+
+func asMap(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	return m
+}
+
+func asSlice(v any) []any {
+	s, _ := v.([]any)
+	return s
+}
+
+func TestContract_WrappersMatchSpec(t *testing.T) {
+	server := newContractServer(t)
+	api := newTestApi(t, server.URL)
+	api.apiConfig.StrictDecoding = true
+	api.config.Capabilities = &Capabilities{StructureUpgrades: true, ProficiencyPoints: true}
+
+	calls := []struct {
+		name string
+		fn   func() error
+	}{
+		{"Login", func() error { _, err := api.Login(LoginRequest{Email: "a@b.c", Password: "pw"}); return err }},
+		{"Register", func() error {
+			_, err := api.Register(RegisterRequest{Email: "a@b.c", Password: "pw", ConfirmPassword: "pw", Username: "u"})
+			return err
+		}},
+		{"GetCurrentUser", func() error { _, err := api.GetCurrentUser(); return err }},
+		{"GetCurrentUserAPI", func() error { _, err := api.GetCurrentUserAPI(); return err }},
+		{"GetPlayersForCurrentUser", func() error { _, err := api.GetPlayersForCurrentUser(); return err }},
+		{"GetPlayerByIndex", func() error { _, err := api.GetPlayerByIndex(0); return err }},
+		{"AssumePlayer", func() error { _, err := api.AssumePlayer("p1"); return err }},
+		{"UnassumePlayer", func() error { return api.UnassumePlayer() }},
+		{"FetchAllPlayers", func() error { _, err := api.FetchAllPlayers(1, 10); return err }},
+		{"CreatePlayer", func() error {
+			_, err := api.CreatePlayer(CreatePlayerRequest{Name: "n", Race: "human", Password: "pw"})
+			return err
+		}},
+		{"ValidatePlayerName", func() error { _, err := api.ValidatePlayerName("n"); return err }},
+		{"FetchPlayerByID", func() error { _, err := api.FetchPlayerByID("p1"); return err }},
+		{"FetchAllMatchingIDs", func() error { _, err := api.FetchAllMatchingIDs([]string{"p1", "p2"}); return err }},
+		{"FetchPlayers", func() error { _, err := api.FetchPlayers(context.Background(), []string{"p1"}); return err }},
+		{"FetchWarHistoryByID", func() error { _, err := api.FetchWarHistoryByID("w1"); return err }},
+		{"FetchWarHistory", func() error {
+			_, err := api.FetchWarHistory(WarHistoryFilter{Page: 1, PlayerID: "p1", Outcome: WarOutcomeVictory, From: time.Now().Add(-time.Hour)})
+			return err
+		}},
+		{"FetchAllWarHistory", func() error { _, err := api.FetchAllWarHistory(); return err }},
+		{"TrainUnits", func() error {
+			_, err := api.TrainUnits(TrainUnitsRequest{PlayerID: "p1", Units: []UnitRequest{{UnitType: "worker", Quantity: 1}}})
+			return err
+		}},
+		{"UntrainUnits", func() error {
+			_, err := api.UntrainUnits(UntrainUnitsRequest{PlayerID: "p1", Units: []UnitRequest{{UnitType: "worker", Quantity: 1}}})
+			return err
+		}},
+		{"AttackPlayer", func() error { _, err := api.AttackPlayer("p2"); return err }},
+		{"DepositGold", func() error { _, err := api.DepositGold(BankDepositRequest{PlayerID: "p1", Amount: 10}); return err }},
+		{"WithdrawGold", func() error { _, err := api.WithdrawGold(BankWithdrawRequest{PlayerID: "p1", Amount: 10}); return err }},
+		{"UpgradeStructure", func() error {
+			_, err := api.UpgradeStructure(UpgradeStructureRequest{StructureID: "s1", UpgradeLevel: 2})
+			return err
+		}},
+		{"SpendProficiencyPoints", func() error {
+			_, err := api.SpendProficiencyPoints(ProficiencyPointsRequest{PlayerID: "p1", PointsToSpend: 1, ProficiencyType: "strength"})
+			return err
+		}},
+		{"RefreshUnitCatalog", func() error { _, err := api.RefreshUnitCatalog(context.Background()); return err }},
+		{"Logout", func() error { return api.Logout() }},
+	}
+	for _, c := range calls {
+		if err := c.fn(); err != nil {
+			t.Errorf("%s: %v", c.name, err)
+		}
+	}
+
+	var missed []string
+	for name := range endpointRegistry {
+		if !server.called[string(name)] {
+			missed = append(missed, string(name))
+		}
+	}
+	sort.Strings(missed)
+	if len(missed) > 0 {
+		t.Errorf("operations not exercised by any wrapper: %v", missed)
+	}
+}
+
+func TestContract_ServerRejectsInvalidRequests(t *testing.T) {
+	server := newContractServer(t)
+	op, ok := server.operation("POST", "/bank/deposit")
+	if !ok {
+		t.Fatal("bank/deposit not found")
+	}
+	schema := server.contentSchema(op["requestBody"])
+	problems := server.validate(schema, map[string]any{"playerId": "p1", "amount": "ten", "memo": "x"}, "body")
+	sort.Strings(problems)
+	want := []string{"body.amount is not an integer", "body.memo is not in the schema"}
+	if strings.Join(problems, "|") != strings.Join(want, "|") {
+		t.Errorf("problems = %v, want %v", problems, want)
+	}
+}
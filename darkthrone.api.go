@@ -1,21 +1,22 @@
 package DarkThroneApi
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/Rihoj/DarkThroneApi/audit"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
-	loginEndpoint        = "auth/login"
-	currentUserEndpoint  = "auth/current-user"
-	playersListEndpoint  = "auth/current-user/players"
-	assumePlayerEndpoint = "auth/assume-player"
-
-	min_attack_turns = 10
-	page_size        = 100
+	min_attack_turns          = 10
+	page_size                 = 100
+	fetch_players_concurrency = 4
 )
 
 var (
@@ -26,6 +27,33 @@ var (
 // Config holds configuration for the DarkThroneApi client, such as the logger.
 type Config struct {
 	Logger *slog.Logger
+	// Cache enables caching of GET responses. Leave nil to always hit the API.
+	Cache *ResponseCache
+	// Coalescer shares one response between identical concurrent GET requests. Leave nil to disable.
+	Coalescer *Coalescer
+	// Snapshots records every player fetched by ID, list or batch. Leave nil to disable.
+	Snapshots SnapshotStore
+	// Metrics collects request, ping and game-state metrics. Leave nil to disable.
+	Metrics *Metrics
+	// CircuitBreaker stops sending requests to a failing rate-limit group until it cools down. Leave nil to disable.
+	CircuitBreaker *CircuitBreaker
+	// StrictDecoding fails requests whose response has unknown fields or lacks required ones.
+	StrictDecoding bool
+	// DriftDetector logs and collects field-level differences between responses and the client's types.
+	DriftDetector *DriftDetector
+	// TracerProvider enables OpenTelemetry tracing of requests and multi-request operations. Leave nil to disable.
+	TracerProvider trace.TracerProvider
+	// Capabilities overrides feature detection. Leave nil to probe the server on first use of an optional feature.
+	Capabilities *Capabilities
+	// UnitCatalog is used to estimate and validate training orders until RefreshUnitCatalog fetches one.
+	// Leave nil to use the bundled catalog.
+	UnitCatalog *UnitCatalog
+	// DryRun simulates attacks, training, banking, player creation, structure upgrades and proficiency
+	// spending from the cached game state instead of sending them. Read endpoints still hit the API.
+	DryRun bool
+	// AuditLog records every mutating request, with its redacted payload and outcome, in a hash-chained
+	// log. A request is not sent if its intent cannot be recorded. Leave nil to disable.
+	AuditLog *audit.Log
 }
 
 // DarkThroneApi is the main client for interacting with the Dark Throne API.
@@ -33,6 +61,16 @@ type DarkThroneApi struct {
 	config    *Config
 	token     string
 	apiConfig *ApiRequestConfig
+	events    *EventBus
+	// health pauses API calls while the API is down, when a HealthMonitor is configured to do so.
+	health atomic.Pointer[HealthMonitor]
+	// capabilities holds the optional features found by ProbeCapabilities.
+	capabilities capabilityState
+	// state is the last known game state, used to validate requests locally.
+	state gameState
+	// journal records mutating requests; see Journal.
+	journal     *MutationJournal
+	journalOnce sync.Once
 }
 
 // New creates a new instance of DarkThroneApi with the provided configuration.
@@ -40,11 +78,24 @@ func New(config *Config) *DarkThroneApi {
 	once.Do(func() {
 		instance = &DarkThroneApi{
 			config: config,
+			events: NewEventBus(),
 			apiConfig: &ApiRequestConfig{
-				BaseURL: "https://api.darkthronereborn.com",
-				Logger:  config.Logger,
+				BaseURL:        "https://api.darkthronereborn.com",
+				Logger:         config.Logger,
+				Cache:          config.Cache,
+				Coalescer:      config.Coalescer,
+				Metrics:        config.Metrics,
+				TracerProvider: config.TracerProvider,
+				CircuitBreaker: config.CircuitBreaker,
+				StrictDecoding: config.StrictDecoding,
+				DriftDetector:  config.DriftDetector,
 			},
 		}
+		if config.Metrics != nil {
+			config.Metrics.coalescer = config.Coalescer
+			config.Metrics.breaker = config.CircuitBreaker
+			config.Metrics.subscribe(instance.events)
+		}
 	})
 	return instance
 }
@@ -72,8 +123,14 @@ func (d *DarkThroneApi) getAuthHeaders() map[string]string {
 // Ping checks if the Dark Throne API server can be reached by making a HEAD request to the base URL.
 // It returns the latency in milliseconds if successful, or an error if not.
 func (d *DarkThroneApi) Ping() (latencyMs int64, err error) {
+	latency, err := d.PingContext(context.Background())
+	return latency.Milliseconds(), err
+}
+
+// PingContext is Ping with a context that can cancel the request, returning the latency as a duration.
+func (d *DarkThroneApi) PingContext(ctx context.Context) (time.Duration, error) {
 	url := d.apiConfig.BaseURL
-	req, err := http.NewRequest("HEAD", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "HEAD", url, nil)
 	if err != nil {
 		if d.config != nil && d.config.Logger != nil {
 			d.config.Logger.Error("Ping request creation failed", "error", err)
@@ -83,11 +140,12 @@ func (d *DarkThroneApi) Ping() (latencyMs int64, err error) {
 	start := time.Now()
 	client := &http.Client{}
 	resp, err := client.Do(req)
-	latency := time.Since(start).Milliseconds()
+	latency := time.Since(start)
 	if err != nil {
 		if d.config != nil && d.config.Logger != nil {
 			d.config.Logger.Error("Ping failed", "error", err)
 		}
+		d.apiConfig.Metrics.observePing(latency, err)
 		return latency, err
 	}
 	defer resp.Body.Close()
@@ -96,10 +154,12 @@ func (d *DarkThroneApi) Ping() (latencyMs int64, err error) {
 		if d.config != nil && d.config.Logger != nil {
 			d.config.Logger.Error("Ping failed", "status", resp.Status)
 		}
+		d.apiConfig.Metrics.observePing(latency, err)
 		return latency, err
 	}
 	if d.config != nil && d.config.Logger != nil {
-		d.config.Logger.Info("Ping successful", "url", url, "status", resp.Status, "latency_ms", latency)
+		d.config.Logger.Info("Ping successful", "url", url, "status", resp.Status, "latency_ms", latency.Milliseconds())
 	}
+	d.apiConfig.Metrics.observePing(latency, nil)
 	return latency, nil
 }
@@ -0,0 +1,166 @@
+package DarkThroneApi
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrUnknownGameState is returned in dry-run mode when a request cannot be simulated because
+// the players it involves have not been fetched yet.
+var ErrUnknownGameState = errors.New("dry run: game state unknown")
+
+// simulator returns the simulated response to a mutating request and applies its effect to the cached game state.
+type simulator func(d *DarkThroneApi, body any) (any, error)
+
+// simulators lists the endpoints that are simulated instead of sent in dry-run mode.
+// Every other endpoint, including login and assume, still reaches the API.
+var simulators = map[endpointName]simulator{
+	epAttack:                 simulateAttack,
+	epTrainingTrain:          simulateTraining,
+	epTrainingUntrain:        simulateUntraining,
+	epBankDeposit:            simulateDeposit,
+	epBankWithdraw:           simulateWithdrawal,
+	epPlayersCreate:          simulateCreatePlayer,
+	epStructuresUpgrade:      simulateUpgrade,
+	epProficiencyPointsSpend: simulateProficiencyPoints,
+}
+
+// dryRun reports whether mutating requests are simulated.
+func (d *DarkThroneApi) dryRun() bool {
+	return d.config != nil && d.config.DryRun
+}
+
+// simulate logs the request name would send and returns the simulated response.
+func simulate[Resp any](d *DarkThroneApi, name endpointName, sim simulator, body any) (Resp, error) {
+	var zero Resp
+	d.config.Logger.Info("Dry run: request not sent", "endpoint", name, "request", body)
+	resp, err := sim(d, body)
+	if err != nil {
+		d.publishFailure(name, err)
+		return zero, err
+	}
+	d.publish(RequestSimulated{Endpoint: string(name), Request: body, Response: resp, At: time.Now()})
+	return resp.(Resp), nil
+}
+
+// isMutationEvent reports whether e announces a change made on the server, which never happens in dry-run mode.
+func isMutationEvent(e Event) bool {
+	switch e.(type) {
+	case PlayerCreated, AttackCompleted, UnitsTrained, UnitsUntrained, GoldDeposited, GoldWithdrawn:
+		return true
+	}
+	return false
+}
+
+// simulateAttack wins when the assumed player's offense beats the target's defense, using the unit catalog.
+func simulateAttack(d *DarkThroneApi, body any) (any, error) {
+	req := body.(attackRequest)
+	attacker, ok := d.assumedPlayer()
+	if !ok {
+		return nil, fmt.Errorf("%w: no assumed player", ErrUnknownGameState)
+	}
+	target, ok := d.knownPlayer(req.TargetID)
+	if !ok {
+		return nil, fmt.Errorf("%w: target %q has not been fetched", ErrUnknownGameState, req.TargetID)
+	}
+	if attacker.AttackTurns < req.AttackTurns {
+		return nil, fmt.Errorf("dry run: attack needs %d turns, player has %d", req.AttackTurns, attacker.AttackTurns)
+	}
+	catalog := d.UnitCatalog()
+	victory := catalog.ArmyStats(attacker.Units).Offense > catalog.ArmyStats(target.Units).Defense
+	attacker.AttackTurns -= req.AttackTurns
+	d.rememberPlayers(attacker)
+	return AttackResponse{IsAttackerVictor: victory}, nil
+}
+
+// simulateTraining prices the order. TrainUnits applies it to the cached player, as it does for real orders.
+func simulateTraining(d *DarkThroneApi, body any) (any, error) {
+	req := body.(TrainUnitsRequest)
+	player, _ := d.knownPlayer(req.PlayerID)
+	estimate, err := d.UnitCatalog().EstimateTraining(player, req)
+	if err != nil {
+		return nil, err
+	}
+	return TrainUnitsResponse{
+		Success: true,
+		Message: fmt.Sprintf("dry run: trained %d units for %d gold", estimate.Units, estimate.Cost),
+	}, nil
+}
+
+// simulateUntraining counts the units. UntrainUnits removes them from the cached player.
+func simulateUntraining(d *DarkThroneApi, body any) (any, error) {
+	req := body.(UntrainUnitsRequest)
+	count := 0
+	for _, u := range req.Units {
+		count += u.Quantity
+	}
+	return UntrainUnitsResponse{Success: true, Message: fmt.Sprintf("dry run: untrained %d units", count)}, nil
+}
+
+// simulateDeposit adds the amount to the bank balance last reported, or zero. DepositGold takes
+// the gold from the cached player, as it does for real deposits.
+func simulateDeposit(d *DarkThroneApi, body any) (any, error) {
+	req := body.(BankDepositRequest)
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("dry run: deposit amount must be positive, got %d", req.Amount)
+	}
+	if player, ok := d.knownPlayer(req.PlayerID); ok && player.Gold < req.Amount {
+		return nil, fmt.Errorf("%w: depositing %d, player has %d", ErrInsufficientGold, req.Amount, player.Gold)
+	}
+	balance, _ := d.knownBankBalance(req.PlayerID)
+	balance += req.Amount
+	d.rememberBankBalance(req.PlayerID, balance)
+	return BankResponse{Success: true, Message: "dry run: gold deposited", Balance: balance}, nil
+}
+
+// simulateWithdrawal takes the amount from the bank balance, checking it only once one has been
+// reported. WithdrawGold gives the gold to the cached player, as it does for real withdrawals.
+func simulateWithdrawal(d *DarkThroneApi, body any) (any, error) {
+	req := body.(BankWithdrawRequest)
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("dry run: withdrawal amount must be positive, got %d", req.Amount)
+	}
+	balance, known := d.knownBankBalance(req.PlayerID)
+	if known && balance < req.Amount {
+		return nil, fmt.Errorf("%w: withdrawing %d, bank holds %d", ErrInsufficientGold, req.Amount, balance)
+	}
+	balance = max(balance-req.Amount, 0)
+	d.rememberBankBalance(req.PlayerID, balance)
+	return BankResponse{Success: true, Message: "dry run: gold withdrawn", Balance: balance}, nil
+}
+
+// simulateCreatePlayer returns a new player with a placeholder ID.
+func simulateCreatePlayer(d *DarkThroneApi, body any) (any, error) {
+	req := body.(CreatePlayerRequest)
+	if req.Name == "" {
+		return nil, errors.New("dry run: player name is required")
+	}
+	player := Player{ID: "dry-run-" + req.Name, Name: req.Name, Race: req.Race, Level: 1}
+	d.rememberPlayers(player)
+	return player, nil
+}
+
+// simulateUpgrade reports the structure at the requested level.
+func simulateUpgrade(_ *DarkThroneApi, body any) (any, error) {
+	req := body.(UpgradeStructureRequest)
+	if req.UpgradeLevel <= 0 {
+		return nil, fmt.Errorf("dry run: upgrade level must be positive, got %d", req.UpgradeLevel)
+	}
+	return UpgradeStructureResponse{
+		Success:     true,
+		Message:     "dry run: structure upgraded",
+		StructureID: req.StructureID,
+		NewLevel:    req.UpgradeLevel,
+	}, nil
+}
+
+// simulateProficiencyPoints accepts the spend. The client does not track proficiency points,
+// so RemainingPoints is always zero.
+func simulateProficiencyPoints(_ *DarkThroneApi, body any) (any, error) {
+	req := body.(ProficiencyPointsRequest)
+	return ProficiencyPointsResponse{
+		Success: true,
+		Message: fmt.Sprintf("dry run: spent %d points on %s", req.PointsToSpend, req.ProficiencyType),
+	}, nil
+}
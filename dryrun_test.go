@@ -0,0 +1,118 @@
+package DarkThroneApi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func newDryRunApi(t *testing.T) (*DarkThroneApi, *atomic.Int32) {
+	t.Helper()
+	var posts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			posts.Add(1)
+			t.Errorf("dry run sent %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"id":"p2","name":"Target","units":[{"unitType":"soldier","quantity":1}]}`))
+	}))
+	t.Cleanup(server.Close)
+	api := newTestApi(t, server.URL)
+	api.token = "t"
+	api.config.DryRun = true
+	api.config.UnitCatalog = testUnitCatalog
+	return api, &posts
+}
+
+func TestDryRun_TrainingAndBankingUpdateCachedState(t *testing.T) {
+	api, _ := newDryRunApi(t)
+	api.rememberAssumed(Player{ID: "p1", Gold: 1000})
+	var simulated []string
+	api.Events().Subscribe(func(e Event) {
+		switch e := e.(type) {
+		case RequestSimulated:
+			simulated = append(simulated, e.Endpoint)
+		case UnitsTrained, GoldDeposited:
+			t.Errorf("dry run published %s", e.EventName())
+		}
+	})
+
+	train, err := api.TrainUnits(TrainUnitsRequest{PlayerID: "p1", Units: []UnitRequest{{UnitType: "soldier", Quantity: 3}}})
+	if err != nil || !train.Success {
+		t.Fatalf("TrainUnits = %+v, %v", train, err)
+	}
+	if _, err := api.UntrainUnits(UntrainUnitsRequest{PlayerID: "p1", Units: []UnitRequest{{UnitType: "soldier", Quantity: 1}}}); err != nil {
+		t.Fatal(err)
+	}
+	player, _ := api.knownPlayer("p1")
+	if player.Gold != 400 || len(player.Units) != 1 || player.Units[0].Quantity != 2 {
+		t.Errorf("player after training = %+v", player)
+	}
+
+	deposit, err := api.DepositGold(BankDepositRequest{PlayerID: "p1", Amount: 300})
+	if err != nil || deposit.Balance != 300 {
+		t.Fatalf("DepositGold = %+v, %v", deposit, err)
+	}
+	if _, err := api.DepositGold(BankDepositRequest{PlayerID: "p1", Amount: 300}); !errors.Is(err, ErrInsufficientGold) {
+		t.Errorf("err = %v, want ErrInsufficientGold", err)
+	}
+	if _, err := api.WithdrawGold(BankWithdrawRequest{PlayerID: "p1", Amount: 500}); !errors.Is(err, ErrInsufficientGold) {
+		t.Errorf("err = %v, want ErrInsufficientGold", err)
+	}
+	withdraw, err := api.WithdrawGold(BankWithdrawRequest{PlayerID: "p1", Amount: 100})
+	if err != nil || withdraw.Balance != 200 {
+		t.Fatalf("WithdrawGold = %+v, %v", withdraw, err)
+	}
+	if player, _ := api.knownPlayer("p1"); player.Gold != 200 {
+		t.Errorf("gold = %d, want 200", player.Gold)
+	}
+	if want := "training.train,training.untrain,bank.deposit,bank.withdraw"; strings.Join(simulated, ",") != want {
+		t.Errorf("simulated = %v, want %s", simulated, want)
+	}
+}
+
+func TestDryRun_AttackUsesCachedArmies(t *testing.T) {
+	api, posts := newDryRunApi(t)
+	if _, err := api.AttackPlayer("p2"); !errors.Is(err, ErrUnknownGameState) {
+		t.Errorf("err = %v, want ErrUnknownGameState", err)
+	}
+	api.rememberAssumed(Player{ID: "p1", AttackTurns: 15, Units: []Unit{{UnitType: "soldier", Quantity: 1}}})
+	// Reads still reach the API and feed the simulation.
+	if _, err := api.FetchPlayerByID("p2"); err != nil {
+		t.Fatal(err)
+	}
+	victory, err := api.AttackPlayer("p2")
+	if err != nil || !victory {
+		t.Fatalf("AttackPlayer = %v, %v; 3 offense should beat 0 defense", victory, err)
+	}
+	if _, err := api.AttackPlayer("p2"); err == nil {
+		t.Error("expected an error once the attack turns are spent")
+	}
+	if posts.Load() != 0 {
+		t.Errorf("dry run sent %d mutating requests", posts.Load())
+	}
+}
+
+func TestDryRun_OtherMutations(t *testing.T) {
+	api, _ := newDryRunApi(t)
+	player, err := api.CreatePlayer(CreatePlayerRequest{Name: "Nova", Race: "elf"})
+	if err != nil || player.Name != "Nova" || player.ID == "" {
+		t.Errorf("CreatePlayer = %+v, %v", player, err)
+	}
+	// Capabilities are not probed in dry-run mode.
+	upgrade, err := api.UpgradeStructure(UpgradeStructureRequest{StructureID: "mine", UpgradeLevel: 2})
+	if err != nil || upgrade.NewLevel != 2 || upgrade.StructureID != "mine" {
+		t.Errorf("UpgradeStructure = %+v, %v", upgrade, err)
+	}
+	spent, err := api.SpendProficiencyPoints(ProficiencyPointsRequest{PlayerID: "p1", PointsToSpend: 3, ProficiencyType: ProficiencyWealth})
+	if err != nil || !spent.Success {
+		t.Errorf("SpendProficiencyPoints = %+v, %v", spent, err)
+	}
+	api.config.Capabilities = &Capabilities{}
+	if _, err := api.UpgradeStructure(UpgradeStructureRequest{StructureID: "mine", UpgradeLevel: 2}); !errors.Is(err, ErrFeatureUnavailable) {
+		t.Errorf("err = %v, want ErrFeatureUnavailable for a known missing feature", err)
+	}
+}
@@ -0,0 +1,164 @@
+package DarkThroneApi
+
+//go:generate go run ./internal/genendpoints -spec endpoints.yaml -out endpoints_gen.go -test endpoints_gen_test.go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Rate-limit groups. Requests in the same group share the minimum delay enforced by DoRequest,
+// and all groups together are held to overallRequestsPerRateLimit.
+const (
+	rateLimitGroupAuth  = "auth"
+	rateLimitGroupRead  = "read"
+	rateLimitGroupWrite = "write"
+)
+
+// endpointName identifies an entry in the endpoint registry.
+// The names, the registry itself and the wrappers are generated from endpoints.yaml into endpoints_gen.go.
+type endpointName string
+
+// endpoint describes a single route of the Dark Throne API.
+type endpoint struct {
+	Method string
+	// Path is relative to the base URL and may contain {name} placeholders,
+	// which are filled from path parameters and escaped as single segments.
+	Path string
+	// Auth reports whether the route requires a bearer token.
+	Auth bool
+	// Idempotent reports whether repeating the request has no additional effect on game state.
+	Idempotent     bool
+	RateLimitGroup string
+}
+
+// ErrNotAuthenticated is returned when an endpoint that requires a token is called before Login.
+var ErrNotAuthenticated = errors.New("token is not set")
+
+// pathParams holds values substituted into an endpoint path template.
+type pathParams map[string]string
+
+// lookupEndpoint returns the registry entry for name.
+func lookupEndpoint(name endpointName) (endpoint, error) {
+	ep, ok := endpointRegistry[name]
+	if !ok {
+		return endpoint{}, fmt.Errorf("unknown endpoint %q", name)
+	}
+	return ep, nil
+}
+
+// buildPath fills the {name} placeholders of the endpoint path, escaping each value as a single path segment.
+func (ep endpoint) buildPath(params pathParams) (string, error) {
+	segments := strings.Split(ep.Path, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+		key := segment[1 : len(segment)-1]
+		value, ok := params[key]
+		if !ok || value == "" {
+			return "", fmt.Errorf("missing path parameter %q for %s", key, ep.Path)
+		}
+		segments[i] = escapePathSegment(value)
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+// escapePathSegment escapes value so that it is always interpreted as exactly one path segment.
+// Dot segments are percent-encoded as well, since "." and ".." would otherwise be resolved away.
+func escapePathSegment(value string) string {
+	escaped := url.PathEscape(value)
+	if value == "." || value == ".." {
+		escaped = strings.ReplaceAll(escaped, ".", "%2E")
+	}
+	return escaped
+}
+
+// endpointHeaders returns the request headers for the endpoint, including the bearer token when required.
+func (d *DarkThroneApi) endpointHeaders(ep endpoint) (map[string]string, error) {
+	if !ep.Auth {
+		return map[string]string{
+			"Content-Type": "application/json",
+			"Accept":       "application/json",
+		}, nil
+	}
+	if d.token == "" {
+		return nil, ErrNotAuthenticated
+	}
+	return d.getAuthHeaders(), nil
+}
+
+// call executes the registered endpoint name with the given path parameters, query and body.
+// Every wrapper goes through call so that cross-cutting behaviour is applied uniformly.
+func call[Req any, Resp any](d *DarkThroneApi, name endpointName, params pathParams, query url.Values, body Req) (Resp, error) {
+	return callContext[Req, Resp](context.Background(), d, name, params, query, body)
+}
+
+// callContext is call with a context that bounds the rate-limit wait and the HTTP request.
+func callContext[Req any, Resp any](ctx context.Context, d *DarkThroneApi, name endpointName, params pathParams, query url.Values, body Req) (Resp, error) {
+	var zero Resp
+	ep, err := lookupEndpoint(name)
+	if err != nil {
+		return zero, err
+	}
+	path, err := ep.buildPath(params)
+	if err != nil {
+		return zero, err
+	}
+	if sim, ok := simulators[name]; ok && d.dryRun() {
+		return simulate[Resp](d, name, sim, body)
+	}
+	headers, err := d.endpointHeaders(ep)
+	if err != nil {
+		return zero, err
+	}
+	if attempt := mutationAttemptFrom(ctx); attempt != nil {
+		headers[idempotencyKeyHeader] = attempt.key
+	}
+	if err := d.health.Load().WaitUntilAvailable(ctx); err != nil {
+		return zero, err
+	}
+	resp, err := ApiRequest[Req, Resp]{
+		Method:         ep.Method,
+		Endpoint:       path,
+		Query:          query,
+		Headers:        headers,
+		Body:           body,
+		Config:         d.apiConfig,
+		RateLimitGroup: ep.RateLimitGroup,
+		Name:           string(name),
+		Idempotent:     ep.Idempotent,
+	}.DoRequestContext(ctx)
+	if err != nil {
+		d.publishFailure(name, err)
+	}
+	return resp, err
+}
+
+// forgetCached removes the cached response of the endpoint name with params, leaving other entries alone.
+func (d *DarkThroneApi) forgetCached(name endpointName, params pathParams) error {
+	if d.apiConfig.Cache == nil || d.apiConfig.Cache.Backend == nil {
+		return nil
+	}
+	ep, err := lookupEndpoint(name)
+	if err != nil {
+		return err
+	}
+	path, err := ep.buildPath(params)
+	if err != nil {
+		return err
+	}
+	headers, err := d.endpointHeaders(ep)
+	if err != nil {
+		return err
+	}
+	requestURL, err := (&ApiRequest[struct{}, struct{}]{Endpoint: path, Config: d.apiConfig}).buildURL()
+	if err != nil {
+		return err
+	}
+	d.apiConfig.Cache.Backend.Delete(requestKey(ep.Method, requestURL.String(), headers["Authorization"]))
+	return nil
+}
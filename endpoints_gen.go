@@ -0,0 +1,368 @@
+// Code generated by genendpoints from endpoints.yaml. DO NOT EDIT.
+
+package DarkThroneApi
+
+import (
+	"context"
+	"net/url"
+)
+
+const (
+	epLogin                  endpointName = "auth.login"
+	epRegister               endpointName = "auth.register"
+	epLogout                 endpointName = "auth.logout"
+	epCurrentUser            endpointName = "auth.current-user"
+	epCurrentUserPlayers     endpointName = "auth.current-user.players"
+	epAssumePlayer           endpointName = "auth.assume-player"
+	epUnassumePlayer         endpointName = "auth.unassume-player"
+	epPlayersList            endpointName = "players.list"
+	epPlayersCreate          endpointName = "players.create"
+	epPlayersValidateName    endpointName = "players.validate-name"
+	epPlayersGet             endpointName = "players.get"
+	epPlayersMatchingIDs     endpointName = "players.matching-ids"
+	epWarHistoryGet          endpointName = "war-history.get"
+	epWarHistoryList         endpointName = "war-history.list"
+	epTrainingTrain          endpointName = "training.train"
+	epTrainingUntrain        endpointName = "training.untrain"
+	epAttack                 endpointName = "attack"
+	epBankDeposit            endpointName = "bank.deposit"
+	epBankWithdraw           endpointName = "bank.withdraw"
+	epUnitsList              endpointName = "units.list"
+	epStructuresUpgrade      endpointName = "structures.upgrade"
+	epProficiencyPointsSpend endpointName = "proficiency-points.spend"
+)
+
+// endpointRegistry is the declarative table of every route the client calls.
+var endpointRegistry = map[endpointName]endpoint{
+	epLogin:                  {Method: "POST", Path: "auth/login", RateLimitGroup: rateLimitGroupAuth},
+	epRegister:               {Method: "POST", Path: "auth/register", RateLimitGroup: rateLimitGroupAuth},
+	epLogout:                 {Method: "POST", Path: "auth/logout", Auth: true, RateLimitGroup: rateLimitGroupAuth},
+	epCurrentUser:            {Method: "GET", Path: "auth/current-user", Auth: true, Idempotent: true, RateLimitGroup: rateLimitGroupAuth},
+	epCurrentUserPlayers:     {Method: "GET", Path: "auth/current-user/players", Auth: true, Idempotent: true, RateLimitGroup: rateLimitGroupAuth},
+	epAssumePlayer:           {Method: "POST", Path: "auth/assume-player", Auth: true, RateLimitGroup: rateLimitGroupAuth},
+	epUnassumePlayer:         {Method: "POST", Path: "auth/unassume-player", Auth: true, RateLimitGroup: rateLimitGroupAuth},
+	epPlayersList:            {Method: "GET", Path: "players", Auth: true, Idempotent: true, RateLimitGroup: rateLimitGroupRead},
+	epPlayersCreate:          {Method: "POST", Path: "players", Auth: true, RateLimitGroup: rateLimitGroupWrite},
+	epPlayersValidateName:    {Method: "POST", Path: "players/validate-name", Auth: true, Idempotent: true, RateLimitGroup: rateLimitGroupRead},
+	epPlayersGet:             {Method: "GET", Path: "players/{id}", Auth: true, Idempotent: true, RateLimitGroup: rateLimitGroupRead},
+	epPlayersMatchingIDs:     {Method: "POST", Path: "players/matching-ids", Auth: true, Idempotent: true, RateLimitGroup: rateLimitGroupRead},
+	epWarHistoryGet:          {Method: "GET", Path: "war-history/{id}", Auth: true, Idempotent: true, RateLimitGroup: rateLimitGroupRead},
+	epWarHistoryList:         {Method: "GET", Path: "war-history", Auth: true, Idempotent: true, RateLimitGroup: rateLimitGroupRead},
+	epTrainingTrain:          {Method: "POST", Path: "training/train", Auth: true, RateLimitGroup: rateLimitGroupWrite},
+	epTrainingUntrain:        {Method: "POST", Path: "training/untrain", Auth: true, RateLimitGroup: rateLimitGroupWrite},
+	epAttack:                 {Method: "POST", Path: "attack", Auth: true, RateLimitGroup: rateLimitGroupWrite},
+	epBankDeposit:            {Method: "POST", Path: "bank/deposit", Auth: true, RateLimitGroup: rateLimitGroupWrite},
+	epBankWithdraw:           {Method: "POST", Path: "bank/withdraw", Auth: true, RateLimitGroup: rateLimitGroupWrite},
+	epUnitsList:              {Method: "GET", Path: "units", Auth: true, Idempotent: true, RateLimitGroup: rateLimitGroupRead},
+	epStructuresUpgrade:      {Method: "POST", Path: "structures/upgrade", Auth: true, RateLimitGroup: rateLimitGroupWrite},
+	epProficiencyPointsSpend: {Method: "POST", Path: "proficiency-points", Auth: true, RateLimitGroup: rateLimitGroupWrite},
+}
+
+// endpointSchemas holds the request and response types of every registry endpoint.
+var endpointSchemas = map[endpointName]endpointSchema{
+	epLogin:                  {Summary: "Log in", Request: typeOf[LoginRequest](), Response: typeOf[LoginResponse]()},
+	epRegister:               {Summary: "Register a user", Request: typeOf[RegisterRequest](), Response: typeOf[RegisterResponse]()},
+	epLogout:                 {Summary: "Log out"},
+	epCurrentUser:            {Summary: "Get the current user", Response: typeOf[CurrentUserResponse]()},
+	epCurrentUserPlayers:     {Summary: "List the current user's players", Response: typeOf[UserPlayersListResponse]()},
+	epAssumePlayer:           {Summary: "Assume one of the current user's players", Request: typeOf[assumePlayerRequest](), Response: typeOf[CurrentUserResponse]()},
+	epUnassumePlayer:         {Summary: "Unassume the current player"},
+	epPlayersList:            {Summary: "List players", Response: typeOf[PlayersListResponse](), Query: []string{"page", "pageSize"}},
+	epPlayersCreate:          {Summary: "Create a player", Request: typeOf[CreatePlayerRequest](), Response: typeOf[Player]()},
+	epPlayersValidateName:    {Summary: "Check whether a player name is available", Request: typeOf[validatePlayerNameRequest](), Response: typeOf[validatePlayerNameResponse]()},
+	epPlayersGet:             {Summary: "Get a player", Response: typeOf[Player]()},
+	epPlayersMatchingIDs:     {Summary: "Get several players by ID", Request: typeOf[matchingIDsRequest](), Response: typeOf[matchingIDsResponse]()},
+	epWarHistoryGet:          {Summary: "Get a war history record", Response: typeOf[WarHistory]()},
+	epWarHistoryList:         {Summary: "List war history", Response: typeOf[WarHistoryPage](), Query: []string{"page", "pageSize", "playerId", "opponentId", "outcome", "from", "to"}},
+	epTrainingTrain:          {Summary: "Train units", Request: typeOf[TrainUnitsRequest](), Response: typeOf[TrainUnitsResponse]()},
+	epTrainingUntrain:        {Summary: "Untrain units", Request: typeOf[UntrainUnitsRequest](), Response: typeOf[UntrainUnitsResponse]()},
+	epAttack:                 {Summary: "Attack a player", Request: typeOf[attackRequest](), Response: typeOf[AttackResponse]()},
+	epBankDeposit:            {Summary: "Deposit gold", Request: typeOf[BankDepositRequest](), Response: typeOf[BankResponse]()},
+	epBankWithdraw:           {Summary: "Withdraw gold", Request: typeOf[BankWithdrawRequest](), Response: typeOf[BankResponse]()},
+	epUnitsList:              {Summary: "Get the unit catalog", Response: typeOf[UnitCatalog]()},
+	epStructuresUpgrade:      {Summary: "Upgrade a structure", Request: typeOf[UpgradeStructureRequest](), Response: typeOf[UpgradeStructureResponse]()},
+	epProficiencyPointsSpend: {Summary: "Spend proficiency points", Request: typeOf[ProficiencyPointsRequest](), Response: typeOf[ProficiencyPointsResponse]()},
+}
+
+// LoginRequest represents the payload for a login request.
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// LoginResponse represents the response from a login request.
+type LoginResponse struct {
+	Session struct {
+		Id                  string  `json:"id"`
+		Email               string  `json:"email"`
+		Player_id           *string `json:"playerID"`
+		Has_confirmed_email bool    `json:"hasConfirmedEmail"`
+		Server_time         string  `json:"serverTime"`
+	} `json:"session"`
+	Token string `json:"token"`
+}
+
+// RegisterRequest represents the payload for user registration.
+type RegisterRequest struct {
+	Email           string `json:"email"`
+	Password        string `json:"password"`
+	ConfirmPassword string `json:"confirmPassword"`
+	Username        string `json:"username"`
+}
+
+// RegisterResponse represents the response for user registration.
+type RegisterResponse struct {
+	Session struct {
+		Id                  string  `json:"id"`
+		Email               string  `json:"email"`
+		Player_id           *string `json:"playerID"`
+		Has_confirmed_email bool    `json:"hasConfirmedEmail"`
+		Server_time         string  `json:"serverTime"`
+	} `json:"session"`
+	Token string `json:"token"`
+}
+
+// CurrentUserResponse represents the response for the current user API call.
+type CurrentUserResponse struct {
+	Player Player `json:"player"`
+}
+
+// UserPlayersListResponse represents a list of players for the current user.
+type UserPlayersListResponse []Player
+
+// assumePlayerRequest is the payload to assume one of the current user's players.
+type assumePlayerRequest struct {
+	PlayerID string `json:"playerID"`
+}
+
+// PlayersListResponse represents a paginated list of players.
+type PlayersListResponse struct {
+	Items []Player `json:"items"`
+}
+
+// CreatePlayerRequest represents the payload to create a player.
+type CreatePlayerRequest struct {
+	Name     string `json:"name"`
+	Race     string `json:"race"`
+	Password string `json:"password"`
+}
+
+// validatePlayerNameRequest is the payload to check whether a player name is available.
+type validatePlayerNameRequest struct {
+	Name string `json:"name"`
+}
+
+// validatePlayerNameResponse is the result of a player name check.
+type validatePlayerNameResponse struct {
+	Valid bool `json:"valid"`
+}
+
+// matchingIDsRequest is the payload to fetch several players by ID.
+type matchingIDsRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// matchingIDsResponse holds the players found by a matching-ids request.
+type matchingIDsResponse struct {
+	Players []Player `json:"players"`
+}
+
+// UnitRequest represents a unit and quantity for training/untraining.
+type UnitRequest struct {
+	UnitType UnitType `json:"unitType"`
+	Quantity int      `json:"quantity"`
+}
+
+// TrainUnitsRequest represents the payload to train units.
+type TrainUnitsRequest struct {
+	PlayerID string        `json:"playerId"`
+	Units    []UnitRequest `json:"units"`
+}
+
+// TrainUnitsResponse represents the response from training units.
+type TrainUnitsResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// UntrainUnitsRequest represents the payload to untrain units.
+type UntrainUnitsRequest struct {
+	PlayerID string        `json:"playerId"`
+	Units    []UnitRequest `json:"units"`
+}
+
+// UntrainUnitsResponse represents the response from untraining units.
+type UntrainUnitsResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// attackRequest is the payload of an attack.
+type attackRequest struct {
+	TargetID    string `json:"targetID"`
+	AttackTurns int    `json:"attackTurns"`
+}
+
+// AttackResponse represents the result of an attack action.
+type AttackResponse struct {
+	IsAttackerVictor bool `json:"isAttackerVictor"`
+}
+
+// BankDepositRequest represents the payload to deposit gold.
+type BankDepositRequest struct {
+	PlayerID string `json:"playerId"`
+	Amount   int    `json:"amount"`
+}
+
+// BankWithdrawRequest represents the payload to withdraw gold.
+type BankWithdrawRequest struct {
+	PlayerID string `json:"playerId"`
+	Amount   int    `json:"amount"`
+}
+
+// BankResponse represents the response from a bank operation.
+type BankResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Balance int    `json:"balance"`
+}
+
+// UpgradeStructureRequest represents the payload to upgrade a structure.
+type UpgradeStructureRequest struct {
+	StructureID  string `json:"structureId"`
+	UpgradeLevel int    `json:"upgradeLevel"`
+}
+
+// UpgradeStructureResponse represents the response from upgrading a structure.
+type UpgradeStructureResponse struct {
+	Success     bool   `json:"success"`
+	Message     string `json:"message"`
+	StructureID string `json:"structureId"`
+	NewLevel    int    `json:"newLevel"`
+}
+
+// ProficiencyPointsRequest represents the payload to spend proficiency points.
+type ProficiencyPointsRequest struct {
+	PlayerID        string          `json:"playerId"`
+	PointsToSpend   int             `json:"pointsToSpend"`
+	ProficiencyType ProficiencyType `json:"proficiencyType"`
+}
+
+// ProficiencyPointsResponse represents the response from spending proficiency points.
+type ProficiencyPointsResponse struct {
+	Success         bool   `json:"success"`
+	Message         string `json:"message"`
+	RemainingPoints int    `json:"remainingPoints"`
+}
+
+// loginAPI calls POST auth/login: Log in.
+func (d *DarkThroneApi) loginAPI(ctx context.Context, req LoginRequest) (LoginResponse, error) {
+	return callContext[LoginRequest, LoginResponse](ctx, d, epLogin, nil, nil, req)
+}
+
+// registerAPI calls POST auth/register: Register a user.
+func (d *DarkThroneApi) registerAPI(ctx context.Context, req RegisterRequest) (RegisterResponse, error) {
+	return callContext[RegisterRequest, RegisterResponse](ctx, d, epRegister, nil, nil, req)
+}
+
+// logoutAPI calls POST auth/logout: Log out.
+func (d *DarkThroneApi) logoutAPI(ctx context.Context) error {
+	_, err := callContext[struct{}, struct{}](ctx, d, epLogout, nil, nil, struct{}{})
+	return err
+}
+
+// currentUserAPI calls GET auth/current-user: Get the current user.
+func (d *DarkThroneApi) currentUserAPI(ctx context.Context) (CurrentUserResponse, error) {
+	return callContext[struct{}, CurrentUserResponse](ctx, d, epCurrentUser, nil, nil, struct{}{})
+}
+
+// currentUserPlayersAPI calls GET auth/current-user/players: List the current user's players.
+func (d *DarkThroneApi) currentUserPlayersAPI(ctx context.Context) (UserPlayersListResponse, error) {
+	return callContext[struct{}, UserPlayersListResponse](ctx, d, epCurrentUserPlayers, nil, nil, struct{}{})
+}
+
+// assumePlayerAPI calls POST auth/assume-player: Assume one of the current user's players.
+func (d *DarkThroneApi) assumePlayerAPI(ctx context.Context, req assumePlayerRequest) (CurrentUserResponse, error) {
+	return callContext[assumePlayerRequest, CurrentUserResponse](ctx, d, epAssumePlayer, nil, nil, req)
+}
+
+// unassumePlayerAPI calls POST auth/unassume-player: Unassume the current player.
+func (d *DarkThroneApi) unassumePlayerAPI(ctx context.Context) error {
+	_, err := callContext[struct{}, struct{}](ctx, d, epUnassumePlayer, nil, nil, struct{}{})
+	return err
+}
+
+// playersListAPI calls GET players: List players.
+func (d *DarkThroneApi) playersListAPI(ctx context.Context, query url.Values) (PlayersListResponse, error) {
+	return callContext[struct{}, PlayersListResponse](ctx, d, epPlayersList, nil, query, struct{}{})
+}
+
+// createPlayerAPI calls POST players: Create a player.
+func (d *DarkThroneApi) createPlayerAPI(ctx context.Context, req CreatePlayerRequest) (Player, error) {
+	return callContext[CreatePlayerRequest, Player](ctx, d, epPlayersCreate, nil, nil, req)
+}
+
+// validatePlayerNameAPI calls POST players/validate-name: Check whether a player name is available.
+func (d *DarkThroneApi) validatePlayerNameAPI(ctx context.Context, req validatePlayerNameRequest) (validatePlayerNameResponse, error) {
+	return callContext[validatePlayerNameRequest, validatePlayerNameResponse](ctx, d, epPlayersValidateName, nil, nil, req)
+}
+
+// playerAPI calls GET players/{id}: Get a player.
+func (d *DarkThroneApi) playerAPI(ctx context.Context, id string) (Player, error) {
+	return callContext[struct{}, Player](ctx, d, epPlayersGet, pathParams{"id": id}, nil, struct{}{})
+}
+
+// matchingIDsAPI calls POST players/matching-ids: Get several players by ID.
+func (d *DarkThroneApi) matchingIDsAPI(ctx context.Context, req matchingIDsRequest) (matchingIDsResponse, error) {
+	return callContext[matchingIDsRequest, matchingIDsResponse](ctx, d, epPlayersMatchingIDs, nil, nil, req)
+}
+
+// warHistoryAPI calls GET war-history/{id}: Get a war history record.
+func (d *DarkThroneApi) warHistoryAPI(ctx context.Context, id string) (WarHistory, error) {
+	return callContext[struct{}, WarHistory](ctx, d, epWarHistoryGet, pathParams{"id": id}, nil, struct{}{})
+}
+
+// warHistoryListAPI calls GET war-history: List war history.
+func (d *DarkThroneApi) warHistoryListAPI(ctx context.Context, query url.Values) (WarHistoryPage, error) {
+	return callContext[struct{}, WarHistoryPage](ctx, d, epWarHistoryList, nil, query, struct{}{})
+}
+
+// trainUnitsAPI calls POST training/train: Train units.
+func (d *DarkThroneApi) trainUnitsAPI(ctx context.Context, req TrainUnitsRequest) (TrainUnitsResponse, error) {
+	return callContext[TrainUnitsRequest, TrainUnitsResponse](ctx, d, epTrainingTrain, nil, nil, req)
+}
+
+// untrainUnitsAPI calls POST training/untrain: Untrain units.
+func (d *DarkThroneApi) untrainUnitsAPI(ctx context.Context, req UntrainUnitsRequest) (UntrainUnitsResponse, error) {
+	return callContext[UntrainUnitsRequest, UntrainUnitsResponse](ctx, d, epTrainingUntrain, nil, nil, req)
+}
+
+// attackAPI calls POST attack: Attack a player.
+func (d *DarkThroneApi) attackAPI(ctx context.Context, req attackRequest) (AttackResponse, error) {
+	return callContext[attackRequest, AttackResponse](ctx, d, epAttack, nil, nil, req)
+}
+
+// bankDepositAPI calls POST bank/deposit: Deposit gold.
+func (d *DarkThroneApi) bankDepositAPI(ctx context.Context, req BankDepositRequest) (BankResponse, error) {
+	return callContext[BankDepositRequest, BankResponse](ctx, d, epBankDeposit, nil, nil, req)
+}
+
+// bankWithdrawAPI calls POST bank/withdraw: Withdraw gold.
+func (d *DarkThroneApi) bankWithdrawAPI(ctx context.Context, req BankWithdrawRequest) (BankResponse, error) {
+	return callContext[BankWithdrawRequest, BankResponse](ctx, d, epBankWithdraw, nil, nil, req)
+}
+
+// unitCatalogAPI calls GET units: Get the unit catalog.
+func (d *DarkThroneApi) unitCatalogAPI(ctx context.Context) (UnitCatalog, error) {
+	return callContext[struct{}, UnitCatalog](ctx, d, epUnitsList, nil, nil, struct{}{})
+}
+
+// upgradeStructureAPI calls POST structures/upgrade: Upgrade a structure.
+func (d *DarkThroneApi) upgradeStructureAPI(ctx context.Context, req UpgradeStructureRequest) (UpgradeStructureResponse, error) {
+	return callContext[UpgradeStructureRequest, UpgradeStructureResponse](ctx, d, epStructuresUpgrade, nil, nil, req)
+}
+
+// spendProficiencyPointsAPI calls POST proficiency-points: Spend proficiency points.
+func (d *DarkThroneApi) spendProficiencyPointsAPI(ctx context.Context, req ProficiencyPointsRequest) (ProficiencyPointsResponse, error) {
+	return callContext[ProficiencyPointsRequest, ProficiencyPointsResponse](ctx, d, epProficiencyPointsSpend, nil, nil, req)
+}
@@ -0,0 +1,235 @@
+// Code generated by genendpoints from endpoints.yaml. DO NOT EDIT.
+
+package DarkThroneApi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// newGeneratedTestServer answers every request with a JSON null after checking method, path,
+// query parameter, authentication and that only endpoints with a payload send a JSON body.
+func newGeneratedTestServer(t *testing.T, method, path, query string, auth, body bool) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method || r.URL.Path != path {
+			t.Errorf("request = %s %s, want %s %s", r.Method, r.URL.Path, method, path)
+		}
+		if query != "" && r.URL.Query().Get(query) != "1" {
+			t.Errorf("query = %q, want %s=1", r.URL.RawQuery, query)
+		}
+		if got, want := r.Header.Get("Authorization"), map[bool]string{true: "Bearer test-token"}[auth]; got != want {
+			t.Errorf("Authorization = %q, want %q", got, want)
+		}
+		data, _ := io.ReadAll(r.Body)
+		if len(data) > 0 && (!body || !json.Valid(data)) {
+			t.Errorf("unexpected request body %q", data)
+		}
+		w.Write([]byte("null"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGenerated_loginAPI(t *testing.T) {
+	server := newGeneratedTestServer(t, "POST", "/auth/login", "", false, true)
+	api := newTestApi(t, server.URL)
+	api.token = "test-token"
+	if _, err := api.loginAPI(context.Background(), LoginRequest{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerated_registerAPI(t *testing.T) {
+	server := newGeneratedTestServer(t, "POST", "/auth/register", "", false, true)
+	api := newTestApi(t, server.URL)
+	api.token = "test-token"
+	if _, err := api.registerAPI(context.Background(), RegisterRequest{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerated_logoutAPI(t *testing.T) {
+	server := newGeneratedTestServer(t, "POST", "/auth/logout", "", true, false)
+	api := newTestApi(t, server.URL)
+	api.token = "test-token"
+	if err := api.logoutAPI(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerated_currentUserAPI(t *testing.T) {
+	server := newGeneratedTestServer(t, "GET", "/auth/current-user", "", true, false)
+	api := newTestApi(t, server.URL)
+	api.token = "test-token"
+	if _, err := api.currentUserAPI(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerated_currentUserPlayersAPI(t *testing.T) {
+	server := newGeneratedTestServer(t, "GET", "/auth/current-user/players", "", true, false)
+	api := newTestApi(t, server.URL)
+	api.token = "test-token"
+	if _, err := api.currentUserPlayersAPI(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerated_assumePlayerAPI(t *testing.T) {
+	server := newGeneratedTestServer(t, "POST", "/auth/assume-player", "", true, true)
+	api := newTestApi(t, server.URL)
+	api.token = "test-token"
+	if _, err := api.assumePlayerAPI(context.Background(), assumePlayerRequest{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerated_unassumePlayerAPI(t *testing.T) {
+	server := newGeneratedTestServer(t, "POST", "/auth/unassume-player", "", true, false)
+	api := newTestApi(t, server.URL)
+	api.token = "test-token"
+	if err := api.unassumePlayerAPI(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerated_playersListAPI(t *testing.T) {
+	server := newGeneratedTestServer(t, "GET", "/players", "page", true, false)
+	api := newTestApi(t, server.URL)
+	api.token = "test-token"
+	if _, err := api.playersListAPI(context.Background(), url.Values{"page": {"1"}}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerated_createPlayerAPI(t *testing.T) {
+	server := newGeneratedTestServer(t, "POST", "/players", "", true, true)
+	api := newTestApi(t, server.URL)
+	api.token = "test-token"
+	if _, err := api.createPlayerAPI(context.Background(), CreatePlayerRequest{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerated_validatePlayerNameAPI(t *testing.T) {
+	server := newGeneratedTestServer(t, "POST", "/players/validate-name", "", true, true)
+	api := newTestApi(t, server.URL)
+	api.token = "test-token"
+	if _, err := api.validatePlayerNameAPI(context.Background(), validatePlayerNameRequest{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerated_playerAPI(t *testing.T) {
+	server := newGeneratedTestServer(t, "GET", "/players/x1", "", true, false)
+	api := newTestApi(t, server.URL)
+	api.token = "test-token"
+	if _, err := api.playerAPI(context.Background(), "x1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerated_matchingIDsAPI(t *testing.T) {
+	server := newGeneratedTestServer(t, "POST", "/players/matching-ids", "", true, true)
+	api := newTestApi(t, server.URL)
+	api.token = "test-token"
+	if _, err := api.matchingIDsAPI(context.Background(), matchingIDsRequest{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerated_warHistoryAPI(t *testing.T) {
+	server := newGeneratedTestServer(t, "GET", "/war-history/x1", "", true, false)
+	api := newTestApi(t, server.URL)
+	api.token = "test-token"
+	if _, err := api.warHistoryAPI(context.Background(), "x1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerated_warHistoryListAPI(t *testing.T) {
+	server := newGeneratedTestServer(t, "GET", "/war-history", "page", true, false)
+	api := newTestApi(t, server.URL)
+	api.token = "test-token"
+	if _, err := api.warHistoryListAPI(context.Background(), url.Values{"page": {"1"}}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerated_trainUnitsAPI(t *testing.T) {
+	server := newGeneratedTestServer(t, "POST", "/training/train", "", true, true)
+	api := newTestApi(t, server.URL)
+	api.token = "test-token"
+	if _, err := api.trainUnitsAPI(context.Background(), TrainUnitsRequest{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerated_untrainUnitsAPI(t *testing.T) {
+	server := newGeneratedTestServer(t, "POST", "/training/untrain", "", true, true)
+	api := newTestApi(t, server.URL)
+	api.token = "test-token"
+	if _, err := api.untrainUnitsAPI(context.Background(), UntrainUnitsRequest{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerated_attackAPI(t *testing.T) {
+	server := newGeneratedTestServer(t, "POST", "/attack", "", true, true)
+	api := newTestApi(t, server.URL)
+	api.token = "test-token"
+	if _, err := api.attackAPI(context.Background(), attackRequest{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerated_bankDepositAPI(t *testing.T) {
+	server := newGeneratedTestServer(t, "POST", "/bank/deposit", "", true, true)
+	api := newTestApi(t, server.URL)
+	api.token = "test-token"
+	if _, err := api.bankDepositAPI(context.Background(), BankDepositRequest{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerated_bankWithdrawAPI(t *testing.T) {
+	server := newGeneratedTestServer(t, "POST", "/bank/withdraw", "", true, true)
+	api := newTestApi(t, server.URL)
+	api.token = "test-token"
+	if _, err := api.bankWithdrawAPI(context.Background(), BankWithdrawRequest{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerated_unitCatalogAPI(t *testing.T) {
+	server := newGeneratedTestServer(t, "GET", "/units", "", true, false)
+	api := newTestApi(t, server.URL)
+	api.token = "test-token"
+	if _, err := api.unitCatalogAPI(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerated_upgradeStructureAPI(t *testing.T) {
+	server := newGeneratedTestServer(t, "POST", "/structures/upgrade", "", true, true)
+	api := newTestApi(t, server.URL)
+	api.token = "test-token"
+	if _, err := api.upgradeStructureAPI(context.Background(), UpgradeStructureRequest{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerated_spendProficiencyPointsAPI(t *testing.T) {
+	server := newGeneratedTestServer(t, "POST", "/proficiency-points", "", true, true)
+	api := newTestApi(t, server.URL)
+	api.token = "test-token"
+	if _, err := api.spendProficiencyPointsAPI(context.Background(), ProficiencyPointsRequest{}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,155 @@
+package DarkThroneApi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestApi returns a client pointed at baseURL with rate limiting disabled for the duration of the test.
+func newTestApi(t *testing.T, baseURL string) *DarkThroneApi {
+	t.Helper()
+	previous := doRequestRateLimit
+	doRequestRateLimit = 0
+	t.Cleanup(func() { doRequestRateLimit = previous })
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return &DarkThroneApi{
+		config:    &Config{Logger: logger},
+		apiConfig: &ApiRequestConfig{BaseURL: baseURL, Logger: logger},
+		events:    NewEventBus(),
+	}
+}
+
+func TestEndpointRegistry_Complete(t *testing.T) {
+	for name, ep := range endpointRegistry {
+		if ep.Method == "" || ep.Path == "" || ep.RateLimitGroup == "" {
+			t.Errorf("endpoint %s is missing method, path or rate-limit group: %+v", name, ep)
+		}
+		if ep.Method == "GET" && !ep.Idempotent {
+			t.Errorf("GET endpoint %s should be idempotent", name)
+		}
+	}
+}
+
+func TestEndpoint_BuildPath(t *testing.T) {
+	ep := endpoint{Path: "players/{id}"}
+	path, err := ep.buildPath(pathParams{"id": "a/b?c"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != "players/a%2Fb%3Fc" {
+		t.Errorf("unexpected path: %s", path)
+	}
+	if _, err := ep.buildPath(nil); err == nil {
+		t.Error("expected error for missing path parameter")
+	}
+}
+
+func TestEscapePathSegment_DotSegments(t *testing.T) {
+	for in, want := range map[string]string{".": "%2E", "..": "%2E%2E", "...": "...", "a.b": "a.b"} {
+		if got := escapePathSegment(in); got != want {
+			t.Errorf("escapePathSegment(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// FuzzPlayerPath checks that no user-supplied ID can change the endpoint a request is sent to.
+func FuzzPlayerPath(f *testing.F) {
+	for _, seed := range []string{"abc", "a/b", "..", ".", "../auth/logout", "x?admin=1", "#frag", "%2e%2e", "a%2Fb", " ", "\\..\\"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, id string) {
+		if id == "" {
+			return
+		}
+		ep := endpointRegistry[epPlayersGet]
+		path, err := ep.buildPath(pathParams{"id": id})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		req := ApiRequest[struct{}, struct{}]{Endpoint: path, Config: &ApiRequestConfig{BaseURL: "http://localhost"}}
+		u, err := url.Parse(req.GetUrl())
+		if err != nil {
+			t.Fatalf("generated URL does not parse: %v", err)
+		}
+		if u.Host != "localhost" || u.RawQuery != "" || u.Fragment != "" {
+			t.Fatalf("id %q changed the URL: %s", id, u)
+		}
+		segments := strings.Split(u.EscapedPath(), "/")
+		if len(segments) != 3 || segments[0] != "" || segments[1] != "players" {
+			t.Fatalf("id %q changed the path: %s", id, u.EscapedPath())
+		}
+		if got, err := url.PathUnescape(segments[2]); err != nil || got != id {
+			t.Fatalf("id %q was not preserved: %q (%v)", id, got, err)
+		}
+	})
+}
+
+func TestCall_RequiresToken(t *testing.T) {
+	d := newTestApi(t, "http://127.0.0.1:0")
+	_, err := call[struct{}, Player](d, epPlayersGet, pathParams{"id": "x"}, nil, struct{}{})
+	if !errors.Is(err, ErrNotAuthenticated) {
+		t.Errorf("expected ErrNotAuthenticated, got %v", err)
+	}
+}
+
+func TestCall_SendsAuthHeaderAndPath(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/auth/current-user" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			t.Errorf("missing auth header: %q", r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`{"player":{"id":"p1"}}`))
+	}))
+	defer ts.Close()
+
+	d := newTestApi(t, ts.URL)
+	d.token = "tok"
+	resp, err := d.GetCurrentUserAPI()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Player.ID != "p1" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestWaitForRateLimit_SpacesRequestsPerGroup(t *testing.T) {
+	previous := doRequestRateLimit
+	doRequestRateLimit = 20 * time.Millisecond
+	defer func() { doRequestRateLimit = previous }()
+
+	start := time.Now()
+	ctx := context.Background()
+	waitForRateLimit(ctx, "test-a")
+	waitForRateLimit(ctx, "test-a")
+	waitForRateLimit(ctx, "test-b")
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond || elapsed > 200*time.Millisecond {
+		t.Errorf("unexpected elapsed time: %s", elapsed)
+	}
+}
+
+func TestWaitForRateLimit_CapsAllGroupsTogether(t *testing.T) {
+	previous := doRequestRateLimit
+	doRequestRateLimit = 40 * time.Millisecond
+	defer func() { doRequestRateLimit = previous }()
+
+	// One request in each of five groups: the overall cap spaces them by 40ms.
+	start := time.Now()
+	ctx := context.Background()
+	for _, group := range []string{"cap-a", "cap-b", "cap-c", "cap-d", "cap-e"} {
+		waitForRateLimit(ctx, group)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond || elapsed > 600*time.Millisecond {
+		t.Errorf("unexpected elapsed time: %s", elapsed)
+	}
+}
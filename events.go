@@ -0,0 +1,327 @@
+package DarkThroneApi
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event is implemented by every event published on the client's EventBus.
+type Event interface {
+	// EventName returns a stable identifier such as "attack.completed".
+	EventName() string
+}
+
+// LoggedIn is published after a successful Login.
+type LoggedIn struct {
+	Email string
+	At    time.Time
+}
+
+// LoggedOut is published after a successful Logout.
+type LoggedOut struct {
+	At time.Time
+}
+
+// UserRegistered is published after a successful Register.
+type UserRegistered struct {
+	Email    string
+	Username string
+	At       time.Time
+}
+
+// PlayerAssumed is published when the client assumes a player.
+type PlayerAssumed struct {
+	Player Player
+	At     time.Time
+}
+
+// PlayerUnassumed is published when the client unassumes the current player.
+type PlayerUnassumed struct {
+	At time.Time
+}
+
+// PlayerCreated is published after a successful CreatePlayer.
+type PlayerCreated struct {
+	Player Player
+	At     time.Time
+}
+
+// AttackCompleted is published when an attack request succeeds, whether or not the attack was won.
+type AttackCompleted struct {
+	TargetID    string
+	AttackTurns int
+	Victory     bool
+	At          time.Time
+}
+
+// UnitsTrained is published after a successful TrainUnits.
+type UnitsTrained struct {
+	PlayerID string
+	Units    []UnitRequest
+	At       time.Time
+}
+
+// UnitsUntrained is published after a successful UntrainUnits.
+type UnitsUntrained struct {
+	PlayerID string
+	Units    []UnitRequest
+	At       time.Time
+}
+
+// GoldDeposited is published after a successful DepositGold.
+type GoldDeposited struct {
+	PlayerID string
+	Amount   int
+	Balance  int
+	At       time.Time
+}
+
+// GoldWithdrawn is published after a successful WithdrawGold.
+type GoldWithdrawn struct {
+	PlayerID string
+	Amount   int
+	Balance  int
+	At       time.Time
+}
+
+// SessionExpired is published when the server rejects the client's token.
+type SessionExpired struct {
+	Endpoint string
+	Err      error
+	At       time.Time
+}
+
+// RequestFailed is published whenever an endpoint call returns an error.
+type RequestFailed struct {
+	Endpoint string
+	Err      error
+	At       time.Time
+}
+
+// RequestSimulated is published in dry-run mode instead of sending a mutating request.
+type RequestSimulated struct {
+	Endpoint string
+	Request  any
+	Response any
+	At       time.Time
+}
+
+func (LoggedIn) EventName() string         { return "auth.logged-in" }
+func (LoggedOut) EventName() string        { return "auth.logged-out" }
+func (UserRegistered) EventName() string   { return "auth.registered" }
+func (PlayerAssumed) EventName() string    { return "player.assumed" }
+func (PlayerUnassumed) EventName() string  { return "player.unassumed" }
+func (PlayerCreated) EventName() string    { return "player.created" }
+func (AttackCompleted) EventName() string  { return "attack.completed" }
+func (UnitsTrained) EventName() string     { return "training.trained" }
+func (UnitsUntrained) EventName() string   { return "training.untrained" }
+func (GoldDeposited) EventName() string    { return "bank.deposited" }
+func (GoldWithdrawn) EventName() string    { return "bank.withdrawn" }
+func (SessionExpired) EventName() string   { return "auth.session-expired" }
+func (RequestFailed) EventName() string    { return "request.failed" }
+func (RequestSimulated) EventName() string { return "request.simulated" }
+
+// OverflowPolicy decides what an asynchronous subscriber does when its buffer is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock makes Publish wait until the subscriber has room, applying back-pressure to the client.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest discards the event being published.
+	OverflowDropNewest
+	// OverflowDropOldest discards the oldest buffered event to make room.
+	OverflowDropOldest
+)
+
+// defaultEventBuffer is the buffer size used by asynchronous subscribers that do not set one.
+const defaultEventBuffer = 64
+
+// EventBus delivers client events to subscribers.
+// Synchronous subscribers run on the publishing goroutine; asynchronous subscribers each
+// get a buffered queue and a goroutine of their own.
+type EventBus struct {
+	mu sync.RWMutex
+	// subs is ordered by ID, so synchronous subscribers run in the order they subscribed.
+	subs    []*Subscription
+	nextID  int
+	dropped atomic.Int64
+}
+
+// Subscription is a handle to a registered subscriber.
+type Subscription struct {
+	bus      *EventBus
+	id       int
+	handler  func(Event)
+	queue    chan Event
+	overflow OverflowPolicy
+	// stop is closed by Unsubscribe; the queue itself is never closed, so late publishers cannot panic.
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	removed atomic.Bool
+}
+
+// NewEventBus creates an EventBus without subscribers.
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe registers a synchronous handler. It is called on the publishing goroutine,
+// so slow handlers slow down the client.
+func (b *EventBus) Subscribe(handler func(Event)) *Subscription {
+	return b.add(&Subscription{handler: handler})
+}
+
+// SubscribeAsync registers a handler that runs on its own goroutine with a queue of buffer events.
+// overflow selects what happens when the queue is full.
+func (b *EventBus) SubscribeAsync(handler func(Event), buffer int, overflow OverflowPolicy) *Subscription {
+	if buffer <= 0 {
+		buffer = defaultEventBuffer
+	}
+	s := &Subscription{handler: handler, queue: make(chan Event, buffer), overflow: overflow, stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		for {
+			select {
+			case e := <-s.queue:
+				s.handler(e)
+			case <-s.stop:
+				// Handle what was queued before Unsubscribe, then exit.
+				for {
+					select {
+					case e := <-s.queue:
+						s.handler(e)
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+	return b.add(s)
+}
+
+// SubscribeTo registers a synchronous handler for events of type E only.
+func SubscribeTo[E Event](b *EventBus, handler func(E)) *Subscription {
+	return b.Subscribe(func(e Event) {
+		if typed, ok := e.(E); ok {
+			handler(typed)
+		}
+	})
+}
+
+func (b *EventBus) add(s *Subscription) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s.bus = b
+	s.id = b.nextID
+	b.nextID++
+	b.subs = append(b.subs, s)
+	return s
+}
+
+// Publish delivers e to every subscriber in the order they subscribed. It is a no-op on a nil bus.
+// No lock is held while handlers run, so handlers may subscribe and unsubscribe.
+func (b *EventBus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	subs := slices.Clone(b.subs)
+	b.mu.RUnlock()
+	for _, s := range subs {
+		if s.removed.Load() {
+			continue
+		}
+		if s.queue == nil {
+			s.handler(e)
+			continue
+		}
+		b.enqueue(s, e)
+	}
+}
+
+// enqueue hands e to an asynchronous subscriber according to its overflow policy.
+func (b *EventBus) enqueue(s *Subscription, e Event) {
+	switch s.overflow {
+	case OverflowBlock:
+		select {
+		case s.queue <- e:
+		case <-s.stop:
+		}
+	case OverflowDropNewest:
+		select {
+		case s.queue <- e:
+		default:
+			b.dropped.Add(1)
+		}
+	case OverflowDropOldest:
+		for !s.removed.Load() {
+			select {
+			case s.queue <- e:
+				return
+			default:
+			}
+			select {
+			case <-s.queue:
+				b.dropped.Add(1)
+			default:
+			}
+		}
+	}
+}
+
+// Dropped returns the number of events discarded because an asynchronous subscriber was full.
+func (b *EventBus) Dropped() int64 {
+	return b.dropped.Load()
+}
+
+// Unsubscribe removes the subscriber. For asynchronous subscribers it waits until queued events are handled,
+// so it must not be called from the subscriber's own handler, which would wait for itself; use Stop there.
+func (s *Subscription) Unsubscribe() {
+	s.Stop()
+	if s.done != nil {
+		<-s.done
+	}
+}
+
+// Stop removes the subscriber like Unsubscribe, but returns without waiting for an asynchronous
+// subscriber's queued events, which are still handled on its goroutine. It may be called from any handler.
+func (s *Subscription) Stop() {
+	s.once.Do(func() {
+		s.removed.Store(true)
+		s.bus.mu.Lock()
+		s.bus.subs = slices.DeleteFunc(s.bus.subs, func(o *Subscription) bool { return o == s })
+		s.bus.mu.Unlock()
+		if s.stop != nil {
+			close(s.stop)
+		}
+	})
+}
+
+// Events returns the client's event bus.
+func (d *DarkThroneApi) Events() *EventBus {
+	return d.events
+}
+
+// publish sends e on the client's event bus, if there is one.
+func (d *DarkThroneApi) publish(e Event) {
+	if d.dryRun() && isMutationEvent(e) {
+		return
+	}
+	d.events.Publish(e)
+}
+
+// publishFailure publishes RequestFailed for err, and SessionExpired as well when the server rejected the token.
+func (d *DarkThroneApi) publishFailure(name endpointName, err error) {
+	now := time.Now()
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusUnauthorized && d.token != "" {
+		d.publish(SessionExpired{Endpoint: string(name), Err: err, At: now})
+	}
+	d.publish(RequestFailed{Endpoint: string(name), Err: err, At: now})
+}
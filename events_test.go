@@ -0,0 +1,211 @@
+package DarkThroneApi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEventBus_SyncSubscriberReceivesEvents(t *testing.T) {
+	bus := NewEventBus()
+	var got []string
+	sub := bus.Subscribe(func(e Event) { got = append(got, e.EventName()) })
+	bus.Publish(LoggedIn{Email: "a@b.c"})
+	bus.Publish(LoggedOut{})
+	sub.Unsubscribe()
+	bus.Publish(LoggedIn{})
+	if len(got) != 2 || got[0] != "auth.logged-in" || got[1] != "auth.logged-out" {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestSubscribeTo_FiltersByType(t *testing.T) {
+	bus := NewEventBus()
+	var deposits []GoldDeposited
+	SubscribeTo(bus, func(e GoldDeposited) { deposits = append(deposits, e) })
+	bus.Publish(GoldWithdrawn{Amount: 5})
+	bus.Publish(GoldDeposited{Amount: 10})
+	if len(deposits) != 1 || deposits[0].Amount != 10 {
+		t.Errorf("got %+v", deposits)
+	}
+}
+
+func TestEventBus_AsyncDeliversAllWhenBlocking(t *testing.T) {
+	bus := NewEventBus()
+	var mu sync.Mutex
+	count := 0
+	sub := bus.SubscribeAsync(func(Event) {
+		mu.Lock()
+		count++
+		mu.Unlock()
+	}, 1, OverflowBlock)
+	for i := 0; i < 50; i++ {
+		bus.Publish(LoggedOut{})
+	}
+	sub.Unsubscribe()
+	if count != 50 || bus.Dropped() != 0 {
+		t.Errorf("count = %d, dropped = %d", count, bus.Dropped())
+	}
+}
+
+func TestEventBus_AsyncDropPolicies(t *testing.T) {
+	for _, policy := range []OverflowPolicy{OverflowDropNewest, OverflowDropOldest} {
+		bus := NewEventBus()
+		release := make(chan struct{})
+		var received []int
+		sub := bus.SubscribeAsync(func(e Event) {
+			<-release
+			received = append(received, e.(GoldDeposited).Amount)
+		}, 2, policy)
+		// The first event is taken by the handler, which blocks; the buffer then holds two.
+		bus.Publish(GoldDeposited{Amount: 1})
+		time.Sleep(20 * time.Millisecond)
+		for i := 2; i <= 5; i++ {
+			bus.Publish(GoldDeposited{Amount: i})
+		}
+		close(release)
+		sub.Unsubscribe()
+		if bus.Dropped() != 2 {
+			t.Errorf("policy %d: dropped = %d, want 2", policy, bus.Dropped())
+		}
+		want := []int{1, 2, 3}
+		if policy == OverflowDropOldest {
+			want = []int{1, 4, 5}
+		}
+		if len(received) != 3 || received[0] != want[0] || received[1] != want[1] || received[2] != want[2] {
+			t.Errorf("policy %d: received %v, want %v", policy, received, want)
+		}
+	}
+}
+
+func TestEvents_EmittedByClientMethods(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bank/deposit":
+			w.Write([]byte(`{"success":true,"balance":150}`))
+		case "/attack":
+			w.Write([]byte(`{"isAttackerVictor":true}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+	api := newTestApi(t, server.URL)
+	api.token = "token"
+	var events []Event
+	api.Events().Subscribe(func(e Event) { events = append(events, e) })
+
+	if _, err := api.DepositGold(BankDepositRequest{PlayerID: "p1", Amount: 50}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.AttackPlayer("p2"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := api.FetchPlayerByID("p3")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 StatusError, got %v", err)
+	}
+
+	if len(events) != 4 {
+		t.Fatalf("got %d events: %+v", len(events), events)
+	}
+	if e, ok := events[0].(GoldDeposited); !ok || e.Balance != 150 || e.Amount != 50 {
+		t.Errorf("events[0] = %+v", events[0])
+	}
+	if e, ok := events[1].(AttackCompleted); !ok || !e.Victory || e.TargetID != "p2" {
+		t.Errorf("events[1] = %+v", events[1])
+	}
+	if e, ok := events[2].(SessionExpired); !ok || e.Endpoint != string(epPlayersGet) {
+		t.Errorf("events[2] = %+v", events[2])
+	}
+	if _, ok := events[3].(RequestFailed); !ok {
+		t.Errorf("events[3] = %+v", events[3])
+	}
+}
+
+func TestEventBus_HandlersMaySubscribeAndUnsubscribe(t *testing.T) {
+	bus := NewEventBus()
+	var calls, added int
+	var sub *Subscription
+	sub = bus.Subscribe(func(Event) {
+		calls++
+		sub.Unsubscribe()
+		bus.Subscribe(func(Event) { added++ })
+	})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		bus.Publish(LoggedOut{})
+		bus.Publish(LoggedOut{})
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Publish deadlocked when a handler changed the subscriptions")
+	}
+	if calls != 1 || added != 1 {
+		t.Errorf("calls = %d, added = %d, want 1 and 1", calls, added)
+	}
+}
+
+func TestEventBus_UnsubscribeDuringBlockingPublish(t *testing.T) {
+	bus := NewEventBus()
+	release := make(chan struct{})
+	sub := bus.SubscribeAsync(func(Event) { <-release }, 1, OverflowBlock)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bus.Publish(LoggedOut{})
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	sub.Unsubscribe()
+	wg.Wait()
+}
+
+func TestEventBus_SyncSubscribersRunInSubscriptionOrder(t *testing.T) {
+	bus := NewEventBus()
+	var order []int
+	for i := 0; i < 20; i++ {
+		bus.Subscribe(func(Event) { order = append(order, i) })
+	}
+	bus.Subscribe(func(Event) {}).Unsubscribe()
+	bus.Subscribe(func(Event) { order = append(order, 20) })
+	bus.Publish(LoggedOut{})
+	for i, n := range order {
+		if n != i {
+			t.Fatalf("order = %v, want subscription order", order)
+		}
+	}
+	if len(order) != 21 {
+		t.Errorf("order = %v, want 21 handlers", order)
+	}
+}
+
+func TestSubscription_StopFromOwnAsyncHandler(t *testing.T) {
+	bus := NewEventBus()
+	var sub *Subscription
+	handled := make(chan struct{}, 2)
+	sub = bus.SubscribeAsync(func(Event) {
+		sub.Stop()
+		handled <- struct{}{}
+	}, 1, OverflowBlock)
+	bus.Publish(LoggedOut{})
+	select {
+	case <-handled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop from the subscriber's own handler deadlocked")
+	}
+	bus.Publish(LoggedOut{})
+	sub.Unsubscribe()
+	if len(handled) != 0 {
+		t.Error("a stopped subscriber received an event")
+	}
+}
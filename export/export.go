@@ -0,0 +1,461 @@
+// Package export writes Dark Throne data to CSV, NDJSON or SQLite files with a stable schema.
+//
+// War history and bank ledger exports are incremental: records whose ID is already present in
+// the target file are skipped, so re-running an export only adds new records. Player exports
+// replace previously exported rows for the same player ID. Records without an ID are never exported.
+package export
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	DarkThroneApi "github.com/Rihoj/DarkThroneApi"
+	"github.com/Rihoj/DarkThroneApi/audit"
+	_ "modernc.org/sqlite"
+)
+
+// Format selects the output file format.
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+	FormatSQLite Format = "sqlite"
+)
+
+// ParseFormat converts a user-supplied format name into a Format.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(name)); f {
+	case FormatCSV, FormatNDJSON, FormatSQLite:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown export format %q (want csv, ndjson or sqlite)", name)
+}
+
+// columnType is the SQLite storage class of a column.
+type columnType string
+
+const (
+	typeText    columnType = "TEXT"
+	typeInteger columnType = "INTEGER"
+)
+
+// column describes one field of an exported record.
+type column[T any] struct {
+	name  string
+	typ   columnType
+	value func(T) any
+}
+
+// table describes the stable schema of an exported record type. The first column is the primary key.
+type table[T any] struct {
+	name    string
+	columns []column[T]
+	// replace selects upsert semantics; otherwise rows whose key already exists are skipped.
+	replace bool
+}
+
+// warHistoryTable is the schema used for war history exports.
+var warHistoryTable = table[DarkThroneApi.WarHistory]{
+	name: "war_history",
+	columns: []column[DarkThroneApi.WarHistory]{
+		{"id", typeText, func(w DarkThroneApi.WarHistory) any { return w.ID }},
+		{"created_at", typeText, func(w DarkThroneApi.WarHistory) any { return formatTime(w.Time()) }},
+		{"attacker_id", typeText, func(w DarkThroneApi.WarHistory) any { return w.AttackerID }},
+		{"defender_id", typeText, func(w DarkThroneApi.WarHistory) any { return w.DefenderID }},
+		{"is_attacker_victor", typeInteger, func(w DarkThroneApi.WarHistory) any { return boolToInt(w.IsAttackerVictor) }},
+		{"attack_turns_used", typeInteger, func(w DarkThroneApi.WarHistory) any { return w.AttackTurnsUsed }},
+		{"gold_stolen", typeInteger, func(w DarkThroneApi.WarHistory) any { return w.GoldStolen }},
+		{"attacker_strength", typeInteger, func(w DarkThroneApi.WarHistory) any { return w.AttackerStrength }},
+		{"defender_strength", typeInteger, func(w DarkThroneApi.WarHistory) any { return w.DefenderStrength }},
+		{"attacker_casualties", typeInteger, func(w DarkThroneApi.WarHistory) any { return w.AttackerCasualties }},
+		{"defender_casualties", typeInteger, func(w DarkThroneApi.WarHistory) any { return w.DefenderCasualties }},
+		{"xp_earned", typeInteger, func(w DarkThroneApi.WarHistory) any { return w.XPEarned }},
+	},
+}
+
+// tableNamePattern matches the table names that may be interpolated into SQL statements.
+var tableNamePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// playerTable returns the schema used for player exports under the given table name.
+func playerTable(name string, exportedAt time.Time) table[DarkThroneApi.Player] {
+	return table[DarkThroneApi.Player]{
+		name:    name,
+		replace: true,
+		columns: []column[DarkThroneApi.Player]{
+			{"id", typeText, func(p DarkThroneApi.Player) any { return p.ID }},
+			{"exported_at", typeText, func(DarkThroneApi.Player) any { return formatTime(exportedAt) }},
+			{"name", typeText, func(p DarkThroneApi.Player) any { return p.Name }},
+			{"level", typeInteger, func(p DarkThroneApi.Player) any { return p.Level }},
+			{"gold", typeInteger, func(p DarkThroneApi.Player) any { return p.Gold }},
+			{"army_size", typeInteger, func(p DarkThroneApi.Player) any { return p.ArmySize }},
+			{"attack_turns", typeInteger, func(p DarkThroneApi.Player) any { return p.AttackTurns }},
+			{"units", typeText, func(p DarkThroneApi.Player) any { return unitsJSON(p.Units) }},
+		},
+	}
+}
+
+// WarHistory exports war history records to path, skipping records that were exported before.
+// It returns the number of records added.
+func WarHistory(path string, format Format, records []DarkThroneApi.WarHistory) (int, error) {
+	return write(path, format, warHistoryTable, records)
+}
+
+// Players exports players to path, replacing rows previously exported for the same player ID.
+// tableName distinguishes datasets in a shared SQLite file, e.g. "players" and "own_players", and
+// must match ^[a-z_][a-z0-9_]*$. It returns the number of rows written.
+func Players(path string, format Format, tableName string, players []DarkThroneApi.Player) (int, error) {
+	if !tableNamePattern.MatchString(tableName) {
+		return 0, fmt.Errorf("invalid table name %q (want lowercase letters, digits and underscores)", tableName)
+	}
+	return write(path, format, playerTable(tableName, time.Now().UTC()), players)
+}
+
+// BankTransaction is one deposit or withdrawal of the bank ledger.
+type BankTransaction struct {
+	// ID identifies the transaction; for ledgers built from an audit log it is the request's idempotency key.
+	ID       string
+	PlayerID string
+	Type     string // "deposit" or "withdraw"
+	Amount   int
+	// Balance is the bank balance the server reported after the transaction.
+	Balance int
+	At      time.Time
+}
+
+// bankTable is the schema used for bank ledger exports.
+var bankTable = table[BankTransaction]{
+	name: "bank_ledger",
+	columns: []column[BankTransaction]{
+		{"id", typeText, func(t BankTransaction) any { return t.ID }},
+		{"at", typeText, func(t BankTransaction) any { return formatTime(t.At) }},
+		{"player_id", typeText, func(t BankTransaction) any { return t.PlayerID }},
+		{"type", typeText, func(t BankTransaction) any { return t.Type }},
+		{"amount", typeInteger, func(t BankTransaction) any { return t.Amount }},
+		{"balance", typeInteger, func(t BankTransaction) any { return t.Balance }},
+	},
+}
+
+// BankLedger exports bank transactions to path, skipping transactions that were exported before.
+// It returns the number of transactions added.
+func BankLedger(path string, format Format, transactions []BankTransaction) (int, error) {
+	return write(path, format, bankTable, transactions)
+}
+
+// BankTransactionsFromAudit rebuilds the bank ledger from audit log entries. The API has no ledger
+// endpoint, so the client's own audit log is the record of its deposits and withdrawals; only
+// requests whose outcome is "done" are included. It fails on an entry whose payload or response
+// cannot be decoded, rather than record a wrong amount or balance.
+func BankTransactionsFromAudit(entries []audit.Entry) ([]BankTransaction, error) {
+	types := map[string]string{"bank.deposit": "deposit", "bank.withdraw": "withdraw"}
+	amounts := make(map[string]int)
+	var transactions []BankTransaction
+	for _, e := range entries {
+		typ, ok := types[e.Action]
+		if !ok || e.Key == "" {
+			continue
+		}
+		switch {
+		case e.Phase == audit.PhaseIntent:
+			var payload struct {
+				Amount int `json:"amount"`
+			}
+			if err := json.Unmarshal(e.Payload, &payload); err != nil {
+				return nil, fmt.Errorf("audit entry %d: decoding %s payload: %w", e.Seq, e.Action, err)
+			}
+			amounts[e.Key] = payload.Amount
+		case e.Phase == audit.PhaseOutcome && e.Outcome == "done":
+			var response DarkThroneApi.BankResponse
+			if err := json.Unmarshal(e.Response, &response); err != nil {
+				return nil, fmt.Errorf("audit entry %d: decoding %s response: %w", e.Seq, e.Action, err)
+			}
+			transactions = append(transactions, BankTransaction{
+				ID:       e.Key,
+				PlayerID: e.PlayerID,
+				Type:     typ,
+				Amount:   amounts[e.Key],
+				Balance:  response.Balance,
+				At:       e.Time,
+			})
+		}
+	}
+	return transactions, nil
+}
+
+// write dispatches to the writer for format. Records with an empty primary key cannot be told apart
+// from each other, so they are skipped.
+func write[T any](path string, format Format, t table[T], records []T) (int, error) {
+	records = slices.DeleteFunc(slices.Clone(records), func(r T) bool { return t.key(r) == "" })
+	switch format {
+	case FormatCSV:
+		return writeCSV(path, t, records)
+	case FormatNDJSON:
+		return writeNDJSON(path, t, records)
+	case FormatSQLite:
+		return writeSQLite(path, t, records)
+	}
+	return 0, fmt.Errorf("unknown export format %q", format)
+}
+
+// row returns the column values of record in schema order.
+func (t table[T]) row(record T) []any {
+	values := make([]any, len(t.columns))
+	for i, c := range t.columns {
+		values[i] = c.value(record)
+	}
+	return values
+}
+
+// key returns the primary key of record.
+func (t table[T]) key(record T) string {
+	return fmt.Sprint(t.columns[0].value(record))
+}
+
+// merge decides which existing rows survive and which records are written, given the primary keys
+// already present in the target. Duplicate keys within records are written once.
+func (t table[T]) merge(existingKeys []string, records []T) (keep map[string]bool, added []T) {
+	keep = make(map[string]bool, len(existingKeys))
+	for _, k := range existingKeys {
+		keep[k] = true
+	}
+	seen := make(map[string]bool, len(records))
+	for _, r := range records {
+		k := t.key(r)
+		if seen[k] || (!t.replace && keep[k]) {
+			continue
+		}
+		seen[k] = true
+		if t.replace {
+			delete(keep, k)
+		}
+		added = append(added, r)
+	}
+	return keep, added
+}
+
+// writeCSV appends records to a CSV file, writing the header when the file is created.
+// In replace mode the file is rewritten without the replaced rows.
+func writeCSV[T any](path string, t table[T], records []T) (int, error) {
+	header := make([]string, len(t.columns))
+	for i, c := range t.columns {
+		header[i] = c.name
+	}
+	existing, err := readCSV(path, header)
+	if err != nil {
+		return 0, err
+	}
+	keys := make([]string, len(existing))
+	for i, r := range existing {
+		keys[i] = r[0]
+	}
+	keep, added := t.merge(keys, records)
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	rewrite := t.replace || existing == nil
+	if rewrite {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	w := csv.NewWriter(f)
+	if rewrite {
+		w.Write(header)
+		for _, r := range existing {
+			if keep[r[0]] {
+				w.Write(r)
+			}
+		}
+	}
+	for _, r := range added {
+		values := t.row(r)
+		fields := make([]string, len(values))
+		for i, v := range values {
+			fields[i] = fmt.Sprint(v)
+		}
+		w.Write(fields)
+	}
+	w.Flush()
+	if err := errors.Join(w.Error(), f.Close()); err != nil {
+		return 0, err
+	}
+	return len(added), nil
+}
+
+// readCSV returns the data rows of an existing export, or nil if the file does not exist.
+func readCSV(path string, header []string) ([][]string, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read existing export %s: %w", path, err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	if strings.Join(rows[0], ",") != strings.Join(header, ",") {
+		return nil, fmt.Errorf("existing export %s has a different schema", path)
+	}
+	return rows[1:], nil
+}
+
+// writeNDJSON appends one JSON object per record to path. In replace mode the file is rewritten.
+func writeNDJSON[T any](path string, t table[T], records []T) (int, error) {
+	existing, err := readNDJSON(path, t.columns[0].name)
+	if err != nil {
+		return 0, err
+	}
+	keys := make([]string, len(existing))
+	for i, line := range existing {
+		keys[i] = line.key
+	}
+	keep, added := t.merge(keys, records)
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if t.replace {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	if t.replace {
+		for _, line := range existing {
+			if keep[line.key] {
+				fmt.Fprintf(f, "%s\n", line.raw)
+			}
+		}
+	}
+	for _, r := range added {
+		obj := make(map[string]any, len(t.columns))
+		for i, v := range t.row(r) {
+			obj[t.columns[i].name] = v
+		}
+		data, err := json.Marshal(obj)
+		if err != nil {
+			f.Close()
+			return 0, err
+		}
+		fmt.Fprintf(f, "%s\n", data)
+	}
+	if err := f.Close(); err != nil {
+		return 0, err
+	}
+	return len(added), nil
+}
+
+type ndjsonLine struct {
+	key string
+	raw []byte
+}
+
+// readNDJSON returns the lines of an existing export with their primary keys.
+func readNDJSON(path, keyField string) ([]ndjsonLine, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var lines []ndjsonLine
+	for i, raw := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		var obj map[string]any
+		if err := json.Unmarshal([]byte(raw), &obj); err != nil {
+			return nil, fmt.Errorf("failed to read existing export %s line %d: %w", path, i+1, err)
+		}
+		lines = append(lines, ndjsonLine{key: fmt.Sprint(obj[keyField]), raw: []byte(raw)})
+	}
+	return lines, nil
+}
+
+// writeSQLite inserts records into a table in the SQLite database at path, creating it if needed.
+func writeSQLite[T any](path string, t table[T], records []T) (int, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	defs := make([]string, len(t.columns))
+	names := make([]string, len(t.columns))
+	placeholders := make([]string, len(t.columns))
+	for i, c := range t.columns {
+		defs[i] = fmt.Sprintf("%s %s NOT NULL", c.name, c.typ)
+		names[i] = c.name
+		placeholders[i] = "?"
+	}
+	defs[0] += " PRIMARY KEY"
+	if _, err := db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", t.name, strings.Join(defs, ", "))); err != nil {
+		return 0, fmt.Errorf("failed to create table %s: %w", t.name, err)
+	}
+
+	verb := "INSERT OR IGNORE"
+	if t.replace {
+		verb = "INSERT OR REPLACE"
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	stmt, err := tx.Prepare(fmt.Sprintf("%s INTO %s (%s) VALUES (%s)", verb, t.name, strings.Join(names, ", "), strings.Join(placeholders, ", ")))
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	added := 0
+	for _, r := range records {
+		result, err := stmt.Exec(t.row(r)...)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			added++
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return added, nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func unitsJSON(units []DarkThroneApi.Unit) string {
+	if units == nil {
+		units = []DarkThroneApi.Unit{}
+	}
+	data, _ := json.Marshal(units)
+	return string(data)
+}
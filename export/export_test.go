@@ -0,0 +1,211 @@
+package export
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	DarkThroneApi "github.com/Rihoj/DarkThroneApi"
+	"github.com/Rihoj/DarkThroneApi/audit"
+)
+
+func wars(ids ...string) []DarkThroneApi.WarHistory {
+	var records []DarkThroneApi.WarHistory
+	for _, id := range ids {
+		records = append(records, DarkThroneApi.WarHistory{
+			ID:         id,
+			AttackerID: "a",
+			DefenderID: "d",
+			GoldStolen: 10,
+			CreatedAt:  time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+		})
+	}
+	return records
+}
+
+func TestWarHistory_IncrementalForEveryFormat(t *testing.T) {
+	for _, format := range []Format{FormatCSV, FormatNDJSON, FormatSQLite} {
+		t.Run(string(format), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "wars."+string(format))
+			if n, err := WarHistory(path, format, wars("1", "2")); err != nil || n != 2 {
+				t.Fatalf("first export: %d %v", n, err)
+			}
+			if n, err := WarHistory(path, format, wars("1", "2", "3")); err != nil || n != 1 {
+				t.Fatalf("second export: %d %v", n, err)
+			}
+			if got := countRows(t, path, format, "war_history"); got != 3 {
+				t.Errorf("expected 3 rows, got %d", got)
+			}
+		})
+	}
+}
+
+func TestBankLedger_FromAuditIsIncremental(t *testing.T) {
+	at := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	entries := []audit.Entry{
+		{Action: "bank.deposit", Phase: audit.PhaseIntent, Key: "k1", PlayerID: "p1", Payload: []byte(`{"playerId":"p1","amount":100}`)},
+		{Action: "bank.deposit", Phase: audit.PhaseOutcome, Key: "k1", PlayerID: "p1", Outcome: "done", Response: []byte(`{"success":true,"balance":100}`), Time: at},
+		{Action: "bank.withdraw", Phase: audit.PhaseIntent, Key: "k2", PlayerID: "p1", Payload: []byte(`{"playerId":"p1","amount":40}`)},
+		{Action: "bank.withdraw", Phase: audit.PhaseOutcome, Key: "k2", PlayerID: "p1", Outcome: "failed"},
+		{Action: "training.train", Phase: audit.PhaseOutcome, Key: "k3", Outcome: "done"},
+	}
+	transactions, err := BankTransactionsFromAudit(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := BankTransaction{ID: "k1", PlayerID: "p1", Type: "deposit", Amount: 100, Balance: 100, At: at}
+	if len(transactions) != 1 || transactions[0] != want {
+		t.Fatalf("transactions = %+v, want [%+v]", transactions, want)
+	}
+	for _, format := range []Format{FormatCSV, FormatNDJSON, FormatSQLite} {
+		t.Run(string(format), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "bank."+string(format))
+			if n, err := BankLedger(path, format, transactions); err != nil || n != 1 {
+				t.Fatalf("first export: %d %v", n, err)
+			}
+			if n, err := BankLedger(path, format, transactions); err != nil || n != 0 {
+				t.Fatalf("second export: %d %v", n, err)
+			}
+			if got := countRows(t, path, format, "bank_ledger"); got != 1 {
+				t.Errorf("expected 1 row, got %d", got)
+			}
+		})
+	}
+}
+
+func TestBankTransactionsFromAudit_ReportsUndecodableEntries(t *testing.T) {
+	entries := []audit.Entry{
+		{Seq: 1, Action: "bank.deposit", Phase: audit.PhaseIntent, Key: "k1", Payload: []byte(`{"amount":"lots"}`)},
+	}
+	if _, err := BankTransactionsFromAudit(entries); err == nil {
+		t.Error("expected an error for an undecodable payload")
+	}
+	entries = []audit.Entry{
+		{Seq: 1, Action: "bank.deposit", Phase: audit.PhaseOutcome, Key: "k1", Outcome: "done"},
+	}
+	if _, err := BankTransactionsFromAudit(entries); err == nil {
+		t.Error("expected an error for a done outcome without a response")
+	}
+}
+
+func TestWarHistory_SkipsRecordsWithoutID(t *testing.T) {
+	records := []DarkThroneApi.WarHistory{{ID: "w1"}, {GoldStolen: 5}, {GoldStolen: 7}}
+	for _, format := range []Format{FormatCSV, FormatNDJSON, FormatSQLite} {
+		t.Run(string(format), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "wars."+string(format))
+			if n, err := WarHistory(path, format, records); err != nil || n != 1 {
+				t.Fatalf("export: %d %v, want only the record with an ID", n, err)
+			}
+			if got := countRows(t, path, format, "war_history"); got != 1 {
+				t.Errorf("expected 1 row, got %d", got)
+			}
+		})
+	}
+}
+
+func TestPlayers_RejectsInvalidTableNames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "players.db")
+	for _, name := range []string{"", "Players", "1players", "players; DROP TABLE war_history", "own-players"} {
+		if _, err := Players(path, FormatSQLite, name, []DarkThroneApi.Player{{ID: "p1"}}); err == nil {
+			t.Errorf("%q: expected an error", name)
+		}
+	}
+}
+
+func TestPlayers_ReplacesExistingRows(t *testing.T) {
+	for _, format := range []Format{FormatCSV, FormatNDJSON, FormatSQLite} {
+		t.Run(string(format), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "players."+string(format))
+			first := []DarkThroneApi.Player{{ID: "p1", Gold: 1}, {ID: "p2", Gold: 2}}
+			if _, err := Players(path, format, "players", first); err != nil {
+				t.Fatalf("first export: %v", err)
+			}
+			if n, err := Players(path, format, "players", []DarkThroneApi.Player{{ID: "p1", Gold: 5}}); err != nil || n != 1 {
+				t.Fatalf("second export: %d %v", n, err)
+			}
+			if got := countRows(t, path, format, "players"); got != 2 {
+				t.Errorf("expected 2 rows, got %d", got)
+			}
+		})
+	}
+}
+
+func TestCSV_StableHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wars.csv")
+	if _, err := WarHistory(path, FormatCSV, wars("1")); err != nil {
+		t.Fatal(err)
+	}
+	f, _ := os.Open(path)
+	defer f.Close()
+	rows, _ := csv.NewReader(f).ReadAll()
+	want := "id,created_at,attacker_id,defender_id,is_attacker_victor,attack_turns_used,gold_stolen,attacker_strength,defender_strength,attacker_casualties,defender_casualties,xp_earned"
+	if got := joinFields(rows[0]); got != want {
+		t.Errorf("unexpected header:\n got %s\nwant %s", got, want)
+	}
+	if rows[1][1] != "2025-06-01T00:00:00Z" {
+		t.Errorf("unexpected created_at: %s", rows[1][1])
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat("SQLite"); err != nil || f != FormatSQLite {
+		t.Errorf("unexpected result: %v %v", f, err)
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func countRows(t *testing.T, path string, format Format, table string) int {
+	t.Helper()
+	switch format {
+	case FormatCSV:
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		rows, err := csv.NewReader(f).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(rows) - 1
+	case FormatNDJSON:
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		n := 0
+		for s := bufio.NewScanner(f); s.Scan(); {
+			n++
+		}
+		return n
+	default:
+		db, err := sql.Open("sqlite", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		var n int
+		if err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+}
+
+func joinFields(fields []string) string {
+	out := ""
+	for i, f := range fields {
+		if i > 0 {
+			out += ","
+		}
+		out += f
+	}
+	return out
+}
@@ -0,0 +1,306 @@
+package DarkThroneApi
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HealthState is the availability of the API as judged by a HealthMonitor.
+type HealthState int
+
+const (
+	HealthUp HealthState = iota
+	HealthDegraded
+	HealthDown
+)
+
+// String returns "up", "degraded" or "down".
+func (s HealthState) String() string {
+	switch s {
+	case HealthUp:
+		return "up"
+	case HealthDegraded:
+		return "degraded"
+	case HealthDown:
+		return "down"
+	}
+	return "unknown"
+}
+
+// HealthStateChanged is published on the client's event bus when a HealthMonitor changes state.
+type HealthStateChanged struct {
+	From HealthState
+	To   HealthState
+	At   time.Time
+}
+
+func (HealthStateChanged) EventName() string { return "health.state-changed" }
+
+// HealthConfig configures a HealthMonitor. Zero values select the defaults.
+type HealthConfig struct {
+	// Interval between pings. Defaults to 30 seconds.
+	Interval time.Duration
+	// Timeout bounds each ping. Defaults to 10 seconds.
+	Timeout time.Duration
+	// Window is the number of recent pings kept for the latency histogram and availability. Defaults to 100.
+	Window int
+	// DegradedLatency is the latency above which a successful ping counts as slow. Defaults to 1 second.
+	DegradedLatency time.Duration
+	// DownAfter is the number of consecutive failed pings that mark the API down, and of consecutive
+	// slow or failed pings that mark it degraded. Defaults to 3.
+	DownAfter int
+	// UpAfter is the number of consecutive successful pings needed to leave the down state, and of
+	// consecutive fast pings needed to return to up. Defaults to 3.
+	UpAfter int
+	// PauseRequests makes the client's API calls wait while the API is down.
+	PauseRequests bool
+}
+
+// healthSample is the outcome of one ping.
+type healthSample struct {
+	latency time.Duration
+	ok      bool
+}
+
+// HealthStats summarises the pings in a HealthMonitor's window.
+type HealthStats struct {
+	State HealthState
+	// Samples is the number of pings in the window.
+	Samples int
+	// Availability is the fraction of pings in the window that succeeded, from 0 to 1.
+	Availability float64
+	// P50 and P95 are latency percentiles of the successful pings in the window.
+	P50 time.Duration
+	P95 time.Duration
+}
+
+// HistogramBucket counts the successful pings with latency at or below UpperBound.
+type HistogramBucket struct {
+	UpperBound time.Duration
+	Count      int
+}
+
+// HealthMonitor pings the API on an interval and tracks its availability.
+// States change with hysteresis so that a single slow or failed ping does not flap the state.
+type HealthMonitor struct {
+	api    *DarkThroneApi
+	config HealthConfig
+
+	mu       sync.Mutex
+	state    HealthState
+	samples  []healthSample // ring buffer of the last config.Window samples
+	next     int
+	failures int // consecutive failed pings
+	bad      int // consecutive slow or failed pings
+	recovers int // consecutive successful pings
+	fast     int // consecutive fast pings
+	resumed  chan struct{}
+	stopped  bool // set by Stop; requests are never paused again
+}
+
+// NewHealthMonitor creates a monitor for api, starting in the up state.
+// With PauseRequests set, api's calls wait while the monitor reports the API as down.
+func NewHealthMonitor(api *DarkThroneApi, config HealthConfig) *HealthMonitor {
+	if config.Interval <= 0 {
+		config.Interval = 30 * time.Second
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.Window <= 0 {
+		config.Window = 100
+	}
+	if config.DegradedLatency <= 0 {
+		config.DegradedLatency = time.Second
+	}
+	if config.DownAfter <= 0 {
+		config.DownAfter = 3
+	}
+	if config.UpAfter <= 0 {
+		config.UpAfter = 3
+	}
+	resumed := make(chan struct{})
+	close(resumed)
+	m := &HealthMonitor{api: api, config: config, resumed: resumed}
+	if config.PauseRequests {
+		api.health.Store(m)
+	}
+	return m
+}
+
+// Run pings on the configured interval until ctx is cancelled.
+// When it returns, paused requests are let through, since nothing would otherwise resume them.
+func (m *HealthMonitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.config.Interval)
+	defer ticker.Stop()
+	defer m.resume()
+	for {
+		m.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop detaches the monitor from its client, so that API calls no longer wait for it, and lets
+// paused calls continue. The monitor keeps recording checks but never pauses requests again.
+func (m *HealthMonitor) Stop() {
+	m.api.health.CompareAndSwap(m, nil)
+	m.mu.Lock()
+	m.stopped = true
+	m.mu.Unlock()
+	m.resume()
+}
+
+// resume lets paused requests through until the next check finds the API down.
+func (m *HealthMonitor) resume() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.updatePause(HealthUp)
+}
+
+// Check pings the API once, records the result and returns the resulting state.
+func (m *HealthMonitor) Check(ctx context.Context) HealthState {
+	ctx, cancel := context.WithTimeout(ctx, m.config.Timeout)
+	defer cancel()
+	latency, err := m.api.PingContext(ctx)
+	return m.record(healthSample{latency: latency, ok: err == nil})
+}
+
+// record adds a sample, applies the state transitions and publishes a state change if there was one.
+func (m *HealthMonitor) record(sample healthSample) HealthState {
+	m.mu.Lock()
+	if len(m.samples) < m.config.Window {
+		m.samples = append(m.samples, sample)
+	} else {
+		m.samples[m.next] = sample
+	}
+	m.next = (m.next + 1) % m.config.Window
+
+	fast := sample.ok && sample.latency <= m.config.DegradedLatency
+	m.failures = countIf(!sample.ok, m.failures)
+	m.bad = countIf(!fast, m.bad)
+	m.recovers = countIf(sample.ok, m.recovers)
+	m.fast = countIf(fast, m.fast)
+
+	from := m.state
+	switch {
+	case m.state != HealthDown && m.failures >= m.config.DownAfter:
+		m.state = HealthDown
+	case m.state == HealthUp && m.bad >= m.config.DownAfter:
+		m.state = HealthDegraded
+	case m.state != HealthUp && m.fast >= m.config.UpAfter:
+		m.state = HealthUp
+	case m.state == HealthDown && m.recovers >= m.config.UpAfter:
+		m.state = HealthDegraded
+	}
+	to := m.state
+	if from != to {
+		m.updatePause(to)
+	}
+	m.mu.Unlock()
+
+	if from != to {
+		if m.api.config != nil && m.api.config.Logger != nil {
+			m.api.config.Logger.Warn("API health changed", "from", from.String(), "to", to.String())
+		}
+		m.api.publish(HealthStateChanged{From: from, To: to, At: time.Now()})
+	}
+	return to
+}
+
+// updatePause closes or reopens the resumed channel as the API goes up or down. m.mu must be held.
+func (m *HealthMonitor) updatePause(state HealthState) {
+	select {
+	case <-m.resumed:
+		if state == HealthDown && !m.stopped {
+			m.resumed = make(chan struct{})
+		}
+	default:
+		if state != HealthDown {
+			close(m.resumed)
+		}
+	}
+}
+
+// countIf returns n+1 when cond holds and 0 otherwise.
+func countIf(cond bool, n int) int {
+	if cond {
+		return n + 1
+	}
+	return 0
+}
+
+// State returns the current state.
+func (m *HealthMonitor) State() HealthState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state
+}
+
+// Stats summarises the pings in the window.
+func (m *HealthMonitor) Stats() HealthStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := HealthStats{State: m.state, Samples: len(m.samples)}
+	var latencies []time.Duration
+	for _, s := range m.samples {
+		if s.ok {
+			latencies = append(latencies, s.latency)
+		}
+	}
+	if len(m.samples) > 0 {
+		stats.Availability = float64(len(latencies)) / float64(len(m.samples))
+	}
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		stats.P50 = percentile(latencies, 0.50)
+		stats.P95 = percentile(latencies, 0.95)
+	}
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of sorted.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// Histogram returns the latency histogram of the successful pings in the window.
+// Buckets are cumulative, like Prometheus histograms.
+func (m *HealthMonitor) Histogram() []HistogramBucket {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	buckets := make([]HistogramBucket, len(defaultLatencyBuckets))
+	for i, bound := range defaultLatencyBuckets {
+		buckets[i].UpperBound = time.Duration(bound * float64(time.Second))
+		for _, s := range m.samples {
+			if s.ok && s.latency <= buckets[i].UpperBound {
+				buckets[i].Count++
+			}
+		}
+	}
+	return buckets
+}
+
+// WaitUntilAvailable blocks while the API is down and returns when it is not, or when ctx is done.
+// It returns immediately on a nil monitor.
+func (m *HealthMonitor) WaitUntilAvailable(ctx context.Context) error {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	resumed := m.resumed
+	m.mu.Unlock()
+	select {
+	case <-resumed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,211 @@
+package DarkThroneApi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHealthMonitor_Hysteresis(t *testing.T) {
+	api := newTestApi(t, "http://unused")
+	m := NewHealthMonitor(api, HealthConfig{DegradedLatency: 100 * time.Millisecond, DownAfter: 2, UpAfter: 2})
+	var changes []HealthStateChanged
+	SubscribeTo(api.Events(), func(e HealthStateChanged) { changes = append(changes, e) })
+
+	fast := healthSample{latency: 10 * time.Millisecond, ok: true}
+	slow := healthSample{latency: 500 * time.Millisecond, ok: true}
+	fail := healthSample{}
+	steps := []struct {
+		sample healthSample
+		want   HealthState
+	}{
+		{fail, HealthUp},       // one failure is not enough
+		{fast, HealthUp},       // and is forgotten after a success
+		{slow, HealthUp},       // one slow ping is not enough
+		{slow, HealthDegraded}, // two are
+		{fail, HealthDegraded},
+		{fail, HealthDown},
+		{slow, HealthDown},
+		{slow, HealthDegraded}, // two successes leave down, but slow ones only reach degraded
+		{fast, HealthDegraded},
+		{fast, HealthUp},
+	}
+	for i, step := range steps {
+		if got := m.record(step.sample); got != step.want {
+			t.Fatalf("step %d: state = %s, want %s", i, got, step.want)
+		}
+	}
+	want := []HealthState{HealthDegraded, HealthDown, HealthDegraded, HealthUp}
+	if len(changes) != len(want) {
+		t.Fatalf("got %d state changes: %+v", len(changes), changes)
+	}
+	for i, c := range changes {
+		if c.To != want[i] {
+			t.Errorf("change %d to %s, want %s", i, c.To, want[i])
+		}
+	}
+}
+
+func TestHealthMonitor_StatsAndHistogram(t *testing.T) {
+	api := newTestApi(t, "http://unused")
+	m := NewHealthMonitor(api, HealthConfig{Window: 4})
+	for _, s := range []healthSample{
+		{latency: 500 * time.Millisecond, ok: true}, // pushed out of the window
+		{latency: 20 * time.Millisecond, ok: true},
+		{ok: false},
+		{latency: 40 * time.Millisecond, ok: true},
+		{latency: 200 * time.Millisecond, ok: true},
+	} {
+		m.record(s)
+	}
+	stats := m.Stats()
+	if stats.Samples != 4 || stats.Availability != 0.75 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if stats.P50 != 40*time.Millisecond || stats.P95 != 200*time.Millisecond {
+		t.Errorf("percentiles = %s, %s", stats.P50, stats.P95)
+	}
+	for _, b := range m.Histogram() {
+		if b.UpperBound == 50*time.Millisecond && b.Count != 2 {
+			t.Errorf("bucket <= 50ms has %d pings, want 2", b.Count)
+		}
+		if b.UpperBound == 10*time.Second && b.Count != 3 {
+			t.Errorf("bucket <= 10s has %d pings, want 3", b.Count)
+		}
+	}
+}
+
+func TestHealthMonitor_PausesRequestsWhileDown(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"id":"p1"}`))
+	}))
+	defer server.Close()
+	api := newTestApi(t, server.URL)
+	api.token = "token"
+	m := NewHealthMonitor(api, HealthConfig{DownAfter: 1, UpAfter: 1, PauseRequests: true})
+	if state := m.Check(context.Background()); state != HealthDown {
+		t.Fatalf("state = %s, want down", state)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := callContext[struct{}, Player](ctx, api, epPlayersGet, pathParams{"id": "p1"}, nil, struct{}{}); err != context.DeadlineExceeded {
+		t.Fatalf("expected the call to wait until its deadline, got %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := api.FetchPlayerByID("p1")
+		done <- err
+	}()
+	down.Store(false)
+	if state := m.Check(context.Background()); state != HealthUp {
+		t.Fatalf("state = %s, want up", state)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("call did not resume after the API came back up")
+	}
+}
+
+func TestHealthMonitor_StopAndRunExitResumeRequests(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"id":"p1"}`))
+	}))
+	defer server.Close()
+	api := newTestApi(t, server.URL)
+	api.token = "token"
+	fetch := func() error {
+		done := make(chan error, 1)
+		go func() {
+			_, err := api.FetchPlayerByID("p1")
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(time.Second):
+			return context.DeadlineExceeded
+		}
+	}
+
+	// Run leaves the gate open when its context ends, even though the API was down.
+	m := NewHealthMonitor(api, HealthConfig{DownAfter: 1, UpAfter: 1, Interval: time.Hour, PauseRequests: true})
+	ctx, cancel := context.WithCancel(context.Background())
+	ran := make(chan struct{})
+	go func() {
+		m.Run(ctx)
+		close(ran)
+	}()
+	for m.State() != HealthDown {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-ran
+	down.Store(false)
+	if err := fetch(); err != nil {
+		t.Fatalf("after Run returned: %v", err)
+	}
+
+	// Stop detaches a monitor that is down, and later checks do not pause requests again.
+	m = NewHealthMonitor(api, HealthConfig{DownAfter: 1, UpAfter: 1, PauseRequests: true})
+	down.Store(true)
+	m.Check(context.Background())
+	m.Stop()
+	if api.health.Load() != nil {
+		t.Error("Stop did not detach the monitor")
+	}
+	m.Check(context.Background())
+	down.Store(false)
+	if err := fetch(); err != nil {
+		t.Fatalf("after Stop: %v", err)
+	}
+}
+
+func TestNewHealthMonitor_WhileRequestsAreInFlight(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"p1"}`))
+	}))
+	defer server.Close()
+	api := newTestApi(t, server.URL)
+	api.token = "token"
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if _, err := api.FetchPlayerByID("p1"); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	m := NewHealthMonitor(api, HealthConfig{PauseRequests: true})
+	wg.Wait()
+	if api.health.Load() != m {
+		t.Error("the monitor was not installed")
+	}
+}
@@ -0,0 +1,359 @@
+package DarkThroneApi
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// idempotencyKeyHeader carries the key that lets a server recognise a repeated mutating request.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+var (
+	// ErrAmbiguousOutcome is returned when a mutating request may or may not have been applied,
+	// for example after a timeout. The journal entry stays ambiguous until a retry or
+	// MutationJournal.Resolve settles it.
+	ErrAmbiguousOutcome = errors.New("mutation outcome is unknown")
+	// ErrAlreadyApplied is returned, without sending anything, when a retried mutation is found to
+	// have been applied by the earlier ambiguous attempt.
+	ErrAlreadyApplied = errors.New("mutation was already applied")
+)
+
+// mutationAttempt travels in the context of one attempt of a mutating request.
+type mutationAttempt struct {
+	key string
+	// sent is set once the request has been handed to the HTTP client.
+	sent bool
+	// responded is set once a response arrived, whether or not it could be read.
+	responded bool
+	// echoed is set when the response repeats the idempotency key.
+	echoed bool
+}
+
+type mutationAttemptKey struct{}
+
+func withMutationAttempt(ctx context.Context, a *mutationAttempt) context.Context {
+	return context.WithValue(ctx, mutationAttemptKey{}, a)
+}
+
+func mutationAttemptFrom(ctx context.Context) *mutationAttempt {
+	a, _ := ctx.Value(mutationAttemptKey{}).(*mutationAttempt)
+	return a
+}
+
+// newIdempotencyKey returns a random 128-bit key.
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// supportsIdempotencyKeys reports whether the server is configured or known to honour idempotency keys.
+func (d *DarkThroneApi) supportsIdempotencyKeys() bool {
+	if d.config != nil && d.config.Capabilities != nil && d.config.Capabilities.IdempotencyKeys {
+		return true
+	}
+	d.capabilities.mu.Lock()
+	defer d.capabilities.mu.Unlock()
+	return d.capabilities.idempotencyKeys
+}
+
+// Journal returns the journal of mutating requests sent by the client.
+func (d *DarkThroneApi) Journal() *MutationJournal {
+	d.journalOnce.Do(func() {
+		if d.journal == nil {
+			d.journal = NewMutationJournal()
+		}
+	})
+	return d.journal
+}
+
+// isAmbiguous reports whether err leaves it unknown if the attempt was applied: the request was sent
+// but no response came back, or the server failed in a way that does not say whether it processed it.
+func isAmbiguous(a *mutationAttempt, err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	return a.sent && !a.responded
+}
+
+// isUnreadable reports whether err came from a successful response whose body could not be read or
+// decoded, which means the server applied the mutation.
+func isUnreadable(a *mutationAttempt, err error) bool {
+	var statusErr *StatusError
+	return a.responded && !errors.As(err, &statusErr)
+}
+
+// reconciler re-reads the game state to decide whether the mutation in e was applied.
+type reconciler func(ctx context.Context, d *DarkThroneApi, e JournalEntry) (applied bool, err error)
+
+// reconcilers lists the mutations whose effect can be checked by re-reading state.
+var reconcilers = map[endpointName]reconciler{
+	epTrainingTrain: func(ctx context.Context, d *DarkThroneApi, e JournalEntry) (bool, error) {
+		return compareUnits(ctx, d, e, e.Request.(TrainUnitsRequest).Units, 1)
+	},
+	epTrainingUntrain: func(ctx context.Context, d *DarkThroneApi, e JournalEntry) (bool, error) {
+		return compareUnits(ctx, d, e, e.Request.(UntrainUnitsRequest).Units, -1)
+	},
+	epBankDeposit: func(ctx context.Context, d *DarkThroneApi, e JournalEntry) (bool, error) {
+		return compareBankBalance(d, e, e.Request.(BankDepositRequest).Amount)
+	},
+	epBankWithdraw: func(ctx context.Context, d *DarkThroneApi, e JournalEntry) (bool, error) {
+		return compareBankBalance(d, e, -e.Request.(BankWithdrawRequest).Amount)
+	},
+	epAttack: findAttack,
+	epPlayersCreate: func(ctx context.Context, d *DarkThroneApi, e JournalEntry) (bool, error) {
+		players, err := d.currentUserPlayersAPI(ctx)
+		if err != nil {
+			return false, err
+		}
+		for _, p := range players {
+			if p.Name == e.Request.(CreatePlayerRequest).Name {
+				return true, nil
+			}
+		}
+		return false, nil
+	},
+}
+
+// errUndecidable is returned by a reconciler when the state it can read does not show whether the
+// mutation was applied.
+var errUndecidable = errors.New("state does not show whether it was applied")
+
+// needsBefore reports whether the reconciler of name compares against the player's earlier state.
+func needsBefore(name endpointName) bool {
+	return name == epTrainingTrain || name == epTrainingUntrain
+}
+
+// compareBankBalance reports the mutation applied if the bank balance reported since it was sent
+// moved by delta. Gold on hand also moves with income and attacks, so it proves nothing, and the API
+// cannot re-read the balance, so a balance that has not moved does not prove the request was lost.
+func compareBankBalance(d *DarkThroneApi, e JournalEntry, delta int) (bool, error) {
+	after, ok := d.knownBankBalance(e.PlayerID)
+	if e.BankBefore == nil || !ok || after != *e.BankBefore+delta {
+		return false, errUndecidable
+	}
+	return true, nil
+}
+
+// attackClockSkew widens the war history search for an attack, since war times come from the server's clock.
+const attackClockSkew = time.Minute
+
+// attackWars fetches the wars between the attacker and the target of the attack in e since from.
+func (d *DarkThroneApi) attackWars(ctx context.Context, e *JournalEntry, from time.Time) ([]WarHistory, error) {
+	return d.allWarHistory(ctx, WarHistoryFilter{
+		PlayerID:   e.PlayerID,
+		OpponentID: e.Request.(attackRequest).TargetID,
+		From:       from.Add(-attackClockSkew),
+	})
+}
+
+// findAttack reports whether war history records the attack in e since it was first sent.
+// Wars listed in e.WarsBefore fall within the clock skew but predate the attack, so they are skipped.
+func findAttack(ctx context.Context, d *DarkThroneApi, e JournalEntry) (bool, error) {
+	req := e.Request.(attackRequest)
+	if e.PlayerID == "" || e.WarsBefore == nil {
+		return false, errUndecidable
+	}
+	wars, err := d.attackWars(ctx, &e, e.Started)
+	if err != nil {
+		return false, err
+	}
+	before := make(map[string]bool, len(e.WarsBefore))
+	for _, id := range e.WarsBefore {
+		before[id] = true
+	}
+	for _, w := range wars {
+		if w.AttackerID != e.PlayerID || w.DefenderID != req.TargetID || w.AttackTurnsUsed != req.AttackTurns {
+			continue
+		}
+		if w.ID == "" {
+			// Without an ID it cannot be told apart from an earlier war.
+			return false, errUndecidable
+		}
+		if !before[w.ID] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// recordBefore stores in e the state its reconciler compares against, before the first attempt is sent.
+func (d *DarkThroneApi) recordBefore(ctx context.Context, name endpointName, e *JournalEntry) {
+	if before, ok := d.knownPlayer(e.PlayerID); ok {
+		e.Before = &before
+	}
+	if balance, ok := d.knownBankBalance(e.PlayerID); ok {
+		e.BankBefore = &balance
+	}
+	if name != epAttack || e.PlayerID == "" {
+		return
+	}
+	wars, err := d.attackWars(ctx, e, time.Now())
+	if err != nil {
+		d.config.Logger.Warn("Could not read war history before attacking; an ambiguous attack will need resolving by hand", "error", err)
+		return
+	}
+	e.WarsBefore = make([]string, 0, len(wars))
+	for _, w := range wars {
+		e.WarsBefore = append(e.WarsBefore, w.ID)
+	}
+}
+
+// compareUnits reports whether every unit count in order moved by sign times its quantity.
+func compareUnits(ctx context.Context, d *DarkThroneApi, e JournalEntry, order []UnitRequest, sign int) (bool, error) {
+	after, err := d.rereadPlayer(ctx, e.PlayerID)
+	if err != nil {
+		return false, err
+	}
+	count := func(units []Unit, t UnitType) int {
+		n := 0
+		for _, u := range units {
+			if u.UnitType == t {
+				n += u.Quantity
+			}
+		}
+		return n
+	}
+	for _, o := range order {
+		if sign*(count(after.Units, o.UnitType)-count(e.Before.Units, o.UnitType)) < o.Quantity {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// rereadPlayer fetches the player past the response cache, which may predate the ambiguous request.
+func (d *DarkThroneApi) rereadPlayer(ctx context.Context, id string) (Player, error) {
+	if err := d.forgetCached(epPlayersGet, pathParams{"id": id}); err != nil {
+		return Player{}, err
+	}
+	player, err := d.playerAPI(ctx, id)
+	if err != nil {
+		return Player{}, err
+	}
+	d.rememberPlayers(player)
+	return player, nil
+}
+
+// mutate sends a mutating request through the journal and the audit log.
+//
+// Every attempt carries an idempotency key. If an identical request was left ambiguous, it is sent
+// again with the same key when the server honours keys. Otherwise the state is re-read first: if the
+// earlier attempt was applied, ErrAlreadyApplied is returned and nothing is sent; if that cannot be
+// decided, ErrAmbiguousOutcome is returned until the entry is resolved with MutationJournal.Resolve.
+// Only one retry at a time takes up an ambiguous entry; concurrent ones return ErrAmbiguousOutcome.
+//
+// With an audit log configured, the intent is recorded before the request is sent, and the request
+// is not sent if that fails; the outcome is recorded afterwards.
+func mutate[Req any, Resp any](ctx context.Context, d *DarkThroneApi, name endpointName, playerID string, req Req, send func(context.Context, Req) (Resp, error)) (Resp, error) {
+	var zero Resp
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return zero, err
+	}
+	if d.dryRun() {
+		resp, err := send(ctx, req)
+		outcome := "simulated"
+		if err != nil {
+			outcome = string(MutationFailed)
+		}
+		d.auditOutcome(name, playerID, "", payload, responseJSON(resp, err), outcome, err)
+		return resp, err
+	}
+	journal := d.Journal()
+	fingerprint := string(name) + " " + string(payload)
+
+	entry, claimed, err := journal.claimAmbiguous(fingerprint)
+	if err != nil {
+		return zero, err
+	}
+	if entry != nil && !d.supportsIdempotencyKeys() {
+		applied, err := d.reconcile(ctx, name, claimed)
+		if err != nil {
+			journal.unclaim(entry)
+			return zero, err
+		}
+		if applied {
+			journal.update(entry, MutationApplied, nil)
+			d.auditOutcome(name, playerID, entry.Key, payload, nil, string(MutationApplied), nil)
+			d.config.Logger.Warn("Ambiguous mutation was applied; not sending it again", "endpoint", name, "key", entry.Key)
+			return zero, fmt.Errorf("%w: %s %s", ErrAlreadyApplied, name, entry.Key)
+		}
+		journal.update(entry, MutationFailed, nil)
+		d.auditOutcome(name, playerID, entry.Key, payload, nil, "not-applied", nil)
+		entry = nil
+	}
+	if entry == nil {
+		entry = &JournalEntry{Key: newIdempotencyKey(), Endpoint: string(name), PlayerID: playerID, Request: req, fingerprint: fingerprint}
+		d.recordBefore(ctx, name, entry)
+		journal.begin(entry)
+	}
+	journal.attempt(entry)
+	if err := d.auditIntent(name, playerID, entry.Key, payload); err != nil {
+		journal.update(entry, MutationFailed, err)
+		return zero, err
+	}
+
+	attempt := &mutationAttempt{key: entry.Key}
+	resp, err := send(withMutationAttempt(ctx, attempt), req)
+	if attempt.echoed {
+		d.capabilities.mu.Lock()
+		d.capabilities.idempotencyKeys = true
+		d.capabilities.mu.Unlock()
+	}
+	status := MutationFailed
+	switch {
+	case err == nil:
+		status = MutationDone
+	case isUnreadable(attempt, err):
+		status = MutationApplied
+		d.config.Logger.Warn("Mutation was applied but its response could not be read", "endpoint", name, "key", entry.Key, "error", err)
+	case isAmbiguous(attempt, err):
+		status = MutationAmbiguous
+		d.config.Logger.Warn("Mutation outcome is unknown", "endpoint", name, "key", entry.Key, "error", err)
+		err = fmt.Errorf("%w: %w", ErrAmbiguousOutcome, err)
+	}
+	journal.update(entry, status, err)
+	d.auditOutcome(name, playerID, entry.Key, payload, responseJSON(resp, err), string(status), err)
+	if err != nil {
+		return zero, err
+	}
+	return resp, nil
+}
+
+// responseJSON returns the JSON encoding of resp for the audit log, or nil if the request failed.
+func responseJSON(resp any, err error) []byte {
+	if err != nil {
+		return nil
+	}
+	data, _ := json.Marshal(resp)
+	return data
+}
+
+// reconcile decides whether the ambiguous mutation in e was applied by re-reading state.
+func (d *DarkThroneApi) reconcile(ctx context.Context, name endpointName, e JournalEntry) (bool, error) {
+	check, ok := reconcilers[name]
+	if !ok || (needsBefore(name) && e.Before == nil) {
+		return false, fmt.Errorf("%w: %s %s cannot be checked; resolve it in the journal", ErrAmbiguousOutcome, name, e.Key)
+	}
+	applied, err := check(ctx, d, e)
+	if errors.Is(err, errUndecidable) {
+		return false, fmt.Errorf("%w: %s %s: %w; resolve it in the journal", ErrAmbiguousOutcome, name, e.Key, err)
+	}
+	if err != nil {
+		return false, fmt.Errorf("%w: re-reading state: %w", ErrAmbiguousOutcome, err)
+	}
+	d.config.Logger.Info("Reconciled ambiguous mutation", "endpoint", name, "key", e.Key, "applied", applied)
+	return applied, nil
+}
@@ -0,0 +1,277 @@
+package DarkThroneApi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mutationServer answers POST /bank/deposit with the configured status and GET /players/p1 with the player's gold.
+type mutationServer struct {
+	mu       sync.Mutex
+	status   int
+	echo     bool
+	body     string
+	gold     int
+	keys     []string
+	deposits int
+}
+
+func (s *mutationServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.Method + " " + r.URL.Path {
+	case "GET /players/p1":
+		w.Write([]byte(`{"id":"p1","gold":` + strconv.Itoa(s.gold) + `}`))
+	case "POST /bank/deposit":
+		s.keys = append(s.keys, r.Header.Get(idempotencyKeyHeader))
+		s.deposits++
+		if s.echo {
+			w.Header().Set(idempotencyKeyHeader, r.Header.Get(idempotencyKeyHeader))
+		}
+		if s.status != http.StatusOK {
+			w.WriteHeader(s.status)
+			return
+		}
+		if s.body != "" {
+			w.Write([]byte(s.body))
+			return
+		}
+		w.Write([]byte(`{"success":true,"balance":100}`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newMutationApi(t *testing.T, s *mutationServer) *DarkThroneApi {
+	t.Helper()
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	api := newTestApi(t, server.URL)
+	api.token = "t"
+	api.rememberPlayers(Player{ID: "p1", Gold: 500})
+	return api
+}
+
+func TestMutate_SendsIdempotencyKeyAndLearnsSupport(t *testing.T) {
+	s := &mutationServer{status: http.StatusOK, echo: true}
+	api := newMutationApi(t, s)
+	if _, err := api.DepositGold(BankDepositRequest{PlayerID: "p1", Amount: 100}); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.keys) != 1 || len(s.keys[0]) != 32 {
+		t.Errorf("keys = %q, want one 32-character key", s.keys)
+	}
+	if !api.supportsIdempotencyKeys() {
+		t.Error("an echoed key should mark idempotency keys as supported")
+	}
+	entries := api.Journal().Entries()
+	if len(entries) != 1 || entries[0].Status != MutationDone || entries[0].Key != s.keys[0] {
+		t.Errorf("journal = %+v", entries)
+	}
+}
+
+func TestMutate_AmbiguousRetryWithKeysReusesKey(t *testing.T) {
+	s := &mutationServer{status: http.StatusGatewayTimeout, echo: true}
+	api := newMutationApi(t, s)
+	req := BankDepositRequest{PlayerID: "p1", Amount: 100}
+	if _, err := api.DepositGold(req); !errors.Is(err, ErrAmbiguousOutcome) {
+		t.Fatalf("err = %v, want ErrAmbiguousOutcome", err)
+	}
+	s.status = http.StatusOK
+	if _, err := api.DepositGold(req); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.keys) != 2 || s.keys[0] != s.keys[1] {
+		t.Errorf("keys = %q, want the same key twice", s.keys)
+	}
+	if entries := api.Journal().Entries(); len(entries) != 1 || entries[0].Attempts != 2 || entries[0].Status != MutationDone {
+		t.Errorf("journal = %+v", entries)
+	}
+}
+
+func TestMutate_AmbiguousRetryReconcilesWithoutKeys(t *testing.T) {
+	s := &mutationServer{status: http.StatusGatewayTimeout}
+	api := newMutationApi(t, s)
+	api.rememberBankBalance("p1", 0)
+	req := BankDepositRequest{PlayerID: "p1", Amount: 100}
+	if _, err := api.DepositGold(req); !errors.Is(err, ErrAmbiguousOutcome) {
+		t.Fatalf("err = %v, want ErrAmbiguousOutcome", err)
+	}
+	if len(api.Journal().Ambiguous()) != 1 {
+		t.Fatal("the deposit should be journaled as ambiguous")
+	}
+
+	// A later bank response shows the balance moved by the amount, so the retry is not sent.
+	api.rememberBankBalance("p1", 100)
+	if _, err := api.DepositGold(req); !errors.Is(err, ErrAlreadyApplied) {
+		t.Fatalf("err = %v, want ErrAlreadyApplied", err)
+	}
+
+	// A second deposit is left ambiguous. Gold on hand dropping proves nothing, and an unmoved
+	// balance cannot be re-read, so the outcome stays unknown and nothing is sent.
+	if _, err := api.DepositGold(req); !errors.Is(err, ErrAmbiguousOutcome) {
+		t.Fatalf("err = %v, want ErrAmbiguousOutcome", err)
+	}
+	s.gold = 0
+	if _, err := api.DepositGold(req); !errors.Is(err, ErrAmbiguousOutcome) || errors.Is(err, ErrAlreadyApplied) {
+		t.Fatalf("err = %v, want an unresolved ErrAmbiguousOutcome", err)
+	}
+	if s.deposits != 2 {
+		t.Errorf("deposits sent = %d, want 2", s.deposits)
+	}
+	var statuses []MutationStatus
+	for _, e := range api.Journal().Entries() {
+		statuses = append(statuses, e.Status)
+	}
+	want := []MutationStatus{MutationApplied, MutationAmbiguous}
+	if len(statuses) != len(want) || statuses[0] != want[0] || statuses[1] != want[1] {
+		t.Errorf("statuses = %v, want %v", statuses, want)
+	}
+}
+
+func TestMutate_AmbiguousAttackReconcilesFromWarHistory(t *testing.T) {
+	var mu sync.Mutex
+	attacks, wars := 0, `[]`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/attack":
+			attacks++
+			w.WriteHeader(http.StatusGatewayTimeout)
+		case "/war-history":
+			w.Write([]byte(`{"items":` + wars + `}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	api := newTestApi(t, server.URL)
+	api.token = "t"
+	api.rememberAssumed(Player{ID: "p1"})
+
+	if _, err := api.AttackPlayer("p2"); !errors.Is(err, ErrAmbiguousOutcome) {
+		t.Fatalf("err = %v, want ErrAmbiguousOutcome", err)
+	}
+	// No war was recorded, so the attack was lost and is sent again.
+	if _, err := api.AttackPlayer("p2"); !errors.Is(err, ErrAmbiguousOutcome) || attacks != 2 {
+		t.Fatalf("err = %v, attacks = %d", err, attacks)
+	}
+
+	mu.Lock()
+	wars = `[{"id":"w1","attackerID":"p1","defenderID":"p2","attackTurnsUsed":` + strconv.Itoa(min_attack_turns) +
+		`,"createdAt":"` + time.Now().UTC().Format(time.RFC3339) + `"}]`
+	mu.Unlock()
+	if _, err := api.AttackPlayer("p2"); !errors.Is(err, ErrAlreadyApplied) || attacks != 2 {
+		t.Errorf("err = %v, attacks = %d, want ErrAlreadyApplied without a new attack", err, attacks)
+	}
+}
+
+func TestMutate_AmbiguousAttackIgnoresEarlierWars(t *testing.T) {
+	earlier := `[{"id":"w0","attackerID":"p1","defenderID":"p2","attackTurnsUsed":` + strconv.Itoa(min_attack_turns) +
+		`,"createdAt":"` + time.Now().UTC().Add(-10*time.Second).Format(time.RFC3339) + `"}]`
+	attacks := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/attack":
+			attacks++
+			w.WriteHeader(http.StatusGatewayTimeout)
+		case "/war-history":
+			w.Write([]byte(`{"items":` + earlier + `}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	api := newTestApi(t, server.URL)
+	api.token = "t"
+	api.rememberAssumed(Player{ID: "p1"})
+
+	if _, err := api.AttackPlayer("p2"); !errors.Is(err, ErrAmbiguousOutcome) {
+		t.Fatalf("err = %v, want ErrAmbiguousOutcome", err)
+	}
+	if entries := api.Journal().Entries(); len(entries) != 1 || len(entries[0].WarsBefore) != 1 {
+		t.Fatalf("journal = %+v, want the earlier war recorded", entries)
+	}
+	// The only war predates the attack, so the attack was lost and is sent again.
+	if _, err := api.AttackPlayer("p2"); errors.Is(err, ErrAlreadyApplied) || attacks != 2 {
+		t.Errorf("err = %v, attacks = %d, want the attack sent again", err, attacks)
+	}
+}
+
+func TestMutate_DefinitiveFailureIsNotAmbiguous(t *testing.T) {
+	s := &mutationServer{status: http.StatusBadRequest}
+	api := newMutationApi(t, s)
+	_, err := api.DepositGold(BankDepositRequest{PlayerID: "p1", Amount: 100})
+	if err == nil || errors.Is(err, ErrAmbiguousOutcome) {
+		t.Errorf("err = %v, want a plain failure", err)
+	}
+	if entries := api.Journal().Entries(); len(entries) != 1 || entries[0].Status != MutationFailed {
+		t.Errorf("journal = %+v", entries)
+	}
+}
+
+func TestMutate_UnreadableSuccessIsApplied(t *testing.T) {
+	s := &mutationServer{status: http.StatusOK, body: `{"success":`}
+	api := newMutationApi(t, s)
+	_, err := api.DepositGold(BankDepositRequest{PlayerID: "p1", Amount: 100})
+	if err == nil || errors.Is(err, ErrAmbiguousOutcome) {
+		t.Errorf("err = %v, want a decode error", err)
+	}
+	if entries := api.Journal().Entries(); len(entries) != 1 || entries[0].Status != MutationApplied {
+		t.Errorf("journal = %+v", entries)
+	}
+}
+
+func TestMutate_UncheckableAmbiguityNeedsResolve(t *testing.T) {
+	s := &mutationServer{status: http.StatusGatewayTimeout}
+	api := newMutationApi(t, s)
+	req := BankDepositRequest{PlayerID: "unknown", Amount: 100}
+	if _, err := api.DepositGold(req); !errors.Is(err, ErrAmbiguousOutcome) {
+		t.Fatalf("err = %v", err)
+	}
+	// Without the player's earlier state the retry cannot be checked.
+	if _, err := api.DepositGold(req); !errors.Is(err, ErrAmbiguousOutcome) || s.deposits != 1 {
+		t.Fatalf("err = %v, deposits = %d", err, s.deposits)
+	}
+	key := api.Journal().Ambiguous()[0].Key
+	if !api.Journal().Resolve(key, false) {
+		t.Fatal("Resolve found no entry")
+	}
+	s.status = http.StatusOK
+	if _, err := api.DepositGold(req); err != nil || s.deposits != 2 {
+		t.Errorf("err = %v, deposits = %d", err, s.deposits)
+	}
+}
+
+func TestRereadPlayer_ForgetsOnlyThatPlayer(t *testing.T) {
+	hits := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits[r.URL.Path]++
+		w.Write([]byte(`{"id":"x"}`))
+	}))
+	defer server.Close()
+	api := newTestApi(t, server.URL)
+	api.token = "t"
+	api.apiConfig.Cache = &ResponseCache{Backend: NewMemoryCache(10), DefaultTTL: time.Minute}
+	for _, id := range []string{"p1", "p2"} {
+		if _, err := api.FetchPlayerByID(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := api.rereadPlayer(context.Background(), "p1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.FetchPlayerByID("p2"); err != nil {
+		t.Fatal(err)
+	}
+	if hits["/players/p1"] != 2 || hits["/players/p2"] != 1 {
+		t.Errorf("hits = %v, want p1 re-read and p2 still cached", hits)
+	}
+}
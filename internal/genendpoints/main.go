@@ -0,0 +1,375 @@
+// Command genendpoints generates the endpoint registry, payload types and low-level endpoint wrappers
+// from endpoints.yaml. Run it with go generate from the module root.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is the parsed endpoints.yaml.
+type Spec struct {
+	Types     []Type     `yaml:"types"`
+	Endpoints []Endpoint `yaml:"endpoints"`
+}
+
+// Type is a generated request or response type. It is either a struct with Fields or a named Type.
+type Type struct {
+	Name   string  `yaml:"name"`
+	Doc    string  `yaml:"doc"`
+	Type   string  `yaml:"type"`
+	Fields []Field `yaml:"fields"`
+}
+
+// Field is a struct field. A field with nested Fields is an anonymous struct.
+type Field struct {
+	Name   string  `yaml:"name"`
+	Type   string  `yaml:"type"`
+	JSON   string  `yaml:"json"`
+	Fields []Field `yaml:"fields"`
+}
+
+// Endpoint is one route of the API and the wrapper generated for it.
+type Endpoint struct {
+	Name       string   `yaml:"name"`
+	Const      string   `yaml:"const"`
+	Wrapper    string   `yaml:"wrapper"`
+	Method     string   `yaml:"method"`
+	Path       string   `yaml:"path"`
+	Group      string   `yaml:"group"`
+	Auth       bool     `yaml:"auth"`
+	Idempotent bool     `yaml:"idempotent"`
+	Summary    string   `yaml:"summary"`
+	Request    string   `yaml:"request"`
+	Response   string   `yaml:"response"`
+	Query      []string `yaml:"query"`
+}
+
+// PathParams returns the {name} placeholders of the endpoint path in order.
+func (e Endpoint) PathParams() []string {
+	var params []string
+	for _, segment := range strings.Split(e.Path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params = append(params, strings.Trim(segment, "{}"))
+		}
+	}
+	return params
+}
+
+// GroupConst returns the rate-limit group constant of the endpoint.
+func (e Endpoint) GroupConst() string {
+	return "rateLimitGroup" + strings.ToUpper(e.Group[:1]) + e.Group[1:]
+}
+
+// RequestType returns the Go request body type, struct{} if the endpoint takes no body.
+func (e Endpoint) RequestType() string {
+	if e.Request == "" {
+		return "struct{}"
+	}
+	return e.Request
+}
+
+// Params returns the wrapper's parameter list after ctx.
+func (e Endpoint) Params() string {
+	var params []string
+	for _, p := range e.PathParams() {
+		params = append(params, p+" string")
+	}
+	if len(e.Query) > 0 {
+		params = append(params, "query url.Values")
+	}
+	if e.Request != "" {
+		params = append(params, "req "+e.Request)
+	}
+	if len(params) == 0 {
+		return ""
+	}
+	return ", " + strings.Join(params, ", ")
+}
+
+// CallArgs returns the path parameter, query and body arguments passed to callContext.
+func (e Endpoint) CallArgs() string {
+	params := "nil"
+	if names := e.PathParams(); len(names) > 0 {
+		pairs := make([]string, len(names))
+		for i, p := range names {
+			pairs[i] = fmt.Sprintf("%q: %s", p, p)
+		}
+		params = "pathParams{" + strings.Join(pairs, ", ") + "}"
+	}
+	query := "nil"
+	if len(e.Query) > 0 {
+		query = "query"
+	}
+	body := "struct{}{}"
+	if e.Request != "" {
+		body = "req"
+	}
+	return strings.Join([]string{params, query, body}, ", ")
+}
+
+// TestArgs returns the arguments the generated test passes after ctx.
+func (e Endpoint) TestArgs() string {
+	var args []string
+	for range e.PathParams() {
+		args = append(args, `"x1"`)
+	}
+	if len(e.Query) > 0 {
+		args = append(args, fmt.Sprintf("url.Values{%q: {\"1\"}}", e.TestQuery()))
+	}
+	if e.Request != "" {
+		args = append(args, e.Request+"{}")
+	}
+	if len(args) == 0 {
+		return ""
+	}
+	return ", " + strings.Join(args, ", ")
+}
+
+// TestQuery returns the query parameter the generated test sends, if the endpoint takes any.
+func (e Endpoint) TestQuery() string {
+	if len(e.Query) == 0 {
+		return ""
+	}
+	return e.Query[0]
+}
+
+// TestPath returns the request path the generated test expects, with placeholders set to "x1".
+func (e Endpoint) TestPath() string {
+	segments := strings.Split(e.Path, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, "{") {
+			segments[i] = "x1"
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// goType returns the Go type of a field, expanding nested fields into an anonymous struct.
+func goType(f Field) string {
+	if len(f.Fields) == 0 {
+		return f.Type
+	}
+	var b strings.Builder
+	b.WriteString("struct {\n")
+	for _, nested := range f.Fields {
+		fmt.Fprintf(&b, "%s %s `json:%q`\n", nested.Name, goType(nested), nested.JSON)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+var funcs = template.FuncMap{"goType": goType}
+
+var codeTemplate = template.Must(template.New("code").Funcs(funcs).Parse(`// Code generated by genendpoints from endpoints.yaml. DO NOT EDIT.
+
+package DarkThroneApi
+
+import (
+	"context"
+{{- if .HasQuery}}
+	"net/url"
+{{- end}}
+)
+
+const (
+{{- range .Endpoints}}
+	{{.Const}} endpointName = {{printf "%q" .Name}}
+{{- end}}
+)
+
+// endpointRegistry is the declarative table of every route the client calls.
+var endpointRegistry = map[endpointName]endpoint{
+{{- range .Endpoints}}
+	{{.Const}}: {Method: {{printf "%q" .Method}}, Path: {{printf "%q" .Path}}, {{if .Auth}}Auth: true, {{end}}{{if .Idempotent}}Idempotent: true, {{end}}RateLimitGroup: {{.GroupConst}}},
+{{- end}}
+}
+
+// endpointSchemas holds the request and response types of every registry endpoint.
+var endpointSchemas = map[endpointName]endpointSchema{
+{{- range .Endpoints}}
+	{{.Const}}: {Summary: {{printf "%q" .Summary}}{{if .Request}}, Request: typeOf[{{.Request}}](){{end}}{{if .Response}}, Response: typeOf[{{.Response}}](){{end}}{{if .Query}}, Query: []string{ {{- range $i, $q := .Query}}{{if $i}}, {{end}}{{printf "%q" $q}}{{end -}} }{{end}}},
+{{- end}}
+}
+{{range .Types}}
+// {{.Doc}}
+{{- if .Fields}}
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{.Name}} {{goType .}} ` + "`json:\"{{.JSON}}\"`" + `
+{{- end}}
+}
+{{- else}}
+type {{.Name}} {{.Type}}
+{{- end}}
+{{end}}
+{{- range .Endpoints}}
+// {{.Wrapper}} calls {{.Method}} {{.Path}}: {{.Summary}}.
+{{- if .Response}}
+func (d *DarkThroneApi) {{.Wrapper}}(ctx context.Context{{.Params}}) ({{.Response}}, error) {
+	return callContext[{{.RequestType}}, {{.Response}}](ctx, d, {{.Const}}, {{.CallArgs}})
+}
+{{- else}}
+func (d *DarkThroneApi) {{.Wrapper}}(ctx context.Context{{.Params}}) error {
+	_, err := callContext[{{.RequestType}}, struct{}](ctx, d, {{.Const}}, {{.CallArgs}})
+	return err
+}
+{{- end}}
+{{end}}`))
+
+var testTemplate = template.Must(template.New("test").Parse(`// Code generated by genendpoints from endpoints.yaml. DO NOT EDIT.
+
+package DarkThroneApi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+{{- if .HasQuery}}
+	"net/url"
+{{- end}}
+	"testing"
+)
+
+// newGeneratedTestServer answers every request with a JSON null after checking method, path,
+// query parameter, authentication and that only endpoints with a payload send a JSON body.
+func newGeneratedTestServer(t *testing.T, method, path, query string, auth, body bool) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method || r.URL.Path != path {
+			t.Errorf("request = %s %s, want %s %s", r.Method, r.URL.Path, method, path)
+		}
+		if query != "" && r.URL.Query().Get(query) != "1" {
+			t.Errorf("query = %q, want %s=1", r.URL.RawQuery, query)
+		}
+		if got, want := r.Header.Get("Authorization"), map[bool]string{true: "Bearer test-token"}[auth]; got != want {
+			t.Errorf("Authorization = %q, want %q", got, want)
+		}
+		data, _ := io.ReadAll(r.Body)
+		if len(data) > 0 && (!body || !json.Valid(data)) {
+			t.Errorf("unexpected request body %q", data)
+		}
+		w.Write([]byte("null"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+{{range .Endpoints}}
+func TestGenerated_{{.Wrapper}}(t *testing.T) {
+	server := newGeneratedTestServer(t, {{printf "%q" .Method}}, {{printf "%q" .TestPath}}, {{printf "%q" .TestQuery}}, {{.Auth}}, {{if .Request}}true{{else}}false{{end}})
+	api := newTestApi(t, server.URL)
+	api.token = "test-token"
+{{- if .Response}}
+	if _, err := api.{{.Wrapper}}(context.Background(){{.TestArgs}}); err != nil {
+{{- else}}
+	if err := api.{{.Wrapper}}(context.Background(){{.TestArgs}}); err != nil {
+{{- end}}
+		t.Fatal(err)
+	}
+}
+{{end}}`))
+
+// Generate renders the generated source and test files for spec.
+func Generate(spec Spec) (code, test []byte, err error) {
+	if err := validate(spec); err != nil {
+		return nil, nil, err
+	}
+	data := struct {
+		Spec
+		HasQuery bool
+	}{Spec: spec}
+	for _, e := range spec.Endpoints {
+		if len(e.Query) > 0 {
+			data.HasQuery = true
+		}
+	}
+	if code, err = render(codeTemplate, data); err != nil {
+		return nil, nil, err
+	}
+	if test, err = render(testTemplate, data); err != nil {
+		return nil, nil, err
+	}
+	return code, test, nil
+}
+
+func render(t *template.Template, data any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting %s: %w", t.Name(), err)
+	}
+	return src, nil
+}
+
+// validate checks that every endpoint has the fields the templates rely on and that names are unique.
+func validate(spec Spec) error {
+	seen := map[string]bool{}
+	for _, e := range spec.Endpoints {
+		if e.Name == "" || e.Const == "" || e.Wrapper == "" || e.Method == "" || e.Path == "" || e.Group == "" {
+			return fmt.Errorf("endpoint %q: name, const, wrapper, method, path and group are required", e.Name)
+		}
+		for _, key := range []string{"endpoint " + e.Name, "ident " + e.Const, "ident " + e.Wrapper} {
+			if seen[key] {
+				return fmt.Errorf("endpoint %q: duplicate %s", e.Name, key)
+			}
+			seen[key] = true
+		}
+	}
+	for _, t := range spec.Types {
+		if (len(t.Fields) == 0) == (t.Type == "") {
+			return fmt.Errorf("type %q: exactly one of fields and type is required", t.Name)
+		}
+		if seen["ident "+t.Name] {
+			return fmt.Errorf("type %q: duplicate name", t.Name)
+		}
+		seen["ident "+t.Name] = true
+	}
+	return nil
+}
+
+// Load reads and parses a spec file.
+func Load(path string) (Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Spec{}, err
+	}
+	var spec Spec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return Spec{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return spec, nil
+}
+
+func main() {
+	specPath := flag.String("spec", "endpoints.yaml", "endpoint spec")
+	out := flag.String("out", "endpoints_gen.go", "output file")
+	testOut := flag.String("test", "endpoints_gen_test.go", "output test file")
+	flag.Parse()
+	spec, err := Load(*specPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	code, test, err := Generate(spec)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, code, 0o644); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*testOut, test, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGenerate_UpToDate(t *testing.T) {
+	spec, err := Load("../../endpoints.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, test, err := Generate(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for file, want := range map[string][]byte{"../../endpoints_gen.go": code, "../../endpoints_gen_test.go": test} {
+		got, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s is out of date; run go generate", file)
+		}
+	}
+}
+
+func TestGenerate_Wrappers(t *testing.T) {
+	spec := Spec{
+		Types: []Type{{Name: "thingRequest", Doc: "thingRequest is a test payload.", Fields: []Field{{Name: "Name", Type: "string", JSON: "name"}}}},
+		Endpoints: []Endpoint{
+			{Name: "things.get", Const: "epThingsGet", Wrapper: "thingAPI", Method: "GET", Path: "things/{id}", Group: "read", Auth: true, Idempotent: true, Response: "Thing"},
+			{Name: "things.put", Const: "epThingsPut", Wrapper: "putThingAPI", Method: "POST", Path: "things", Group: "write", Request: "thingRequest"},
+		},
+	}
+	code, _, err := Generate(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`epThingsGet: {Method: "GET", Path: "things/{id}", Auth: true, Idempotent: true, RateLimitGroup: rateLimitGroupRead}`,
+		`func (d *DarkThroneApi) thingAPI(ctx context.Context, id string) (Thing, error) {`,
+		`callContext[struct{}, Thing](ctx, d, epThingsGet, pathParams{"id": id}, nil, struct{}{})`,
+		`func (d *DarkThroneApi) putThingAPI(ctx context.Context, req thingRequest) error {`,
+		"Name string `json:\"name\"`",
+	} {
+		if !strings.Contains(string(code), want) {
+			t.Errorf("generated code is missing %q", want)
+		}
+	}
+	if strings.Contains(string(code), `"net/url"`) {
+		t.Error("net/url is imported although no endpoint takes a query")
+	}
+}
+
+func TestGenerate_RejectsInvalidSpecs(t *testing.T) {
+	valid := Endpoint{Name: "a", Const: "epA", Wrapper: "aAPI", Method: "GET", Path: "a", Group: "read"}
+	for name, spec := range map[string]Spec{
+		"missing method":    {Endpoints: []Endpoint{{Name: "a", Const: "epA", Wrapper: "aAPI", Path: "a", Group: "read"}}},
+		"duplicate const":   {Endpoints: []Endpoint{valid, {Name: "b", Const: "epA", Wrapper: "bAPI", Method: "GET", Path: "b", Group: "read"}}},
+		"type without body": {Types: []Type{{Name: "empty"}}},
+	} {
+		if _, _, err := Generate(spec); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+// Command genopenapi writes the client's OpenAPI document. Run it with go generate from the module root.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	DarkThroneApi "github.com/Rihoj/DarkThroneApi"
+)
+
+func main() {
+	out := flag.String("out", "openapi.json", "output file")
+	flag.Parse()
+	spec, err := DarkThroneApi.OpenAPISpec()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, spec, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,188 @@
+package DarkThroneApi
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MutationStatus is the state of a journaled mutation.
+type MutationStatus string
+
+const (
+	// MutationPending means the request is in flight.
+	MutationPending MutationStatus = "pending"
+	// MutationDone means the server confirmed the mutation.
+	MutationDone MutationStatus = "done"
+	// MutationFailed means the mutation was not applied.
+	MutationFailed MutationStatus = "failed"
+	// MutationAmbiguous means the request may have reached the server but no answer was received.
+	MutationAmbiguous MutationStatus = "ambiguous"
+	// MutationApplied means the mutation took effect without being confirmed: the server accepted it
+	// but its response could not be read, or an ambiguous mutation was found applied by re-reading state.
+	MutationApplied MutationStatus = "applied"
+)
+
+// JournalEntry is one mutating request recorded by the MutationJournal.
+type JournalEntry struct {
+	// Key is the idempotency key sent with every attempt of the mutation.
+	Key      string
+	Endpoint string
+	PlayerID string
+	Request  any
+	// Before is the last known state of the player when the mutation was first sent, if any.
+	Before *Player
+	// BankBefore is the player's last reported bank balance when the mutation was first sent, if any.
+	BankBefore *int
+	// WarsBefore lists the IDs of the wars between the attacker and the target that the server already
+	// recorded when an attack was first sent; nil if they could not be read.
+	WarsBefore []string
+	Status     MutationStatus
+	Attempts   int
+	Err        string // Last error, if any
+	Started    time.Time
+	Updated    time.Time
+
+	fingerprint string
+	// claimed is set while a retry of the ambiguous entry is in progress.
+	claimed bool
+}
+
+// journalHistory is how many settled entries the journal keeps; pending and ambiguous entries are always kept.
+const journalHistory = 100
+
+// MutationJournal records the client's mutating requests so that an ambiguous outcome, such as a
+// timeout after the server received the request, is detected before the same request is sent again.
+type MutationJournal struct {
+	mu      sync.Mutex
+	entries []*JournalEntry
+}
+
+// NewMutationJournal returns an empty journal.
+func NewMutationJournal() *MutationJournal {
+	return &MutationJournal{}
+}
+
+// Entries returns a copy of the journal, oldest first.
+func (j *MutationJournal) Entries() []JournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entries := make([]JournalEntry, len(j.entries))
+	for i, e := range j.entries {
+		entries[i] = *e
+	}
+	return entries
+}
+
+// Ambiguous returns the mutations whose outcome is unknown.
+func (j *MutationJournal) Ambiguous() []JournalEntry {
+	var ambiguous []JournalEntry
+	for _, e := range j.Entries() {
+		if e.Status == MutationAmbiguous {
+			ambiguous = append(ambiguous, e)
+		}
+	}
+	return ambiguous
+}
+
+// Resolve settles an ambiguous mutation by hand, for example after checking the game in a browser.
+// It reports whether an ambiguous entry with key was found.
+func (j *MutationJournal) Resolve(key string, applied bool) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, e := range j.entries {
+		if e.Key == key && e.Status == MutationAmbiguous {
+			e.Status = MutationFailed
+			if applied {
+				e.Status = MutationApplied
+			}
+			e.Updated = time.Now()
+			return true
+		}
+	}
+	return false
+}
+
+// begin records a new pending mutation.
+func (j *MutationJournal) begin(e *JournalEntry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	e.Started = time.Now()
+	e.Updated = e.Started
+	e.Status = MutationPending
+	j.entries = append(j.entries, e)
+	j.prune()
+}
+
+// claimAmbiguous marks the ambiguous entry with fingerprint as pending and returns it with a copy taken
+// under the lock, so that only one retry settles it. It returns nil if there is no such entry, and
+// ErrAmbiguousOutcome while another retry holds the entry.
+func (j *MutationJournal) claimAmbiguous(fingerprint string) (*JournalEntry, JournalEntry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, e := range j.entries {
+		if e.fingerprint != fingerprint {
+			continue
+		}
+		if e.claimed {
+			return nil, JournalEntry{}, fmt.Errorf("%w: %s %s is being retried", ErrAmbiguousOutcome, e.Endpoint, e.Key)
+		}
+		if e.Status == MutationAmbiguous {
+			e.Status = MutationPending
+			e.Updated = time.Now()
+			e.claimed = true
+			return e, *e, nil
+		}
+	}
+	return nil, JournalEntry{}, nil
+}
+
+// unclaim returns a claimed entry to ambiguous when the retry could not settle it.
+func (j *MutationJournal) unclaim(e *JournalEntry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	e.Status = MutationAmbiguous
+	e.Updated = time.Now()
+	e.claimed = false
+}
+
+// update sets the status of e after an attempt.
+func (j *MutationJournal) update(e *JournalEntry, status MutationStatus, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	e.Status = status
+	e.claimed = false
+	e.Err = ""
+	if err != nil {
+		e.Err = err.Error()
+	}
+	e.Updated = time.Now()
+}
+
+// attempt counts a new attempt of e.
+func (j *MutationJournal) attempt(e *JournalEntry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	e.Attempts++
+	e.Status = MutationPending
+	e.Updated = time.Now()
+}
+
+// prune drops the oldest settled entries beyond journalHistory. Callers hold j.mu.
+func (j *MutationJournal) prune() {
+	settled := 0
+	for _, e := range j.entries {
+		if e.Status != MutationPending && e.Status != MutationAmbiguous {
+			settled++
+		}
+	}
+	kept := j.entries[:0]
+	for _, e := range j.entries {
+		if settled > journalHistory && e.Status != MutationPending && e.Status != MutationAmbiguous {
+			settled--
+			continue
+		}
+		kept = append(kept, e)
+	}
+	j.entries = kept
+}
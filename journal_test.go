@@ -0,0 +1,67 @@
+package DarkThroneApi
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestMutationJournal_PrunesSettledEntries(t *testing.T) {
+	j := NewMutationJournal()
+	ambiguous := &JournalEntry{Key: "ambiguous"}
+	j.begin(ambiguous)
+	j.update(ambiguous, MutationAmbiguous, nil)
+	for i := 0; i < journalHistory+10; i++ {
+		e := &JournalEntry{Key: fmt.Sprint(i)}
+		j.begin(e)
+		j.update(e, MutationDone, nil)
+	}
+	j.begin(&JournalEntry{Key: "last"})
+
+	entries := j.Entries()
+	if len(entries) != journalHistory+2 {
+		t.Errorf("kept %d entries, want %d", len(entries), journalHistory+2)
+	}
+	if entries[0].Key != "ambiguous" || entries[len(entries)-1].Key != "last" {
+		t.Errorf("first = %s, last = %s", entries[0].Key, entries[len(entries)-1].Key)
+	}
+}
+
+func TestMutationJournal_Resolve(t *testing.T) {
+	j := NewMutationJournal()
+	e := &JournalEntry{Key: "k"}
+	j.begin(e)
+	if j.Resolve("k", true) {
+		t.Error("a pending entry should not be resolvable")
+	}
+	j.update(e, MutationAmbiguous, nil)
+	if !j.Resolve("k", true) || j.Entries()[0].Status != MutationApplied {
+		t.Errorf("entry = %+v", j.Entries()[0])
+	}
+	if len(j.Ambiguous()) != 0 {
+		t.Error("resolved entry is still ambiguous")
+	}
+}
+
+func TestMutationJournal_ClaimAmbiguousOnce(t *testing.T) {
+	j := NewMutationJournal()
+	e := &JournalEntry{Key: "k", fingerprint: "f"}
+	j.begin(e)
+	j.update(e, MutationAmbiguous, nil)
+
+	claimed, snapshot, err := j.claimAmbiguous("f")
+	if err != nil || claimed != e || snapshot.Key != "k" || e.Status != MutationPending {
+		t.Fatalf("claim = %v, %+v, %v", claimed, snapshot, err)
+	}
+	if _, _, err := j.claimAmbiguous("f"); !errors.Is(err, ErrAmbiguousOutcome) {
+		t.Errorf("second claim err = %v, want ErrAmbiguousOutcome", err)
+	}
+	j.unclaim(e)
+	if claimed, _, err := j.claimAmbiguous("f"); err != nil || claimed != e {
+		t.Errorf("claim after unclaim = %v, %v", claimed, err)
+	}
+	j.update(e, MutationFailed, nil)
+	if claimed, _, err := j.claimAmbiguous("f"); err != nil || claimed != nil {
+		t.Errorf("claim of a settled entry = %v, %v", claimed, err)
+	}
+}
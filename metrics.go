@@ -0,0 +1,365 @@
+package DarkThroneApi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultLatencyBuckets are the histogram upper bounds, in seconds, used for all latency metrics.
+var defaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Error classes used as the error_class label on request metrics.
+const (
+	errorClassNone     = "none"
+	errorClassClient   = "http_4xx"
+	errorClassServer   = "http_5xx"
+	errorClassHTTP     = "http_other"
+	errorClassTimeout  = "timeout"
+	errorClassCanceled = "canceled"
+	errorClassNetwork  = "network"
+	errorClassDecode   = "decode"
+	errorClassOther    = "other"
+)
+
+// Metrics collects client metrics and serves them in the Prometheus text exposition format.
+// It is safe for concurrent use. A nil *Metrics records nothing.
+type Metrics struct {
+	mu            sync.Mutex
+	requests      map[requestMetricKey]uint64
+	latency       map[string]*histogram
+	rateLimitWait map[string]*histogram
+	retries       map[string]uint64
+	ping          *histogram
+	pingFailures  uint64
+	gauges        map[gaugeKey]float64
+	coalescer     *Coalescer
+	breaker       *CircuitBreaker
+}
+
+type requestMetricKey struct {
+	endpoint   string
+	status     string
+	errorClass string
+}
+
+type gaugeKey struct {
+	name     string
+	playerID string
+}
+
+// histogram is a cumulative Prometheus histogram.
+type histogram struct {
+	counts []uint64 // counts[i] is the number of observations <= defaultLatencyBuckets[i]
+	count  uint64
+	sum    float64
+}
+
+func (h *histogram) observe(v float64) {
+	for i, bound := range defaultLatencyBuckets {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// NewMetrics creates an empty metrics registry.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requests:      make(map[requestMetricKey]uint64),
+		latency:       make(map[string]*histogram),
+		rateLimitWait: make(map[string]*histogram),
+		retries:       make(map[string]uint64),
+		ping:          newHistogram(),
+		gauges:        make(map[gaugeKey]float64),
+	}
+}
+
+func newHistogram() *histogram {
+	return &histogram{counts: make([]uint64, len(defaultLatencyBuckets))}
+}
+
+// histogramFor returns the histogram for label in m, creating it if needed. m.mu must be held.
+func histogramFor(m map[string]*histogram, label string) *histogram {
+	h, ok := m[label]
+	if !ok {
+		h = newHistogram()
+		m[label] = h
+	}
+	return h
+}
+
+// observeRequest records a finished request to endpoint.
+func (m *Metrics) observeRequest(endpoint string, err error, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+	status, class := classifyError(err)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[requestMetricKey{endpoint: endpoint, status: status, errorClass: class}]++
+	histogramFor(m.latency, endpoint).observe(elapsed.Seconds())
+}
+
+// observeRateLimitWait records how long a request waited for its rate-limit slot.
+func (m *Metrics) observeRateLimitWait(group string, wait time.Duration) {
+	if m == nil {
+		return
+	}
+	if group == "" {
+		group = defaultRateLimitGroup
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	histogramFor(m.rateLimitWait, group).observe(wait.Seconds())
+}
+
+// observePing records the outcome of a Ping.
+func (m *Metrics) observePing(latency time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		m.pingFailures++
+		return
+	}
+	m.ping.observe(latency.Seconds())
+}
+
+// RecordRetry counts a retry of the named endpoint. The client does not retry on its own,
+// so callers that wrap it in retry loops report their retries here.
+func (m *Metrics) RecordRetry(endpoint string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries[endpoint]++
+}
+
+// gaugeHelp is the HELP text of each game gauge.
+var gaugeHelp = map[string]string{
+	"darkthrone_player_gold":         "Gold on hand of the client's own players.",
+	"darkthrone_player_army_size":    "Army size of the client's own players.",
+	"darkthrone_player_attack_turns": "Attack turns left for the client's own players.",
+	"darkthrone_player_level":        "Level of the client's own players.",
+	"darkthrone_bank_balance":        "Bank balance last reported for the client's own players.",
+}
+
+// SetPlayerState updates the game gauges for p.
+func (m *Metrics) SetPlayerState(p Player) {
+	if m == nil || p.ID == "" {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gauges[gaugeKey{"darkthrone_player_gold", p.ID}] = float64(p.Gold)
+	m.gauges[gaugeKey{"darkthrone_player_army_size", p.ID}] = float64(p.ArmySize)
+	m.gauges[gaugeKey{"darkthrone_player_attack_turns", p.ID}] = float64(p.AttackTurns)
+	m.gauges[gaugeKey{"darkthrone_player_level", p.ID}] = float64(p.Level)
+}
+
+// SetBankBalance updates the bank balance gauge for playerID.
+func (m *Metrics) SetBankBalance(playerID string, balance int) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gauges[gaugeKey{"darkthrone_bank_balance", playerID}] = float64(balance)
+}
+
+// subscribe keeps the game gauges up to date from the client's events.
+func (m *Metrics) subscribe(bus *EventBus) {
+	bus.Subscribe(func(e Event) {
+		switch e := e.(type) {
+		case PlayerAssumed:
+			m.SetPlayerState(e.Player)
+		case PlayerCreated:
+			m.SetPlayerState(e.Player)
+		case GoldDeposited:
+			m.SetBankBalance(e.PlayerID, e.Balance)
+		case GoldWithdrawn:
+			m.SetBankBalance(e.PlayerID, e.Balance)
+		}
+	})
+}
+
+// classifyError returns the status and error_class labels for a request outcome.
+func classifyError(err error) (status, class string) {
+	if err == nil {
+		return "200", errorClassNone
+	}
+	var statusErr *StatusError
+	var netErr net.Error
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var schemaErr *SchemaError
+	switch {
+	case errors.As(err, &statusErr):
+		status = strconv.Itoa(statusErr.StatusCode)
+		switch {
+		case statusErr.StatusCode >= 500:
+			return status, errorClassServer
+		case statusErr.StatusCode >= 400:
+			return status, errorClassClient
+		}
+		return status, errorClassHTTP
+	case errors.Is(err, context.DeadlineExceeded):
+		return "", errorClassTimeout
+	case errors.Is(err, context.Canceled):
+		return "", errorClassCanceled
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return "", errorClassTimeout
+		}
+		return "", errorClassNetwork
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr), errors.As(err, &schemaErr):
+		return "200", errorClassDecode
+	}
+	return "", errorClassOther
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format, for mounting at /metrics.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	m.mu.Lock()
+
+	b.WriteString("# HELP darkthrone_requests_total API requests by endpoint, HTTP status and error class.\n")
+	b.WriteString("# TYPE darkthrone_requests_total counter\n")
+	requestKeys := make([]requestMetricKey, 0, len(m.requests))
+	for k := range m.requests {
+		requestKeys = append(requestKeys, k)
+	}
+	sort.Slice(requestKeys, func(i, j int) bool {
+		a, c := requestKeys[i], requestKeys[j]
+		if a.endpoint != c.endpoint {
+			return a.endpoint < c.endpoint
+		}
+		if a.status != c.status {
+			return a.status < c.status
+		}
+		return a.errorClass < c.errorClass
+	})
+	for _, k := range requestKeys {
+		fmt.Fprintf(&b, "darkthrone_requests_total{endpoint=%s,status=%s,error_class=%s} %d\n",
+			quoteLabel(k.endpoint), quoteLabel(k.status), quoteLabel(k.errorClass), m.requests[k])
+	}
+
+	writeHistograms(&b, "darkthrone_request_duration_seconds", "API request latency, including rate-limit waits.", "endpoint", m.latency)
+	writeHistograms(&b, "darkthrone_rate_limit_wait_seconds", "Time spent waiting for a rate-limit slot.", "group", m.rateLimitWait)
+
+	b.WriteString("# HELP darkthrone_request_retries_total Retried API requests by endpoint.\n")
+	b.WriteString("# TYPE darkthrone_request_retries_total counter\n")
+	for _, endpoint := range sortedKeys(m.retries) {
+		fmt.Fprintf(&b, "darkthrone_request_retries_total{endpoint=%s} %d\n", quoteLabel(endpoint), m.retries[endpoint])
+	}
+
+	writeHistograms(&b, "darkthrone_ping_latency_seconds", "Latency of successful pings.", "", map[string]*histogram{"": m.ping})
+	b.WriteString("# HELP darkthrone_ping_failures_total Failed pings.\n")
+	b.WriteString("# TYPE darkthrone_ping_failures_total counter\n")
+	fmt.Fprintf(&b, "darkthrone_ping_failures_total %d\n", m.pingFailures)
+
+	if m.coalescer != nil {
+		stats := m.coalescer.Stats()
+		b.WriteString("# HELP darkthrone_coalescer_requests_total GET requests handled by the coalescer, by whether they were sent or shared.\n")
+		b.WriteString("# TYPE darkthrone_coalescer_requests_total counter\n")
+		fmt.Fprintf(&b, "darkthrone_coalescer_requests_total{result=\"executed\"} %d\n", stats.Executed)
+		fmt.Fprintf(&b, "darkthrone_coalescer_requests_total{result=\"shared\"} %d\n", stats.Shared)
+	}
+
+	if m.breaker != nil {
+		stats := m.breaker.Stats()
+		b.WriteString("# HELP darkthrone_circuit_breaker_state Circuit breaker state by rate-limit group: 0 closed, 1 open, 2 half-open.\n")
+		b.WriteString("# TYPE darkthrone_circuit_breaker_state gauge\n")
+		for _, s := range stats {
+			fmt.Fprintf(&b, "darkthrone_circuit_breaker_state{group=%s} %d\n", quoteLabel(s.Group), s.State)
+		}
+		b.WriteString("# HELP darkthrone_circuit_breaker_rejections_total Requests rejected by an open circuit breaker.\n")
+		b.WriteString("# TYPE darkthrone_circuit_breaker_rejections_total counter\n")
+		for _, s := range stats {
+			fmt.Fprintf(&b, "darkthrone_circuit_breaker_rejections_total{group=%s} %d\n", quoteLabel(s.Group), s.Rejected)
+		}
+	}
+
+	gaugeKeys := make([]gaugeKey, 0, len(m.gauges))
+	for k := range m.gauges {
+		gaugeKeys = append(gaugeKeys, k)
+	}
+	sort.Slice(gaugeKeys, func(i, j int) bool {
+		if gaugeKeys[i].name != gaugeKeys[j].name {
+			return gaugeKeys[i].name < gaugeKeys[j].name
+		}
+		return gaugeKeys[i].playerID < gaugeKeys[j].playerID
+	})
+	for i, k := range gaugeKeys {
+		if i == 0 || gaugeKeys[i-1].name != k.name {
+			fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", k.name, gaugeHelp[k.name], k.name)
+		}
+		fmt.Fprintf(&b, "%s{player_id=%s} %s\n", k.name, quoteLabel(k.playerID), formatFloat(m.gauges[k]))
+	}
+	m.mu.Unlock()
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// writeHistograms writes one histogram family. label names the single label distinguishing series,
+// or is empty for an unlabelled histogram.
+func writeHistograms(b *strings.Builder, name, help, label string, series map[string]*histogram) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	for _, value := range sortedKeys(series) {
+		h := series[value]
+		prefix := ""
+		if label != "" {
+			prefix = label + "=" + quoteLabel(value) + ","
+		}
+		for i, bound := range defaultLatencyBuckets {
+			fmt.Fprintf(b, "%s_bucket{%sle=\"%s\"} %d\n", name, prefix, formatFloat(bound), h.counts[i])
+		}
+		fmt.Fprintf(b, "%s_bucket{%sle=\"+Inf\"} %d\n", name, prefix, h.count)
+		labels := ""
+		if label != "" {
+			labels = "{" + strings.TrimSuffix(prefix, ",") + "}"
+		}
+		fmt.Fprintf(b, "%s_sum%s %s\n", name, labels, formatFloat(h.sum))
+		fmt.Fprintf(b, "%s_count%s %d\n", name, labels, h.count)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// quoteLabel quotes a label value, escaping backslashes, quotes and newlines.
+func quoteLabel(v string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v) + `"`
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
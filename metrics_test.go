@@ -0,0 +1,173 @@
+package DarkThroneApi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics_RecordsRequestsAndServesExposition(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/missing") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"id":"p1","name":"Alpha","gold":42}`))
+	}))
+	defer server.Close()
+	api := newTestApi(t, server.URL)
+	api.token = "token"
+	metrics := NewMetrics()
+	api.apiConfig.Metrics = metrics
+
+	if _, err := api.FetchPlayerByID("p1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.FetchPlayerByID("missing"); err == nil {
+		t.Fatal("expected an error")
+	}
+	metrics.RecordRetry("players.get")
+
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`darkthrone_requests_total{endpoint="players.get",status="200",error_class="none"} 1`,
+		`darkthrone_requests_total{endpoint="players.get",status="404",error_class="http_4xx"} 1`,
+		`darkthrone_request_duration_seconds_count{endpoint="players.get"} 2`,
+		`darkthrone_request_duration_seconds_bucket{endpoint="players.get",le="+Inf"} 2`,
+		`darkthrone_rate_limit_wait_seconds_count{group="read"} 2`,
+		`darkthrone_request_retries_total{endpoint="players.get"} 1`,
+		`darkthrone_ping_failures_total 0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("exposition is missing %q:\n%s", want, body)
+		}
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+}
+
+func TestMetrics_GameGaugesFromEvents(t *testing.T) {
+	metrics := NewMetrics()
+	bus := NewEventBus()
+	metrics.subscribe(bus)
+	bus.Publish(PlayerAssumed{Player: Player{ID: "p1", Gold: 100, ArmySize: 7, AttackTurns: 30, Level: 3}})
+	bus.Publish(GoldDeposited{PlayerID: "p1", Amount: 50, Balance: 550})
+
+	var b strings.Builder
+	metrics.WriteTo(&b)
+	for _, want := range []string{
+		`darkthrone_player_gold{player_id="p1"} 100`,
+		`darkthrone_player_army_size{player_id="p1"} 7`,
+		`darkthrone_player_attack_turns{player_id="p1"} 30`,
+		`darkthrone_bank_balance{player_id="p1"} 550`,
+		"# TYPE darkthrone_bank_balance gauge",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("exposition is missing %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestMetrics_GameGaugesFromEveryPlayerFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/players/p1":
+			w.Write([]byte(`{"id":"p1","gold":100}`))
+		case "/players/rival":
+			w.Write([]byte(`{"id":"rival","gold":999}`))
+		case "/auth/current-user":
+			w.Write([]byte(`{"player":{"id":"p2","gold":200}}`))
+		case "/auth/current-user/players":
+			w.Write([]byte(`[{"id":"p3","gold":300}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	api := newTestApi(t, server.URL)
+	api.token = "t"
+	api.config.Metrics = NewMetrics()
+
+	if _, err := api.GetCurrentUser(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.GetPlayersForCurrentUser(); err != nil {
+		t.Fatal(err)
+	}
+	api.rememberOwn(Player{ID: "p1"})
+	for _, id := range []string{"p1", "rival"} {
+		if _, err := api.FetchPlayerByID(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var b strings.Builder
+	api.config.Metrics.WriteTo(&b)
+	for _, want := range []string{
+		`darkthrone_player_gold{player_id="p1"} 100`,
+		`darkthrone_player_gold{player_id="p2"} 200`,
+		`darkthrone_player_gold{player_id="p3"} 300`,
+		"# HELP darkthrone_player_gold ",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("exposition is missing %q:\n%s", want, b.String())
+		}
+	}
+	if strings.Contains(b.String(), "rival") {
+		t.Errorf("rival players must not be exported:\n%s", b.String())
+	}
+}
+
+func TestMetrics_PingAndNil(t *testing.T) {
+	var nilMetrics *Metrics
+	nilMetrics.observeRequest("x", nil, time.Second)
+	nilMetrics.RecordRetry("x")
+
+	metrics := NewMetrics()
+	metrics.observePing(30*time.Millisecond, nil)
+	metrics.observePing(0, errors.New("down"))
+	var b strings.Builder
+	metrics.WriteTo(&b)
+	for _, want := range []string{
+		`darkthrone_ping_latency_seconds_bucket{le="0.05"} 1`,
+		`darkthrone_ping_latency_seconds_bucket{le="0.025"} 0`,
+		`darkthrone_ping_latency_seconds_count 1`,
+		`darkthrone_ping_failures_total 1`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("exposition is missing %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status string
+		class  string
+	}{
+		{nil, "200", "none"},
+		{&StatusError{StatusCode: 503, Status: "503 Service Unavailable"}, "503", "http_5xx"},
+		{context.DeadlineExceeded, "", "timeout"},
+		{context.Canceled, "", "canceled"},
+		{errors.New("boom"), "", "other"},
+	}
+	for _, tt := range tests {
+		status, class := classifyError(tt.err)
+		if status != tt.status || class != tt.class {
+			t.Errorf("classifyError(%v) = %q, %q; want %q, %q", tt.err, status, class, tt.status, tt.class)
+		}
+	}
+}
+
+func TestQuoteLabel(t *testing.T) {
+	if got := quoteLabel("a\"b\\c\nd"); got != `"a\"b\\c\nd"` {
+		t.Errorf("quoteLabel = %s", got)
+	}
+}
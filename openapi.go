@@ -0,0 +1,198 @@
+package DarkThroneApi
+
+//go:generate go run ./internal/genopenapi -out openapi.json
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// endpointSchema describes the payloads of a registry endpoint for the OpenAPI document.
+type endpointSchema struct {
+	Summary string
+	// Request is the JSON request body type, or nil if the endpoint takes no body.
+	Request reflect.Type
+	// Response is the JSON response body type, or nil if the response body is ignored.
+	Response reflect.Type
+	// Query lists the supported query parameters.
+	Query []string
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+var timeType = typeOf[time.Time]()
+
+// OpenAPISpec returns the OpenAPI 3 document describing every endpoint the client calls,
+// generated from the endpoint registry and the request and response types.
+func OpenAPISpec() ([]byte, error) {
+	components := map[string]any{}
+	paths := map[string]map[string]any{}
+	names := make([]string, 0, len(endpointRegistry))
+	for name := range endpointRegistry {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ep := endpointRegistry[endpointName(name)]
+		schema := endpointSchemas[endpointName(name)]
+		path := "/" + ep.Path
+		if paths[path] == nil {
+			paths[path] = map[string]any{}
+		}
+		paths[path][strings.ToLower(ep.Method)] = operationObject(endpointName(name), ep, schema, components)
+	}
+	doc := map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "Dark Throne Reborn API",
+			"description": "The subset of the Dark Throne Reborn API used by github.com/Rihoj/DarkThroneApi.",
+			"version":     "1.0.0",
+		},
+		"servers": []any{map[string]any{"url": "https://api.darkthronereborn.com"}},
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas": components,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// operationObject builds the OpenAPI operation for one endpoint, adding its schemas to components.
+func operationObject(name endpointName, ep endpoint, schema endpointSchema, components map[string]any) map[string]any {
+	op := map[string]any{
+		"operationId":        string(name),
+		"summary":            schema.Summary,
+		"x-rate-limit-group": ep.RateLimitGroup,
+		"x-idempotent":       ep.Idempotent,
+		"responses":          map[string]any{"200": map[string]any{"description": "OK"}},
+	}
+	var params []any
+	for _, segment := range strings.Split(ep.Path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params = append(params, map[string]any{
+				"name": strings.Trim(segment, "{}"), "in": "path", "required": true,
+				"schema": map[string]any{"type": "string"},
+			})
+		}
+	}
+	for _, q := range schema.Query {
+		paramSchema := map[string]any{"type": "string"}
+		switch q {
+		case "page", "pageSize":
+			paramSchema = map[string]any{"type": "integer", "minimum": 1}
+		case "from", "to":
+			paramSchema = map[string]any{"type": "string", "format": "date-time"}
+		case "outcome":
+			paramSchema = map[string]any{"type": "string", "enum": []any{string(WarOutcomeVictory), string(WarOutcomeDefeat)}}
+		}
+		params = append(params, map[string]any{"name": q, "in": "query", "required": false, "schema": paramSchema})
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+	if ep.Auth {
+		op["security"] = []any{map[string]any{"bearerAuth": []any{}}}
+	}
+	if schema.Request != nil {
+		op["requestBody"] = map[string]any{
+			"required": true,
+			"content":  map[string]any{"application/json": map[string]any{"schema": schemaFor(schema.Request, components)}},
+		}
+	}
+	if schema.Response != nil {
+		op["responses"] = map[string]any{"200": map[string]any{
+			"description": "OK",
+			"content":     map[string]any{"application/json": map[string]any{"schema": schemaFor(schema.Response, components)}},
+		}}
+	}
+	return op
+}
+
+// schemaFor returns the JSON schema of t. Named struct and slice types are added to components
+// and referenced; anonymous types are inlined.
+func schemaFor(t reflect.Type, components map[string]any) map[string]any {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+	schema := inlineSchemaFor(t, components)
+	if nullable {
+		if _, isRef := schema["$ref"]; isRef {
+			return map[string]any{"allOf": []any{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+	}
+	return schema
+}
+
+func inlineSchemaFor(t reflect.Type, components map[string]any) map[string]any {
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	named := t.Name() != "" && t.PkgPath() != "" && (t.Kind() == reflect.Struct || t.Kind() == reflect.Slice)
+	if named {
+		name := componentName(t)
+		if _, ok := components[name]; !ok {
+			components[name] = nil // reserve the name so recursive types terminate
+			components[name] = structuralSchema(t, components)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	}
+	return structuralSchema(t, components)
+}
+
+// structuralSchema returns the schema of t without naming it.
+func structuralSchema(t reflect.Type, components map[string]any) map[string]any {
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := map[string]any{}
+		var required []string
+		for _, f := range jsonFields(t) {
+			properties[f.name] = schemaFor(f.typ, components)
+			if f.required {
+				required = append(required, f.name)
+			}
+		}
+		schema := map[string]any{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			sort.Strings(required)
+			schema["required"] = required
+		}
+		return schema
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaFor(t.Elem(), components)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaFor(t.Elem(), components)}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	}
+	return map[string]any{}
+}
+
+// componentName returns the schema name of a named type, capitalising unexported names.
+func componentName(t reflect.Type) string {
+	name := []rune(t.Name())
+	name[0] = unicode.ToUpper(name[0])
+	return string(name)
+}
@@ -0,0 +1,51 @@
+package DarkThroneApi
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestOpenAPISpec_UpToDate(t *testing.T) {
+	spec, err := OpenAPISpec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	published, err := os.ReadFile("openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(spec, published) {
+		t.Error("openapi.json is out of date; run go generate")
+	}
+}
+
+func TestOpenAPISpec_CoversRegistry(t *testing.T) {
+	spec, err := OpenAPISpec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Paths map[string]map[string]struct {
+			OperationID string `json:"operationId"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		t.Fatal(err)
+	}
+	operations := map[string]bool{}
+	for _, methods := range doc.Paths {
+		for _, op := range methods {
+			operations[op.OperationID] = true
+		}
+	}
+	for name := range endpointRegistry {
+		if !operations[string(name)] {
+			t.Errorf("endpoint %s is missing from the spec", name)
+		}
+		if _, ok := endpointSchemas[name]; !ok {
+			t.Errorf("endpoint %s has no schema entry", name)
+		}
+	}
+}
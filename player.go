@@ -1,8 +1,12 @@
 package DarkThroneApi
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/url"
+	"sync"
+	"time"
 )
 
 // Player represents a player in the Dark Throne game.
@@ -14,73 +18,20 @@ type Player struct {
 	ArmySize    int    `json:"armySize"`
 	Units       []Unit `json:"units"`
 	AttackTurns int    `json:"attackTurns"`
+	Race        string `json:"race,omitempty"`
 }
 
 // Unit represents a unit in a player's army.
 type Unit struct {
-	UnitType string `json:"unitType"`
-	Quantity int    `json:"quantity"`
-}
-
-// PlayersListResponse represents a paginated list of players.
-type PlayersListResponse struct {
-	Items []Player `json:"items"`
-}
-
-// AttackResponse represents the result of an attack action.
-type AttackResponse struct {
-	IsAttackerVictor bool `json:"isAttackerVictor"`
-}
-
-// CreatePlayerRequest represents the payload to create a player.
-type CreatePlayerRequest struct {
-	Name     string `json:"name"`
-	Race     string `json:"race"`
-	Password string `json:"password"`
-}
-
-// TrainUnitsRequest represents the payload to train units.
-type TrainUnitsRequest struct {
-	PlayerID string        `json:"playerId"`
-	Units    []UnitRequest `json:"units"`
-}
-
-// TrainUnitsResponse represents the response from training units.
-type TrainUnitsResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-}
-
-// UntrainUnitsRequest represents the payload to untrain units.
-type UntrainUnitsRequest struct {
-	PlayerID string        `json:"playerId"`
-	Units    []UnitRequest `json:"units"`
-}
-
-// UntrainUnitsResponse represents the response from untraining units.
-type UntrainUnitsResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-}
-
-// UnitRequest represents a unit and quantity for training/untraining.
-type UnitRequest struct {
-	UnitType string `json:"unitType"`
-	Quantity int    `json:"quantity"`
-}
-
-// WarHistory represents a war history record.
-type WarHistory struct {
-	ID        string `json:"id"`
-	PlayerID  string `json:"playerId"`
-	Opponent  string `json:"opponent"`
-	Result    string `json:"result"`
-	Timestamp string `json:"timestamp"`
+	UnitType UnitType `json:"unitType"`
+	Quantity int      `json:"quantity"`
 }
 
 // GetPlayerByIndex retrieves a player by index from the user's player list and assumes that player.
 // If the index is out of range, it returns an error.
-func (d *DarkThroneApi) GetPlayerByIndex(index int) (Player, error) {
+func (d *DarkThroneApi) GetPlayerByIndex(index int) (player Player, err error) {
+	ctx, span := d.startSpan(context.Background(), "GetPlayerByIndex")
+	defer func() { endSpan(span, err) }()
 	logger := d.config.Logger
 	logger.Debug("Fetching player list for selection...")
 	if d.token == "" {
@@ -88,7 +39,7 @@ func (d *DarkThroneApi) GetPlayerByIndex(index int) (Player, error) {
 		return Player{}, errors.New("token is not set")
 	}
 
-	players, err := d.getPlayersListAPI()
+	players, err := d.currentUserPlayersAPI(ctx)
 	if err != nil || len(players) == 0 {
 		logger.Error("No players found in the response from auth/current-user/players")
 		return Player{}, errors.New("no players found")
@@ -106,88 +57,55 @@ func (d *DarkThroneApi) GetPlayerByIndex(index int) (Player, error) {
 		return Player{}, errors.New("failed to set player_id from the players list")
 	}
 
-	player, err := d.assumePlayerAPI(playerID)
+	response, err := d.assumePlayerAPI(ctx, assumePlayerRequest{PlayerID: playerID})
 	if err != nil {
 		logger.Error("Failed to assume player", "error", err)
 		return Player{}, fmt.Errorf("failed to assume player: %w", err)
 	}
-	return player, nil
+	d.rememberAssumed(response.Player)
+	d.publish(PlayerAssumed{Player: response.Player, At: time.Now()})
+	return response.Player, nil
 }
 
-// getPlayersListAPI fetches the list of players for the current user.
-// It returns a slice of Player and an error if the request fails.
-func (d *DarkThroneApi) getPlayersListAPI() ([]Player, error) {
-	playersReq := ApiRequest[struct{}, UserPlayersListResponse]{
-		Method:   "GET",
-		Endpoint: playersListEndpoint,
-		Headers:  d.getAuthHeaders(),
-		Body:     struct{}{},
-		Config:   d.apiConfig,
-	}
-	return playersReq.DoRequest()
+// FetchAllPlayers fetches all players (paginated). page and pageSize are always sent, even when zero.
+func (d *DarkThroneApi) FetchAllPlayers(page, pageSize int) ([]Player, error) {
+	return d.fetchPlayers(ListOptions{Page: page, PageSize: pageSize}.values(true))
 }
 
-// assumePlayerAPI assumes the given player ID and returns the Player.
-// It sends a POST request to the assume player endpoint and returns the assumed Player or an error.
-func (d *DarkThroneApi) assumePlayerAPI(playerID string) (Player, error) {
-	payload := map[string]string{"playerID": playerID}
-	assumeReq := ApiRequest[map[string]string, CurrentUserResponse]{
-		Method:   "POST",
-		Endpoint: assumePlayerEndpoint,
-		Headers:  d.getAuthHeaders(),
-		Body:     payload,
-		Config:   d.apiConfig,
-	}
-	assumeResp, err := assumeReq.DoRequest()
-	if err != nil {
-		return Player{}, err
+// FetchPlayersList fetches one page of players using typed pagination and filter options.
+// It returns ErrReservedFilter for a filter on "page" or "pageSize".
+func (d *DarkThroneApi) FetchPlayersList(opts ListOptions) ([]Player, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
 	}
-	return assumeResp.Player, nil
+	return d.fetchPlayers(opts.Values())
 }
 
-// FetchAllPlayers fetches all players (paginated).
-func (d *DarkThroneApi) FetchAllPlayers(page, pageSize int) ([]Player, error) {
-	endpoint := fmt.Sprintf("players?page=%d&pageSize=%d", page, pageSize)
-	response, err := ApiRequest[struct{}, PlayersListResponse]{
-		Method:   "GET",
-		Endpoint: endpoint,
-		Headers:  d.getAuthHeaders(),
-		Body:     struct{}{},
-		Config:   d.apiConfig,
-	}.DoRequest()
+// fetchPlayers fetches one page of players with the given query.
+func (d *DarkThroneApi) fetchPlayers(query url.Values) ([]Player, error) {
+	response, err := d.playersListAPI(context.Background(), query)
 	if err != nil {
 		return nil, err
 	}
+	d.recordSnapshots(response.Items...)
 	return response.Items, nil
 }
 
 // CreatePlayer creates a new player.
 func (d *DarkThroneApi) CreatePlayer(req CreatePlayerRequest) (Player, error) {
-	response, err := ApiRequest[CreatePlayerRequest, Player]{
-		Method:   "POST",
-		Endpoint: "players",
-		Headers:  d.getAuthHeaders(),
-		Body:     req,
-		Config:   d.apiConfig,
-	}.DoRequest()
+	response, err := mutate(context.Background(), d, epPlayersCreate, "", req, d.createPlayerAPI)
 	if err != nil {
 		return Player{}, err
 	}
+	d.rememberPlayers(response)
+	d.rememberOwn(response)
+	d.publish(PlayerCreated{Player: response, At: time.Now()})
 	return response, nil
 }
 
 // ValidatePlayerName validates a player name.
 func (d *DarkThroneApi) ValidatePlayerName(name string) (bool, error) {
-	payload := map[string]string{"name": name}
-	response, err := ApiRequest[map[string]string, struct {
-		Valid bool `json:"valid"`
-	}]{
-		Method:   "POST",
-		Endpoint: "players/validate-name",
-		Headers:  d.getAuthHeaders(),
-		Body:     payload,
-		Config:   d.apiConfig,
-	}.DoRequest()
+	response, err := d.validatePlayerNameAPI(context.Background(), validatePlayerNameRequest{Name: name})
 	if err != nil {
 		return false, err
 	}
@@ -196,98 +114,146 @@ func (d *DarkThroneApi) ValidatePlayerName(name string) (bool, error) {
 
 // FetchPlayerByID fetches a player by ID.
 func (d *DarkThroneApi) FetchPlayerByID(id string) (Player, error) {
-	endpoint := fmt.Sprintf("players/%s", id)
-	response, err := ApiRequest[struct{}, Player]{
-		Method:   "GET",
-		Endpoint: endpoint,
-		Headers:  d.getAuthHeaders(),
-		Body:     struct{}{},
-		Config:   d.apiConfig,
-	}.DoRequest()
+	response, err := d.playerAPI(context.Background(), id)
 	if err != nil {
 		return Player{}, err
 	}
+	d.recordSnapshots(response)
 	return response, nil
 }
 
 // FetchAllMatchingIDs fetches all matching player IDs.
 func (d *DarkThroneApi) FetchAllMatchingIDs(ids []string) ([]Player, error) {
-	payload := map[string][]string{"ids": ids}
-	response, err := ApiRequest[map[string][]string, struct {
-		Players []Player `json:"players"`
-	}]{
-		Method:   "POST",
-		Endpoint: "players/matching-ids",
-		Headers:  d.getAuthHeaders(),
-		Body:     payload,
-		Config:   d.apiConfig,
-	}.DoRequest()
+	response, err := d.matchingIDsAPI(context.Background(), matchingIDsRequest{IDs: ids})
 	if err != nil {
 		return nil, err
 	}
+	d.recordSnapshots(response.Players...)
 	return response.Players, nil
 }
 
-// FetchWarHistoryByID fetches war history by ID.
-func (d *DarkThroneApi) FetchWarHistoryByID(id string) (WarHistory, error) {
-	endpoint := fmt.Sprintf("war-history/%s", id)
-	response, err := ApiRequest[struct{}, WarHistory]{
-		Method:   "GET",
-		Endpoint: endpoint,
-		Headers:  d.getAuthHeaders(),
-		Body:     struct{}{},
-		Config:   d.apiConfig,
-	}.DoRequest()
-	if err != nil {
-		return WarHistory{}, err
+// PlayerResult is the outcome of fetching a single player in a batch.
+type PlayerResult struct {
+	ID     string
+	Player Player
+	Err    error
+}
+
+// ErrPlayerNotFound is reported for IDs that the server did not return a player for.
+var ErrPlayerNotFound = errors.New("player not found")
+
+// FetchPlayers fetches the players with the given IDs.
+// IDs are sent to the matching-ids endpoint in chunks of at most 100, with up to four chunks
+// in flight under the rate limiter. If a batch request fails,
+// its IDs are fetched one at a time instead. Results are returned in input order with per-ID errors;
+// the returned error is only set if ctx is cancelled.
+func (d *DarkThroneApi) FetchPlayers(ctx context.Context, ids []string) (_ []PlayerResult, err error) {
+	ctx, span := d.startSpan(ctx, "FetchPlayers")
+	defer func() { endSpan(span, err) }()
+	results := make([]PlayerResult, len(ids))
+	for i, id := range ids {
+		results[i].ID = id
 	}
-	return response, nil
+
+	sem := make(chan struct{}, fetch_players_concurrency)
+	var wg sync.WaitGroup
+	for start := 0; start < len(ids); start += page_size {
+		end := min(start+page_size, len(ids))
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(chunk []PlayerResult) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			d.fetchPlayersChunk(ctx, chunk)
+		}(results[start:end])
+	}
+	wg.Wait()
+
+	fetched := make([]Player, 0, len(results))
+	for _, r := range results {
+		if r.Err == nil && r.Player.ID != "" {
+			fetched = append(fetched, r.Player)
+		}
+	}
+	d.recordSnapshots(fetched...)
+
+	if err = ctx.Err(); err != nil {
+		for i := range results {
+			if results[i].Err == nil && results[i].Player.ID == "" {
+				results[i].Err = err
+			}
+		}
+		return results, err
+	}
+	return results, nil
 }
 
-// FetchAllWarHistory fetches all war history.
-func (d *DarkThroneApi) FetchAllWarHistory() ([]WarHistory, error) {
-	response, err := ApiRequest[struct{}, struct {
-		Items []WarHistory `json:"items"`
-	}]{
-		Method:   "GET",
-		Endpoint: "war-history",
-		Headers:  d.getAuthHeaders(),
-		Body:     struct{}{},
-		Config:   d.apiConfig,
-	}.DoRequest()
-	if err != nil {
-		return nil, err
+// fetchPlayersChunk fills chunk from a single matching-ids request, falling back to per-ID fetches if it fails.
+func (d *DarkThroneApi) fetchPlayersChunk(ctx context.Context, chunk []PlayerResult) {
+	ids := make([]string, len(chunk))
+	for i := range chunk {
+		ids[i] = chunk[i].ID
+	}
+	response, err := d.matchingIDsAPI(ctx, matchingIDsRequest{IDs: ids})
+	if err == nil {
+		byID := make(map[string]Player, len(response.Players))
+		for _, p := range response.Players {
+			byID[p.ID] = p
+		}
+		for i := range chunk {
+			if p, ok := byID[chunk[i].ID]; ok {
+				chunk[i].Player = p
+			} else {
+				chunk[i].Err = ErrPlayerNotFound
+			}
+		}
+		return
+	}
+
+	if d.config != nil && d.config.Logger != nil {
+		d.config.Logger.Warn("Batch player fetch failed, falling back to per-ID fetches", "ids", len(ids), "error", err)
+	}
+	for i := range chunk {
+		if ctx.Err() != nil {
+			return
+		}
+		chunk[i].Player, chunk[i].Err = d.playerAPI(ctx, chunk[i].ID)
 	}
-	return response.Items, nil
 }
 
 // TrainUnits trains units for the current player.
+// The order is validated against the unit catalog first, including the player's gold if the player has been fetched.
 func (d *DarkThroneApi) TrainUnits(req TrainUnitsRequest) (TrainUnitsResponse, error) {
-	response, err := ApiRequest[TrainUnitsRequest, TrainUnitsResponse]{
-		Method:   "POST",
-		Endpoint: "training/train",
-		Headers:  d.getAuthHeaders(),
-		Body:     req,
-		Config:   d.apiConfig,
-	}.DoRequest()
+	if err := d.validateTraining(req); err != nil {
+		return TrainUnitsResponse{}, err
+	}
+	response, err := mutate(context.Background(), d, epTrainingTrain, req.PlayerID, req, d.trainUnitsAPI)
 	if err != nil {
 		return TrainUnitsResponse{}, err
 	}
+	d.applyTraining(req)
+	d.publish(UnitsTrained{PlayerID: req.PlayerID, Units: req.Units, At: time.Now()})
 	return response, nil
 }
 
 // UntrainUnits untrains units for the current player.
+// The order is validated against the unit catalog first, including the player's army if the player has been fetched.
 func (d *DarkThroneApi) UntrainUnits(req UntrainUnitsRequest) (UntrainUnitsResponse, error) {
-	response, err := ApiRequest[UntrainUnitsRequest, UntrainUnitsResponse]{
-		Method:   "POST",
-		Endpoint: "training/untrain",
-		Headers:  d.getAuthHeaders(),
-		Body:     req,
-		Config:   d.apiConfig,
-	}.DoRequest()
+	if err := d.validateUntraining(req); err != nil {
+		return UntrainUnitsResponse{}, err
+	}
+	response, err := mutate(context.Background(), d, epTrainingUntrain, req.PlayerID, req, d.untrainUnitsAPI)
 	if err != nil {
 		return UntrainUnitsResponse{}, err
 	}
+	d.applyUntraining(req)
+	d.publish(UnitsUntrained{PlayerID: req.PlayerID, Units: req.Units, At: time.Now()})
 	return response, nil
 }
 
@@ -296,17 +262,9 @@ func (d *DarkThroneApi) UntrainUnits(req UntrainUnitsRequest) (UntrainUnitsRespo
 func (d *DarkThroneApi) AttackPlayer(targetID string) (bool, error) {
 	logger := d.config.Logger
 	logger.Warn("Attacking player", "target_id", targetID)
-	payload := map[string]any{
-		"targetID":    targetID,
-		"attackTurns": min_attack_turns,
-	}
-	response, err := ApiRequest[map[string]any, AttackResponse]{
-		Method:   "POST",
-		Endpoint: "attack",
-		Headers:  d.getAuthHeaders(),
-		Body:     payload,
-		Config:   d.apiConfig,
-	}.DoRequest()
+	payload := attackRequest{TargetID: targetID, AttackTurns: min_attack_turns}
+	attacker, _ := d.assumedPlayer()
+	response, err := mutate(context.Background(), d, epAttack, attacker.ID, payload, d.attackAPI)
 	if err != nil {
 		logger.Error("Attack request failed", "error", err)
 		return false, err
@@ -316,5 +274,6 @@ func (d *DarkThroneApi) AttackPlayer(targetID string) (bool, error) {
 	} else {
 		logger.Warn("Attack failed", "target_id", targetID)
 	}
+	d.publish(AttackCompleted{TargetID: targetID, AttackTurns: min_attack_turns, Victory: response.IsAttackerVictor, At: time.Now()})
 	return response.IsAttackerVictor, nil
 }
@@ -1,6 +1,14 @@
 package DarkThroneApi
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 )
 
@@ -22,3 +30,77 @@ func TestUnit_Fields(t *testing.T) {
 }
 
 // Add more tests for FetchAllPlayers, CreatePlayer, ValidatePlayerName, FetchPlayerByID, etc. as API integration or with mocks.
+
+func TestFetchPlayers_ChunksAndPreservesOrder(t *testing.T) {
+	var batches atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		batches.Add(1)
+		var payload map[string][]string
+		json.NewDecoder(r.Body).Decode(&payload)
+		if len(payload["ids"]) > page_size {
+			t.Errorf("chunk too large: %d", len(payload["ids"]))
+		}
+		var players []Player
+		for _, id := range payload["ids"] {
+			if id != "missing" {
+				players = append(players, Player{ID: id, Name: "n-" + id})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"players": players})
+	}))
+	defer ts.Close()
+
+	d := newTestApi(t, ts.URL)
+	d.token = "tok"
+	ids := make([]string, 250)
+	for i := range ids {
+		ids[i] = strconv.Itoa(i)
+	}
+	ids[42] = "missing"
+	results, err := d.FetchPlayers(context.Background(), ids)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if batches.Load() != 3 {
+		t.Errorf("expected 3 batch requests, got %d", batches.Load())
+	}
+	for i, r := range results {
+		if r.ID != ids[i] {
+			t.Fatalf("result %d out of order: %s", i, r.ID)
+		}
+		if i == 42 {
+			if !errors.Is(r.Err, ErrPlayerNotFound) {
+				t.Errorf("expected ErrPlayerNotFound, got %v", r.Err)
+			}
+		} else if r.Err != nil || r.Player.Name != "n-"+ids[i] {
+			t.Errorf("unexpected result %d: %+v", i, r)
+		}
+	}
+}
+
+func TestFetchPlayers_FallsBackToSingleFetches(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/players/matching-ids":
+			w.WriteHeader(http.StatusInternalServerError)
+		case r.URL.Path == "/players/bad":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			json.NewEncoder(w).Encode(Player{ID: strings.TrimPrefix(r.URL.Path, "/players/")})
+		}
+	}))
+	defer ts.Close()
+
+	d := newTestApi(t, ts.URL)
+	d.token = "tok"
+	results, err := d.FetchPlayers(context.Background(), []string{"a", "bad", "c"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results[0].Player.ID != "a" || results[2].Player.ID != "c" {
+		t.Errorf("unexpected results: %+v", results)
+	}
+	if results[1].Err == nil {
+		t.Error("expected per-ID error for bad")
+	}
+}
@@ -0,0 +1,279 @@
+package DarkThroneApi
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ProficiencyType is a proficiency that points can be spent on.
+type ProficiencyType string
+
+const (
+	ProficiencyStrength     ProficiencyType = "strength"
+	ProficiencyConstitution ProficiencyType = "constitution"
+	ProficiencyWealth       ProficiencyType = "wealth"
+	ProficiencyDexterity    ProficiencyType = "dexterity"
+	ProficiencyCharisma     ProficiencyType = "charisma"
+)
+
+// ProficiencyTypes lists the proficiencies the client knows of, in display order. The game does not
+// publish its proficiencies, so the list is not authoritative: other types are sent to the server
+// unchanged and it decides whether they exist.
+var ProficiencyTypes = []ProficiencyType{
+	ProficiencyStrength, ProficiencyConstitution, ProficiencyWealth, ProficiencyDexterity, ProficiencyCharisma,
+}
+
+// Known reports whether p is one of ProficiencyTypes.
+func (p ProficiencyType) Known() bool {
+	return slices.Contains(ProficiencyTypes, p)
+}
+
+// errEmptyProficiency is returned for an empty proficiency type.
+var errEmptyProficiency = errors.New("empty proficiency type")
+
+// ParseProficiencyType returns the proficiency named s. Known names are matched ignoring case; other
+// names are returned as they are, since the server may have proficiencies the client does not know.
+func ParseProficiencyType(s string) (ProficiencyType, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", errEmptyProficiency
+	}
+	for _, t := range ProficiencyTypes {
+		if strings.EqualFold(s, string(t)) {
+			return t, nil
+		}
+	}
+	return ProficiencyType(s), nil
+}
+
+// proficiencyOrder returns the types used in maps in display order: known types in ProficiencyTypes
+// order, then the others by name.
+func proficiencyOrder[V any](maps ...map[ProficiencyType]V) []ProficiencyType {
+	var other []ProficiencyType
+	for _, m := range maps {
+		for p := range m {
+			if !p.Known() && !slices.Contains(other, p) {
+				other = append(other, p)
+			}
+		}
+	}
+	slices.Sort(other)
+	order := make([]ProficiencyType, 0, len(ProficiencyTypes)+len(other))
+	for _, p := range ProficiencyTypes {
+		for _, m := range maps {
+			if _, ok := m[p]; ok {
+				order = append(order, p)
+				break
+			}
+		}
+	}
+	return append(order, other...)
+}
+
+// ErrNotEnoughProficiencyPoints is returned when an allocation spends more points than are available.
+var ErrNotEnoughProficiencyPoints = errors.New("not enough proficiency points")
+
+// ProficiencyBuild holds points per proficiency.
+type ProficiencyBuild map[ProficiencyType]int
+
+// Total returns the number of points in the build.
+func (b ProficiencyBuild) Total() int {
+	total := 0
+	for _, n := range b {
+		total += n
+	}
+	return total
+}
+
+// Add returns the sum of b and o.
+func (b ProficiencyBuild) Add(o ProficiencyBuild) ProficiencyBuild {
+	sum := make(ProficiencyBuild, len(ProficiencyTypes))
+	for p, n := range b {
+		sum[p] += n
+	}
+	for p, n := range o {
+		sum[p] += n
+	}
+	return sum
+}
+
+// validate checks that every proficiency is named and no count is negative.
+func (b ProficiencyBuild) validate() error {
+	for p, n := range b {
+		if p == "" {
+			return errEmptyProficiency
+		}
+		if n < 0 {
+			return fmt.Errorf("negative points for %s", p)
+		}
+	}
+	return nil
+}
+
+// AllocateWeighted spreads points across proficiencies in proportion to weights.
+// Rounding uses the largest-remainder method, so exactly points are allocated whenever any weight is positive.
+func AllocateWeighted(points int, weights map[ProficiencyType]float64) (ProficiencyBuild, error) {
+	if points < 0 {
+		return nil, fmt.Errorf("negative points: %d", points)
+	}
+	total := 0.0
+	for p, w := range weights {
+		if p == "" {
+			return nil, errEmptyProficiency
+		}
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return nil, fmt.Errorf("invalid weight %v for %s", w, p)
+		}
+		total += w
+	}
+	build := ProficiencyBuild{}
+	if total == 0 || points == 0 {
+		return build, nil
+	}
+	shares := make(map[ProficiencyType]float64, len(weights))
+	for p, w := range weights {
+		shares[p] = w / total * float64(points)
+	}
+	return largestRemainder(points, shares), nil
+}
+
+// AllocateTowards spends up to points moving current towards target.
+// If there are not enough points to close every gap, they are spread in proportion to the gaps.
+func AllocateTowards(points int, current, target ProficiencyBuild) (ProficiencyBuild, error) {
+	if points < 0 {
+		return nil, fmt.Errorf("negative points: %d", points)
+	}
+	if err := current.validate(); err != nil {
+		return nil, err
+	}
+	if err := target.validate(); err != nil {
+		return nil, err
+	}
+	gaps := make(map[ProficiencyType]float64, len(target))
+	needed := 0
+	for p, want := range target {
+		if gap := want - current[p]; gap > 0 {
+			gaps[p] = float64(gap)
+			needed += gap
+		}
+	}
+	if needed <= points {
+		build := ProficiencyBuild{}
+		for p, gap := range gaps {
+			build[p] = int(gap)
+		}
+		return build, nil
+	}
+	for p := range gaps {
+		gaps[p] = gaps[p] / float64(needed) * float64(points)
+	}
+	return largestRemainder(points, gaps), nil
+}
+
+// largestRemainder rounds shares down and hands the remaining points to the largest fractions,
+// breaking ties in display order.
+func largestRemainder(points int, shares map[ProficiencyType]float64) ProficiencyBuild {
+	build := ProficiencyBuild{}
+	allocated := 0
+	order := proficiencyOrder(shares)
+	for _, p := range order {
+		build[p] = int(shares[p])
+		allocated += build[p]
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		fi := shares[order[i]] - math.Floor(shares[order[i]])
+		fj := shares[order[j]] - math.Floor(shares[order[j]])
+		return fi > fj
+	})
+	for i := 0; allocated < points && len(order) > 0; i = (i + 1) % len(order) {
+		build[order[i]]++
+		allocated++
+	}
+	for p, n := range build {
+		if n == 0 {
+			delete(build, p)
+		}
+	}
+	return build
+}
+
+// ProficiencyPlan is a validated allocation of proficiency points for a player.
+type ProficiencyPlan struct {
+	PlayerID   string
+	Available  int
+	Current    ProficiencyBuild
+	Allocation ProficiencyBuild
+}
+
+// PlanProficiencyPoints validates allocation against the available points and returns the plan.
+// Nothing is sent to the server; use Preview to show the result and SpendProficiencyPlan to commit it.
+func PlanProficiencyPoints(playerID string, available int, current, allocation ProficiencyBuild) (ProficiencyPlan, error) {
+	if err := current.validate(); err != nil {
+		return ProficiencyPlan{}, err
+	}
+	if err := allocation.validate(); err != nil {
+		return ProficiencyPlan{}, err
+	}
+	if spent := allocation.Total(); spent > available {
+		return ProficiencyPlan{}, fmt.Errorf("%w: allocating %d of %d", ErrNotEnoughProficiencyPoints, spent, available)
+	}
+	return ProficiencyPlan{PlayerID: playerID, Available: available, Current: current, Allocation: allocation}, nil
+}
+
+// Result returns the build after the plan is spent.
+func (p ProficiencyPlan) Result() ProficiencyBuild {
+	return p.Current.Add(p.Allocation)
+}
+
+// Remaining returns the points left unspent by the plan.
+func (p ProficiencyPlan) Remaining() int {
+	return p.Available - p.Allocation.Total()
+}
+
+// Requests returns one SpendProficiencyPoints request per proficiency that receives points.
+func (p ProficiencyPlan) Requests() []ProficiencyPointsRequest {
+	var requests []ProficiencyPointsRequest
+	for _, t := range proficiencyOrder(p.Allocation) {
+		if n := p.Allocation[t]; n > 0 {
+			requests = append(requests, ProficiencyPointsRequest{PlayerID: p.PlayerID, PointsToSpend: n, ProficiencyType: t})
+		}
+	}
+	return requests
+}
+
+// Preview describes the resulting build, one proficiency per line, without spending anything.
+// Every known proficiency is listed, followed by any other type in the plan.
+func (p ProficiencyPlan) Preview() string {
+	var b strings.Builder
+	result := p.Result()
+	known := make(ProficiencyBuild, len(ProficiencyTypes))
+	for _, t := range ProficiencyTypes {
+		known[t] = 0
+	}
+	for _, t := range proficiencyOrder(known, result) {
+		fmt.Fprintf(&b, "%-12s %4d -> %4d (+%d)\n", t, p.Current[t], result[t], p.Allocation[t])
+	}
+	fmt.Fprintf(&b, "%-12s %4d -> %4d\n", "unspent", p.Available, p.Remaining())
+	return b.String()
+}
+
+// SpendProficiencyPlan spends the points of plan, one request per proficiency.
+// It stops at the first failure and returns the responses received so far.
+func (d *DarkThroneApi) SpendProficiencyPlan(plan ProficiencyPlan) ([]ProficiencyPointsResponse, error) {
+	if _, err := PlanProficiencyPoints(plan.PlayerID, plan.Available, plan.Current, plan.Allocation); err != nil {
+		return nil, err
+	}
+	var responses []ProficiencyPointsResponse
+	for _, req := range plan.Requests() {
+		response, err := d.SpendProficiencyPoints(req)
+		if err != nil {
+			return responses, fmt.Errorf("spending %d points on %s: %w", req.PointsToSpend, req.ProficiencyType, err)
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
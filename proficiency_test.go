@@ -0,0 +1,137 @@
+package DarkThroneApi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseProficiencyType(t *testing.T) {
+	if p, err := ParseProficiencyType(" Wealth "); err != nil || p != ProficiencyWealth {
+		t.Errorf("ParseProficiencyType = %q, %v", p, err)
+	}
+	if p, err := ParseProficiencyType(" Luck "); err != nil || p != "Luck" {
+		t.Errorf("ParseProficiencyType = %q, %v, want an unknown name passed through", p, err)
+	}
+	if _, err := ParseProficiencyType(" "); err == nil {
+		t.Error("expected an error for an empty name")
+	}
+}
+
+func TestAllocateWeighted(t *testing.T) {
+	build, err := AllocateWeighted(10, map[ProficiencyType]float64{
+		ProficiencyStrength: 1, ProficiencyConstitution: 1, ProficiencyWealth: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 3.33 each; the leftover point goes to the first type in display order.
+	want := ProficiencyBuild{ProficiencyStrength: 4, ProficiencyConstitution: 3, ProficiencyWealth: 3}
+	if !buildsEqual(build, want) {
+		t.Errorf("build = %v, want %v", build, want)
+	}
+	build, _ = AllocateWeighted(7, map[ProficiencyType]float64{ProficiencyDexterity: 0.75, ProficiencyCharisma: 0.25})
+	if !buildsEqual(build, ProficiencyBuild{ProficiencyDexterity: 5, ProficiencyCharisma: 2}) {
+		t.Errorf("build = %v", build)
+	}
+	build, err = AllocateWeighted(5, map[ProficiencyType]float64{"luck": 1, ProficiencyWealth: 1})
+	if err != nil || !buildsEqual(build, ProficiencyBuild{ProficiencyWealth: 3, "luck": 2}) {
+		t.Errorf("unknown types: build = %v, err = %v", build, err)
+	}
+	if _, err := AllocateWeighted(5, map[ProficiencyType]float64{ProficiencyWealth: -1}); err == nil {
+		t.Error("expected an error for a negative weight")
+	}
+}
+
+func TestAllocateTowards(t *testing.T) {
+	current := ProficiencyBuild{ProficiencyStrength: 5, ProficiencyWealth: 10}
+	target := ProficiencyBuild{ProficiencyStrength: 10, ProficiencyWealth: 5, ProficiencyCharisma: 3}
+	build, err := AllocateTowards(20, current, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !buildsEqual(build, ProficiencyBuild{ProficiencyStrength: 5, ProficiencyCharisma: 3}) {
+		t.Errorf("enough points: build = %v", build)
+	}
+	build, _ = AllocateTowards(4, current, target)
+	// Gaps 5 and 3 scaled to 4 points: 2.5 and 1.5.
+	if build.Total() != 4 || build[ProficiencyStrength] < 2 || build[ProficiencyCharisma] < 1 {
+		t.Errorf("scarce points: build = %v", build)
+	}
+}
+
+func TestPlanProficiencyPoints(t *testing.T) {
+	current := ProficiencyBuild{ProficiencyStrength: 2}
+	plan, err := PlanProficiencyPoints("p1", 5, current, ProficiencyBuild{ProficiencyStrength: 1, ProficiencyWealth: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Remaining() != 1 || plan.Result()[ProficiencyStrength] != 3 {
+		t.Errorf("plan = %+v", plan)
+	}
+	requests := plan.Requests()
+	if len(requests) != 2 || requests[0].ProficiencyType != ProficiencyStrength || requests[1].PointsToSpend != 3 {
+		t.Errorf("requests = %+v", requests)
+	}
+	if preview := plan.Preview(); !strings.Contains(preview, "wealth          0 ->    3 (+3)") {
+		t.Errorf("preview:\n%s", preview)
+	}
+	if _, err := PlanProficiencyPoints("p1", 3, nil, ProficiencyBuild{ProficiencyWealth: 4}); !errors.Is(err, ErrNotEnoughProficiencyPoints) {
+		t.Errorf("err = %v, want ErrNotEnoughProficiencyPoints", err)
+	}
+}
+
+func TestSpendProficiencyPlan(t *testing.T) {
+	var spent []ProficiencyPointsRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ProficiencyPointsRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		spent = append(spent, req)
+		w.Write([]byte(`{"success":true,"remainingPoints":0}`))
+	}))
+	defer server.Close()
+	api := newTestApi(t, server.URL)
+	api.token = "t"
+	api.config.Capabilities = &Capabilities{ProficiencyPoints: true}
+
+	plan, _ := PlanProficiencyPoints("p1", 4, nil, ProficiencyBuild{ProficiencyDexterity: 1, ProficiencyCharisma: 3})
+	responses, err := api.SpendProficiencyPlan(plan)
+	if err != nil || len(responses) != 2 {
+		t.Fatalf("responses = %+v, err = %v", responses, err)
+	}
+	if len(spent) != 2 || spent[0].ProficiencyType != ProficiencyDexterity || spent[1].PointsToSpend != 3 {
+		t.Errorf("spent = %+v", spent)
+	}
+
+	plan.Allocation[ProficiencyWealth] = 10
+	if _, err := api.SpendProficiencyPlan(plan); !errors.Is(err, ErrNotEnoughProficiencyPoints) {
+		t.Errorf("err = %v, want ErrNotEnoughProficiencyPoints", err)
+	}
+	if _, err := api.SpendProficiencyPoints(ProficiencyPointsRequest{PlayerID: "p1", PointsToSpend: 1}); err == nil {
+		t.Error("expected an error for an empty proficiency type")
+	}
+	if len(spent) != 2 {
+		t.Errorf("invalid requests reached the server: %+v", spent[2:])
+	}
+	if _, err := api.SpendProficiencyPoints(ProficiencyPointsRequest{PlayerID: "p1", PointsToSpend: 1, ProficiencyType: "luck"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(spent) != 3 || spent[2].ProficiencyType != "luck" {
+		t.Errorf("spent = %+v, want the unknown type sent unchanged", spent)
+	}
+}
+
+func buildsEqual(a, b ProficiencyBuild) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for p, n := range a {
+		if b[p] != n {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package DarkThroneApi
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// ErrReservedFilter is returned for ListOptions filters that use the "page" or "pageSize" key,
+// which are set from Page and PageSize.
+var ErrReservedFilter = errors.New("filter key is reserved for pagination")
+
+// ListOptions holds the typed query parameters accepted by paginated list endpoints.
+// Zero values are omitted from the query string.
+type ListOptions struct {
+	Page     int
+	PageSize int
+	// Filters are additional endpoint-specific query parameters, such as "race" or "opponentId".
+	Filters map[string]string
+}
+
+// validate rejects filters that would clash with the pagination parameters.
+func (o ListOptions) validate() error {
+	for _, k := range []string{"page", "pageSize"} {
+		if _, ok := o.Filters[k]; ok {
+			return fmt.Errorf("%w: %q", ErrReservedFilter, k)
+		}
+	}
+	return nil
+}
+
+// Values encodes the options as URL query parameters. Filters using a pagination key are left out.
+func (o ListOptions) Values() url.Values {
+	return o.values(false)
+}
+
+// values is Values, also sending a zero page and pageSize when paged is set.
+func (o ListOptions) values(paged bool) url.Values {
+	values := url.Values{}
+	if paged || o.Page > 0 {
+		values.Set("page", strconv.Itoa(o.Page))
+	}
+	if paged || o.PageSize > 0 {
+		values.Set("pageSize", strconv.Itoa(o.PageSize))
+	}
+	keys := make([]string, 0, len(o.Filters))
+	for k := range o.Filters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if k == "" || k == "page" || k == "pageSize" || o.Filters[k] == "" {
+			continue
+		}
+		values.Set(k, o.Filters[k])
+	}
+	return values
+}
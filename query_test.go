@@ -0,0 +1,46 @@
+package DarkThroneApi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListOptions_Values(t *testing.T) {
+	opts := ListOptions{Page: 2, PageSize: 50, Filters: map[string]string{"race": "elf", "empty": "", "page": "9"}}
+	if got := opts.Values().Encode(); got != "page=2&pageSize=50&race=elf" {
+		t.Errorf("unexpected query: %s", got)
+	}
+	if got := (ListOptions{}).Values().Encode(); got != "" {
+		t.Errorf("expected empty query, got %s", got)
+	}
+}
+
+func TestFetchAllPlayers_AlwaysSendsPagination(t *testing.T) {
+	var query string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Write([]byte(`{"items":[]}`))
+	}))
+	defer ts.Close()
+	d := newTestApi(t, ts.URL)
+	d.token = "tok"
+
+	if _, err := d.FetchAllPlayers(0, 25); err != nil {
+		t.Fatal(err)
+	}
+	if query != "page=0&pageSize=25" {
+		t.Errorf("query = %q, want page=0&pageSize=25", query)
+	}
+}
+
+func TestFetchPlayersList_RejectsPaginationFilters(t *testing.T) {
+	d := newTestApi(t, "http://127.0.0.1:0")
+	for _, key := range []string{"page", "pageSize"} {
+		_, err := d.FetchPlayersList(ListOptions{Filters: map[string]string{key: "3"}})
+		if !errors.Is(err, ErrReservedFilter) {
+			t.Errorf("%s: err = %v, want ErrReservedFilter", key, err)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package DarkThroneApi
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DriftKind is the kind of difference between a response payload and the Go type it decodes into.
+type DriftKind string
+
+const (
+	// DriftUnknownField is a payload field that no Go field decodes.
+	DriftUnknownField DriftKind = "unknown_field"
+	// DriftMissingField is a required Go field that the payload did not contain.
+	// Fields tagged omitempty, pointer fields and those listed in optionalFields are optional.
+	DriftMissingField DriftKind = "missing_field"
+)
+
+// FieldDrift is one field-level difference found in an endpoint's response.
+type FieldDrift struct {
+	Endpoint string
+	// Path locates the field, e.g. "items[].gold".
+	Path string
+	Kind DriftKind
+}
+
+// String returns e.g. "players.get: unknown field items[].gold".
+func (d FieldDrift) String() string {
+	return fmt.Sprintf("%s: %s %s", d.Endpoint, strings.ReplaceAll(string(d.Kind), "_", " "), d.Path)
+}
+
+// SchemaError is returned in strict decoding mode when a response does not match its Go type.
+type SchemaError struct {
+	Endpoint string
+	Drift    []FieldDrift
+}
+
+func (e *SchemaError) Error() string {
+	paths := make([]string, len(e.Drift))
+	for i, d := range e.Drift {
+		paths[i] = strings.ReplaceAll(string(d.Kind), "_", " ") + " " + d.Path
+	}
+	return fmt.Sprintf("response from %s does not match its schema: %s", e.Endpoint, strings.Join(paths, ", "))
+}
+
+// optionalFields declares JSON fields, by struct type, that drift detection treats as optional even
+// though their tags do not. The legacy WarHistory fields are only sent by older API versions.
+var optionalFields = map[reflect.Type][]string{
+	typeOf[WarHistory](): {"playerId", "opponent", "result", "timestamp"},
+}
+
+// DriftReport is a distinct drift together with how often and when it was seen.
+type DriftReport struct {
+	FieldDrift
+	Count     int
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// DriftDetector collects schema drift from decoded responses. Each distinct drift is logged once,
+// when it is first seen. It is safe for concurrent use.
+type DriftDetector struct {
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	reports map[FieldDrift]*DriftReport
+}
+
+// NewDriftDetector creates a detector that logs new drift to logger, which may be nil.
+func NewDriftDetector(logger *slog.Logger) *DriftDetector {
+	return &DriftDetector{logger: logger, reports: make(map[FieldDrift]*DriftReport)}
+}
+
+// observe records drift found in one response.
+func (dd *DriftDetector) observe(drift []FieldDrift) {
+	if dd == nil || len(drift) == 0 {
+		return
+	}
+	now := time.Now()
+	dd.mu.Lock()
+	defer dd.mu.Unlock()
+	for _, d := range drift {
+		r, ok := dd.reports[d]
+		if !ok {
+			r = &DriftReport{FieldDrift: d, FirstSeen: now}
+			dd.reports[d] = r
+			if dd.logger != nil {
+				dd.logger.Warn("API schema drift detected", "endpoint", d.Endpoint, "path", d.Path, "kind", string(d.Kind))
+			}
+		}
+		r.Count++
+		r.LastSeen = now
+	}
+}
+
+// Report returns every distinct drift seen so far, sorted by endpoint and path.
+func (dd *DriftDetector) Report() []DriftReport {
+	dd.mu.Lock()
+	defer dd.mu.Unlock()
+	reports := make([]DriftReport, 0, len(dd.reports))
+	for _, r := range dd.reports {
+		reports = append(reports, *r)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Endpoint != reports[j].Endpoint {
+			return reports[i].Endpoint < reports[j].Endpoint
+		}
+		if reports[i].Path != reports[j].Path {
+			return reports[i].Path < reports[j].Path
+		}
+		return reports[i].Kind < reports[j].Kind
+	})
+	return reports
+}
+
+// findDrift compares a JSON payload with the type it decodes into. It returns nil if body is not valid JSON;
+// the decode error is reported separately.
+func findDrift(endpoint string, body []byte, t reflect.Type) []FieldDrift {
+	var payload any
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil
+	}
+	seen := make(map[FieldDrift]bool)
+	var drift []FieldDrift
+	report := func(path string, kind DriftKind) {
+		d := FieldDrift{Endpoint: endpoint, Path: path, Kind: kind}
+		if !seen[d] {
+			seen[d] = true
+			drift = append(drift, d)
+		}
+	}
+	compareSchema(payload, t, "", report)
+	sort.Slice(drift, func(i, j int) bool { return drift[i].Path < drift[j].Path })
+	return drift
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// compareSchema walks value and t together, reporting unknown and missing fields.
+// Types with custom unmarshalling are treated as opaque.
+func compareSchema(value any, t reflect.Type, path string, report func(string, DriftKind)) {
+	if t == nil || value == nil {
+		return
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return
+		}
+		fields := jsonFields(t)
+		matched := make(map[string]bool)
+		for key, v := range object {
+			f, ok := lookupJSONField(fields, key)
+			if !ok {
+				report(joinPath(path, key), DriftUnknownField)
+				continue
+			}
+			matched[f.name] = true
+			compareSchema(v, f.typ, joinPath(path, f.name), report)
+		}
+		for _, f := range fields {
+			if f.required && !matched[f.name] {
+				report(joinPath(path, f.name), DriftMissingField)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if items, ok := value.([]any); ok {
+			for _, item := range items {
+				compareSchema(item, t.Elem(), path+"[]", report)
+			}
+		}
+	case reflect.Map:
+		if object, ok := value.(map[string]any); ok {
+			for _, v := range object {
+				compareSchema(v, t.Elem(), path+"{}", report)
+			}
+		}
+	}
+}
+
+// jsonField is a struct field as encoding/json sees it.
+type jsonField struct {
+	name     string
+	typ      reflect.Type
+	required bool
+}
+
+// jsonFields returns the JSON fields of struct type t, flattening untagged embedded structs.
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if sf.Anonymous && name == "" {
+			embedded := sf.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, jsonFields(embedded)...)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		optional := sf.Type.Kind() == reflect.Pointer || strings.Contains(","+opts+",", ",omitempty,") ||
+			slices.Contains(optionalFields[t], name)
+		fields = append(fields, jsonField{name: name, typ: sf.Type, required: !optional})
+	}
+	return fields
+}
+
+// lookupJSONField finds the field for a payload key, preferring an exact match and
+// falling back to a case-insensitive one, as encoding/json does.
+func lookupJSONField(fields []jsonField, key string) (jsonField, bool) {
+	for _, f := range fields {
+		if f.name == key {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.name, key) {
+			return f, true
+		}
+	}
+	return jsonField{}, false
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,63 @@
+package DarkThroneApi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestFindDrift(t *testing.T) {
+	body := []byte(`{"items":[{"id":"p1","name":"A","goldOnHand":5,"level":1,"armySize":2,"units":[{"unitType":"worker","quantity":1,"tier":2}],"attackTurns":3}]}`)
+	drift := findDrift("players.list", body, reflect.TypeOf(PlayersListResponse{}))
+	want := []FieldDrift{
+		{Endpoint: "players.list", Path: "items[].gold", Kind: DriftMissingField},
+		{Endpoint: "players.list", Path: "items[].goldOnHand", Kind: DriftUnknownField},
+		{Endpoint: "players.list", Path: "items[].units[].tier", Kind: DriftUnknownField},
+	}
+	if !reflect.DeepEqual(drift, want) {
+		t.Errorf("drift = %+v\nwant %+v", drift, want)
+	}
+}
+
+func TestFindDrift_OptionalAndOpaqueFields(t *testing.T) {
+	// Legacy war history fields are omitempty, and time.Time is decoded by its own unmarshaller.
+	body := []byte(`{"id":"w1","attackerID":"a","defenderID":"d","isAttackerVictor":true,"attackTurnsUsed":1,
+		"goldStolen":1,"attackerStrength":1,"defenderStrength":1,"attackerCasualties":0,"defenderCasualties":0,
+		"xpEarned":1,"createdAt":"2025-01-01T00:00:00Z"}`)
+	if drift := findDrift("war-history.get", body, reflect.TypeOf(WarHistory{})); len(drift) != 0 {
+		t.Errorf("unexpected drift: %+v", drift)
+	}
+}
+
+func TestStrictDecodingAndDriftDetector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"p1","name":"A","goldOnHand":5,"level":1,"armySize":2,"units":[],"attackTurns":3}`))
+	}))
+	defer server.Close()
+	api := newTestApi(t, server.URL)
+	api.token = "token"
+
+	detector := NewDriftDetector(nil)
+	api.apiConfig.DriftDetector = detector
+	for i := 0; i < 2; i++ {
+		if _, err := api.FetchPlayerByID("p1"); err != nil {
+			t.Fatalf("non-strict mode must not fail: %v", err)
+		}
+	}
+	report := detector.Report()
+	if len(report) != 2 || report[0].Path != "gold" || report[0].Count != 2 || report[1].Path != "goldOnHand" {
+		t.Errorf("report = %+v", report)
+	}
+
+	api.apiConfig.StrictDecoding = true
+	_, err := api.FetchPlayerByID("p1")
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) || schemaErr.Endpoint != string(epPlayersGet) || len(schemaErr.Drift) != 2 {
+		t.Fatalf("err = %v, want a SchemaError with two drifts", err)
+	}
+	if got, want := err.Error(), "response from players.get does not match its schema: missing field gold, unknown field goldOnHand"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,291 @@
+package DarkThroneApi
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PlayerSnapshot is a Player record as it was fetched at a point in time.
+type PlayerSnapshot struct {
+	Player  Player    `json:"player"`
+	TakenAt time.Time `json:"takenAt"`
+}
+
+// SnapshotStore persists player snapshots. Implementations must be safe for concurrent use.
+type SnapshotStore interface {
+	// SaveSnapshots stores the given snapshots.
+	SaveSnapshots(snapshots ...PlayerSnapshot) error
+	// History returns the snapshots of playerID taken at or after since, oldest first.
+	History(playerID string, since time.Time) ([]PlayerSnapshot, error)
+	// PlayerIDs returns the IDs of every player with at least one snapshot.
+	PlayerIDs() ([]string, error)
+}
+
+// recordSnapshots remembers the fetched players, updates the metrics gauges of the user's own players
+// and saves them to the configured snapshot store, if any. Every method that fetches players passes
+// them through here. Rivals are not exported as metrics, so the number of series stays bounded.
+// Failures are logged rather than returned so that snapshotting never breaks a fetch.
+func (d *DarkThroneApi) recordSnapshots(players ...Player) {
+	d.rememberPlayers(players...)
+	if d.config == nil || len(players) == 0 {
+		return
+	}
+	for _, p := range players {
+		if d.ownsPlayer(p.ID) {
+			d.config.Metrics.SetPlayerState(p)
+		}
+	}
+	if d.config.Snapshots == nil {
+		return
+	}
+	now := time.Now().UTC()
+	snapshots := make([]PlayerSnapshot, 0, len(players))
+	for _, p := range players {
+		if p.ID != "" {
+			snapshots = append(snapshots, PlayerSnapshot{Player: p, TakenAt: now})
+		}
+	}
+	if err := d.config.Snapshots.SaveSnapshots(snapshots...); err != nil && d.config.Logger != nil {
+		d.config.Logger.Warn("Failed to save player snapshots", "error", err)
+	}
+}
+
+// MemorySnapshotStore keeps snapshots in memory.
+type MemorySnapshotStore struct {
+	mu        sync.RWMutex
+	snapshots map[string][]PlayerSnapshot
+}
+
+// NewMemorySnapshotStore creates an empty in-memory snapshot store.
+func NewMemorySnapshotStore() *MemorySnapshotStore {
+	return &MemorySnapshotStore{snapshots: make(map[string][]PlayerSnapshot)}
+}
+
+// SaveSnapshots stores the given snapshots, keeping each player's history ordered by time.
+func (s *MemorySnapshotStore) SaveSnapshots(snapshots ...PlayerSnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, snap := range snapshots {
+		history := s.snapshots[snap.Player.ID]
+		if n := len(history); n == 0 || !snap.TakenAt.Before(history[n-1].TakenAt) {
+			s.snapshots[snap.Player.ID] = append(history, snap)
+			continue
+		}
+		i := sort.Search(len(history), func(i int) bool { return history[i].TakenAt.After(snap.TakenAt) })
+		s.snapshots[snap.Player.ID] = slices.Insert(history, i, snap)
+	}
+	return nil
+}
+
+// History returns the snapshots of playerID taken at or after since, oldest first.
+func (s *MemorySnapshotStore) History(playerID string, since time.Time) ([]PlayerSnapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var result []PlayerSnapshot
+	for _, snap := range s.snapshots[playerID] {
+		if !snap.TakenAt.Before(since) {
+			result = append(result, snap)
+		}
+	}
+	return result, nil
+}
+
+// PlayerIDs returns the IDs of every player with at least one snapshot, sorted.
+func (s *MemorySnapshotStore) PlayerIDs() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := make([]string, 0, len(s.snapshots))
+	for id := range s.snapshots {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// FileSnapshotStore is a snapshot store backed by an append-only NDJSON file.
+// The file is loaded into memory when opened, so queries do not touch the disk.
+type FileSnapshotStore struct {
+	*MemorySnapshotStore
+	mu   sync.Mutex
+	file *os.File
+}
+
+// OpenFileSnapshotStore opens or creates the NDJSON snapshot file at path.
+func OpenFileSnapshotStore(path string) (*FileSnapshotStore, error) {
+	memory := NewMemorySnapshotStore()
+	if f, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		line := 0
+		for scanner.Scan() {
+			line++
+			var snap PlayerSnapshot
+			if err := json.Unmarshal(scanner.Bytes(), &snap); err != nil {
+				f.Close()
+				return nil, fmt.Errorf("invalid snapshot on line %d of %s: %w", line, path, err)
+			}
+			memory.snapshots[snap.Player.ID] = append(memory.snapshots[snap.Player.ID], snap)
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		// Sort each history once rather than on every line.
+		for _, history := range memory.snapshots {
+			sort.SliceStable(history, func(i, j int) bool { return history[i].TakenAt.Before(history[j].TakenAt) })
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSnapshotStore{MemorySnapshotStore: memory, file: file}, nil
+}
+
+// SaveSnapshots appends the snapshots to the file and to the in-memory index.
+func (s *FileSnapshotStore) SaveSnapshots(snapshots ...PlayerSnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, snap := range snapshots {
+		data, err := json.Marshal(snap)
+		if err != nil {
+			return err
+		}
+		if _, err := s.file.Write(append(data, '\n')); err != nil {
+			return err
+		}
+	}
+	return s.MemorySnapshotStore.SaveSnapshots(snapshots...)
+}
+
+// Close closes the underlying file.
+func (s *FileSnapshotStore) Close() error {
+	return s.file.Close()
+}
+
+// FieldChange is a single changed field between two player records.
+type FieldChange struct {
+	Field string
+	Old   any
+	New   any
+}
+
+// PlayerDiff describes how a player changed between two records.
+type PlayerDiff struct {
+	PlayerID string
+	Changes  []FieldChange
+	// UnitDeltas maps unit type to the change in quantity; unchanged unit types are omitted.
+	UnitDeltas map[string]int
+}
+
+// Changed reports whether anything differs.
+func (d PlayerDiff) Changed() bool {
+	return len(d.Changes) > 0 || len(d.UnitDeltas) > 0
+}
+
+// Change returns the change to the named field (e.g. "Gold") and whether it changed.
+func (d PlayerDiff) Change(field string) (FieldChange, bool) {
+	for _, c := range d.Changes {
+		if c.Field == field {
+			return c, true
+		}
+	}
+	return FieldChange{}, false
+}
+
+// Diff reports the fields and unit quantities that differ between a and b.
+// Units are compared by type, so reordering the unit list is not a change.
+func Diff(a, b Player) PlayerDiff {
+	diff := PlayerDiff{PlayerID: b.ID, UnitDeltas: map[string]int{}}
+	if diff.PlayerID == "" {
+		diff.PlayerID = a.ID
+	}
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	for i := 0; i < va.NumField(); i++ {
+		field := va.Type().Field(i)
+		if field.Name == "Units" {
+			continue
+		}
+		if before, after := va.Field(i).Interface(), vb.Field(i).Interface(); !reflect.DeepEqual(before, after) {
+			diff.Changes = append(diff.Changes, FieldChange{Field: field.Name, Old: before, New: after})
+		}
+	}
+	for _, u := range a.Units {
+		diff.UnitDeltas[string(u.UnitType)] -= u.Quantity
+	}
+	for _, u := range b.Units {
+		diff.UnitDeltas[string(u.UnitType)] += u.Quantity
+	}
+	for unitType, delta := range diff.UnitDeltas {
+		if delta == 0 {
+			delete(diff.UnitDeltas, unitType)
+		}
+	}
+	return diff
+}
+
+// PlayerGrowth summarises how a player changed over a window of snapshots.
+type PlayerGrowth struct {
+	PlayerID string
+	From     PlayerSnapshot
+	To       PlayerSnapshot
+	Diff     PlayerDiff
+}
+
+// Elapsed returns the time between the first and last snapshot.
+func (g PlayerGrowth) Elapsed() time.Duration {
+	return g.To.TakenAt.Sub(g.From.TakenAt)
+}
+
+// GrowthOver compares the oldest and newest snapshots of playerID taken within the last window.
+// It returns an error if fewer than two snapshots fall inside the window.
+func GrowthOver(store SnapshotStore, playerID string, window time.Duration) (PlayerGrowth, error) {
+	history, err := store.History(playerID, time.Now().Add(-window))
+	if err != nil {
+		return PlayerGrowth{}, err
+	}
+	if len(history) < 2 {
+		return PlayerGrowth{}, fmt.Errorf("not enough snapshots of player %s in the last %s", playerID, window)
+	}
+	first, last := history[0], history[len(history)-1]
+	return PlayerGrowth{
+		PlayerID: playerID,
+		From:     first,
+		To:       last,
+		Diff:     Diff(first.Player, last.Player),
+	}, nil
+}
+
+// ShrunkArmies returns the players whose army size dropped between their two most recent snapshots,
+// which usually means they were just attacked. Each result compares the previous and latest scan.
+func ShrunkArmies(store SnapshotStore) ([]PlayerGrowth, error) {
+	ids, err := store.PlayerIDs()
+	if err != nil {
+		return nil, err
+	}
+	var result []PlayerGrowth
+	for _, id := range ids {
+		history, err := store.History(id, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		if len(history) < 2 {
+			continue
+		}
+		prev, last := history[len(history)-2], history[len(history)-1]
+		if last.Player.ArmySize < prev.Player.ArmySize {
+			result = append(result, PlayerGrowth{PlayerID: id, From: prev, To: last, Diff: Diff(prev.Player, last.Player)})
+		}
+	}
+	return result, nil
+}
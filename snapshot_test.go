@@ -0,0 +1,118 @@
+package DarkThroneApi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestDiff_FieldsAndUnits(t *testing.T) {
+	a := Player{ID: "p", Gold: 10, Level: 1, ArmySize: 5, Units: []Unit{{"soldier", 5}, {"worker", 2}}}
+	b := Player{ID: "p", Gold: 30, Level: 1, ArmySize: 3, Units: []Unit{{"worker", 2}, {"soldier", 3}, {"guard", 1}}}
+	diff := Diff(a, b)
+	if c, ok := diff.Change("Gold"); !ok || c.Old != 10 || c.New != 30 {
+		t.Errorf("unexpected gold change: %+v", c)
+	}
+	if _, ok := diff.Change("Level"); ok {
+		t.Error("level did not change")
+	}
+	if diff.UnitDeltas["soldier"] != -2 || diff.UnitDeltas["guard"] != 1 || len(diff.UnitDeltas) != 2 {
+		t.Errorf("unexpected unit deltas: %v", diff.UnitDeltas)
+	}
+	if Diff(a, a).Changed() {
+		t.Error("identical players must not differ")
+	}
+}
+
+func TestGrowthOverAndShrunkArmies(t *testing.T) {
+	store := NewMemorySnapshotStore()
+	now := time.Now()
+	store.SaveSnapshots(
+		PlayerSnapshot{Player: Player{ID: "a", Gold: 1, ArmySize: 10}, TakenAt: now.Add(-10 * 24 * time.Hour)},
+		PlayerSnapshot{Player: Player{ID: "a", Gold: 5, ArmySize: 12}, TakenAt: now.Add(-2 * 24 * time.Hour)},
+		PlayerSnapshot{Player: Player{ID: "a", Gold: 9, ArmySize: 8}, TakenAt: now},
+		PlayerSnapshot{Player: Player{ID: "b", ArmySize: 3}, TakenAt: now.Add(-time.Hour)},
+		PlayerSnapshot{Player: Player{ID: "b", ArmySize: 4}, TakenAt: now},
+	)
+	growth, err := GrowthOver(store, "a", 7*24*time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c, _ := growth.Diff.Change("Gold"); c.Old != 5 || c.New != 9 {
+		t.Errorf("unexpected growth: %+v", growth.Diff)
+	}
+	shrunk, err := ShrunkArmies(store)
+	if err != nil || len(shrunk) != 1 || shrunk[0].PlayerID != "a" {
+		t.Errorf("unexpected shrunk armies: %+v %v", shrunk, err)
+	}
+}
+
+func TestFileSnapshotStore_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshots.ndjson")
+	store, err := OpenFileSnapshotStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.SaveSnapshots(PlayerSnapshot{Player: Player{ID: "a", Gold: 1}, TakenAt: time.Now()})
+	store.Close()
+
+	reopened, err := OpenFileSnapshotStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	history, _ := reopened.History("a", time.Time{})
+	if len(history) != 1 || history[0].Player.Gold != 1 {
+		t.Errorf("unexpected history: %+v", history)
+	}
+}
+
+func TestSnapshotStores_KeepOutOfOrderSnapshotsSorted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshots.ndjson")
+	store, err := OpenFileSnapshotStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	for _, offset := range []int{0, 3, 1, 1, 4, 2} {
+		store.SaveSnapshots(PlayerSnapshot{Player: Player{ID: "a", Gold: offset}, TakenAt: now.Add(time.Duration(offset) * time.Hour)})
+	}
+	store.Close()
+	reopened, err := OpenFileSnapshotStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	for name, s := range map[string]SnapshotStore{"memory": store, "reopened": reopened} {
+		history, _ := s.History("a", time.Time{})
+		var golds []int
+		for _, snap := range history {
+			golds = append(golds, snap.Player.Gold)
+		}
+		if len(golds) != 6 || !sort.IntsAreSorted(golds) {
+			t.Errorf("%s: history order = %v", name, golds)
+		}
+	}
+}
+
+func TestFetchPlayerByID_RecordsSnapshot(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Player{ID: "a", ArmySize: 4})
+	}))
+	defer ts.Close()
+
+	d := newTestApi(t, ts.URL)
+	d.token = "tok"
+	store := NewMemorySnapshotStore()
+	d.config.Snapshots = store
+	if _, err := d.FetchPlayerByID("a"); err != nil {
+		t.Fatal(err)
+	}
+	if history, _ := store.History("a", time.Time{}); len(history) != 1 {
+		t.Errorf("expected one snapshot, got %d", len(history))
+	}
+}
@@ -0,0 +1,187 @@
+package DarkThroneApi
+
+import "sync"
+
+// gameState is the client's last known view of the game, used to validate requests locally.
+type gameState struct {
+	mu      sync.Mutex
+	players map[string]Player
+	units   *UnitCatalog
+	// assumed is the ID of the player the client is acting as.
+	assumed string
+	// bank holds the last bank balance reported for each player.
+	bank map[string]int
+	// account is the email of the logged-in user.
+	account string
+	// own holds the IDs of the logged-in user's players, the only ones exported as metrics.
+	own map[string]bool
+}
+
+// rememberPlayers records the latest fetched state of each player.
+func (d *DarkThroneApi) rememberPlayers(players ...Player) {
+	d.state.mu.Lock()
+	defer d.state.mu.Unlock()
+	for _, p := range players {
+		if p.ID == "" {
+			continue
+		}
+		if d.state.players == nil {
+			d.state.players = make(map[string]Player)
+		}
+		d.state.players[p.ID] = p
+	}
+}
+
+// knownPlayer returns the last fetched state of the player with the given ID.
+func (d *DarkThroneApi) knownPlayer(id string) (Player, bool) {
+	d.state.mu.Lock()
+	defer d.state.mu.Unlock()
+	p, ok := d.state.players[id]
+	return p, ok
+}
+
+// rememberAssumed records p as the player the client is acting as.
+func (d *DarkThroneApi) rememberAssumed(p Player) {
+	d.rememberPlayers(p)
+	d.rememberOwn(p)
+	d.state.mu.Lock()
+	d.state.assumed = p.ID
+	d.state.mu.Unlock()
+}
+
+// rememberOwn records players as belonging to the logged-in user.
+func (d *DarkThroneApi) rememberOwn(players ...Player) {
+	d.state.mu.Lock()
+	defer d.state.mu.Unlock()
+	for _, p := range players {
+		if p.ID == "" {
+			continue
+		}
+		if d.state.own == nil {
+			d.state.own = make(map[string]bool)
+		}
+		d.state.own[p.ID] = true
+	}
+}
+
+// ownsPlayer reports whether the player with id belongs to the logged-in user.
+func (d *DarkThroneApi) ownsPlayer(id string) bool {
+	d.state.mu.Lock()
+	defer d.state.mu.Unlock()
+	return d.state.own[id]
+}
+
+// forgetAssumed clears the assumed player after an unassume.
+func (d *DarkThroneApi) forgetAssumed() {
+	d.state.mu.Lock()
+	d.state.assumed = ""
+	d.state.mu.Unlock()
+}
+
+// assumedPlayer returns the last known state of the assumed player.
+func (d *DarkThroneApi) assumedPlayer() (Player, bool) {
+	d.state.mu.Lock()
+	id := d.state.assumed
+	d.state.mu.Unlock()
+	if id == "" {
+		return Player{}, false
+	}
+	return d.knownPlayer(id)
+}
+
+// rememberBankBalance records the bank balance last reported for a player.
+func (d *DarkThroneApi) rememberBankBalance(playerID string, balance int) {
+	d.state.mu.Lock()
+	defer d.state.mu.Unlock()
+	if d.state.bank == nil {
+		d.state.bank = make(map[string]int)
+	}
+	d.state.bank[playerID] = balance
+}
+
+// knownBankBalance returns the bank balance last reported for a player.
+func (d *DarkThroneApi) knownBankBalance(playerID string) (int, bool) {
+	d.state.mu.Lock()
+	defer d.state.mu.Unlock()
+	balance, ok := d.state.bank[playerID]
+	return balance, ok
+}
+
+// The apply functions update the remembered player with the known effect of a successful mutation,
+// so that later requests are validated against current gold and units rather than the last fetch.
+
+// applyTraining spends the order's gold and adds its units to the remembered player, if known.
+func (d *DarkThroneApi) applyTraining(req TrainUnitsRequest) {
+	player, ok := d.knownPlayer(req.PlayerID)
+	if !ok {
+		return
+	}
+	catalog, trusted := d.trustedUnitCatalog()
+	if !trusted && d.dryRun() {
+		// A dry run spends the sample prices its simulated response was priced with.
+		catalog, trusted = DefaultUnitCatalog(), true
+	}
+	if !trusted {
+		// The sample catalog's prices are not the game's, so the remembered gold is no longer known.
+		d.forgetPlayer(req.PlayerID)
+		return
+	}
+	estimate, err := catalog.EstimateTraining(player, req)
+	if err != nil {
+		// The catalog cannot price the order, so the remembered gold can no longer be trusted.
+		d.forgetPlayer(req.PlayerID)
+		return
+	}
+	player.Gold = estimate.GoldAfter
+	player.Units = addUnits(player.Units, req.Units, 1)
+	d.rememberPlayers(player)
+}
+
+// applyUntraining removes the order's units from the remembered player, if known.
+// The unit catalog has no refund prices, so gold is left unchanged.
+func (d *DarkThroneApi) applyUntraining(req UntrainUnitsRequest) {
+	if player, ok := d.knownPlayer(req.PlayerID); ok {
+		player.Units = addUnits(player.Units, req.Units, -1)
+		d.rememberPlayers(player)
+	}
+}
+
+// applyGold adds delta to the gold of the remembered player, if known.
+func (d *DarkThroneApi) applyGold(playerID string, delta int) {
+	if player, ok := d.knownPlayer(playerID); ok {
+		player.Gold += delta
+		d.rememberPlayers(player)
+	}
+}
+
+// forgetPlayer drops the remembered state of a player until it is fetched again.
+func (d *DarkThroneApi) forgetPlayer(id string) {
+	d.state.mu.Lock()
+	defer d.state.mu.Unlock()
+	delete(d.state.players, id)
+}
+
+// addUnits returns units with each order quantity added sign times, dropping types that reach zero.
+func addUnits(units []Unit, order []UnitRequest, sign int) []Unit {
+	result := append([]Unit(nil), units...)
+	for _, o := range order {
+		found := false
+		for i := range result {
+			if result[i].UnitType == o.UnitType {
+				result[i].Quantity += sign * o.Quantity
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, Unit{UnitType: o.UnitType, Quantity: sign * o.Quantity})
+		}
+	}
+	kept := result[:0]
+	for _, u := range result {
+		if u.Quantity > 0 {
+			kept = append(kept, u)
+		}
+	}
+	return kept
+}
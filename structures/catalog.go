@@ -0,0 +1,177 @@
+// Package structures models the Dark Throne structure catalog and plans structure upgrades.
+//
+// Planning is done entirely offline against a Catalog; the resulting steps can then be applied
+// with DarkThroneApi.UpgradeStructure. The bundled DefaultCatalog is sample data for trying the
+// planner out, not the game's real structures.
+package structures
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Effect is the bonus a structure level adds on top of the previous level.
+type Effect struct {
+	Income   int `json:"income"`   // Gold per turn
+	Offense  int `json:"offense"`  // Offense points
+	Defense  int `json:"defense"`  // Defense points
+	Citizens int `json:"citizens"` // Citizens per day
+}
+
+// Add returns the sum of e and o.
+func (e Effect) Add(o Effect) Effect {
+	return Effect{
+		Income:   e.Income + o.Income,
+		Offense:  e.Offense + o.Offense,
+		Defense:  e.Defense + o.Defense,
+		Citizens: e.Citizens + o.Citizens,
+	}
+}
+
+// Requirement is a structure level that must be built before another level.
+type Requirement struct {
+	StructureID string `json:"structureId"`
+	Level       int    `json:"level"`
+}
+
+// Level is one upgrade level of a structure.
+type Level struct {
+	Level    int           `json:"level"`
+	Cost     int           `json:"cost"`
+	Requires []Requirement `json:"requires,omitempty"`
+	Effect   Effect        `json:"effect"`
+}
+
+// Structure is a buildable structure and its levels, in ascending order starting at 1.
+type Structure struct {
+	ID     string  `json:"id"`
+	Name   string  `json:"name"`
+	Levels []Level `json:"levels"`
+}
+
+// Level returns level n of the structure.
+func (s Structure) Level(n int) (Level, bool) {
+	if n < 1 || n > len(s.Levels) {
+		return Level{}, false
+	}
+	return s.Levels[n-1], true
+}
+
+// Catalog is the set of structures that can be built.
+type Catalog struct {
+	Structures []Structure `json:"structures"`
+}
+
+// Structure returns the structure with the given ID.
+func (c *Catalog) Structure(id string) (Structure, bool) {
+	for _, s := range c.Structures {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return Structure{}, false
+}
+
+// Validate checks that structure IDs are unique, levels are numbered from 1, costs are not negative
+// and requirements refer to existing levels without forming cycles.
+func (c *Catalog) Validate() error {
+	seen := make(map[string]bool, len(c.Structures))
+	for _, s := range c.Structures {
+		if s.ID == "" {
+			return fmt.Errorf("structure %q has no ID", s.Name)
+		}
+		if seen[s.ID] {
+			return fmt.Errorf("duplicate structure %q", s.ID)
+		}
+		seen[s.ID] = true
+		for i, l := range s.Levels {
+			if l.Level != i+1 {
+				return fmt.Errorf("structure %q: level %d is numbered %d", s.ID, i+1, l.Level)
+			}
+			if l.Cost < 0 {
+				return fmt.Errorf("structure %q level %d: negative cost", s.ID, l.Level)
+			}
+		}
+	}
+	for _, s := range c.Structures {
+		for _, l := range s.Levels {
+			for _, r := range l.Requires {
+				target, ok := c.Structure(r.StructureID)
+				if !ok {
+					return fmt.Errorf("structure %q level %d requires unknown structure %q", s.ID, l.Level, r.StructureID)
+				}
+				if _, ok := target.Level(r.Level); !ok {
+					return fmt.Errorf("structure %q level %d requires missing level %d of %q", s.ID, l.Level, r.Level, r.StructureID)
+				}
+			}
+		}
+	}
+	for _, s := range c.Structures {
+		if err := c.checkCycle(s.ID, len(s.Levels), map[Requirement]bool{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkCycle walks the requirements of level and every level below it, reporting a cycle if one is reached twice on the same path.
+func (c *Catalog) checkCycle(id string, level int, path map[Requirement]bool) error {
+	key := Requirement{StructureID: id, Level: level}
+	if path[key] {
+		return fmt.Errorf("structure %q level %d requires itself", id, level)
+	}
+	path[key] = true
+	defer delete(path, key)
+	s, _ := c.Structure(id)
+	for n := 1; n <= level; n++ {
+		l, _ := s.Level(n)
+		for _, r := range l.Requires {
+			if err := c.checkCycle(r.StructureID, r.Level, path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// defaultCatalog is sample data: the game has not published its structures, so the IDs, costs,
+// effects and requirements in catalog.json are illustrative.
+//
+//go:embed catalog.json
+var defaultCatalog []byte
+
+// DefaultCatalog returns the sample catalog bundled with the package. Its structures are illustrative,
+// not taken from the game; plan real upgrades against a catalog loaded with LoadCatalog or LoadCatalogFile.
+func DefaultCatalog() *Catalog {
+	c, err := LoadCatalog(bytes.NewReader(defaultCatalog))
+	if err != nil {
+		panic(fmt.Sprintf("structures: bundled catalog is invalid: %v", err))
+	}
+	return c
+}
+
+// LoadCatalog reads and validates a JSON catalog.
+func LoadCatalog(r io.Reader) (*Catalog, error) {
+	var c Catalog
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return nil, fmt.Errorf("decoding structure catalog: %w", err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// LoadCatalogFile reads and validates a JSON catalog file.
+func LoadCatalogFile(path string) (*Catalog, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadCatalog(f)
+}
@@ -0,0 +1,31 @@
+package structures
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultCatalog_Valid(t *testing.T) {
+	c := DefaultCatalog()
+	if len(c.Structures) == 0 {
+		t.Fatal("bundled catalog is empty")
+	}
+	if _, ok := c.Structure("mine"); !ok {
+		t.Error("bundled catalog has no mine")
+	}
+}
+
+func TestLoadCatalog_RejectsInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"duplicate ID":        `{"structures":[{"id":"a","levels":[]},{"id":"a","levels":[]}]}`,
+		"misnumbered level":   `{"structures":[{"id":"a","levels":[{"level":2,"cost":1}]}]}`,
+		"unknown requirement": `{"structures":[{"id":"a","levels":[{"level":1,"requires":[{"structureId":"b","level":1}]}]}]}`,
+		"missing level":       `{"structures":[{"id":"a","levels":[{"level":1}]},{"id":"b","levels":[{"level":1,"requires":[{"structureId":"a","level":2}]}]}]}`,
+		"cycle":               `{"structures":[{"id":"a","levels":[{"level":1,"requires":[{"structureId":"b","level":1}]}]},{"id":"b","levels":[{"level":1,"requires":[{"structureId":"a","level":1}]}]}]}`,
+		"not JSON":            `structures`,
+	} {
+		if _, err := LoadCatalog(strings.NewReader(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package structures
+
+import (
+	"errors"
+	"fmt"
+
+	DarkThroneApi "github.com/Rihoj/DarkThroneApi"
+)
+
+// Goal is what an upgrade plan maximizes.
+type Goal string
+
+const (
+	GoalIncome   Goal = "income"
+	GoalOffense  Goal = "offense"
+	GoalDefense  Goal = "defense"
+	GoalCitizens Goal = "citizens"
+)
+
+// value returns the part of e that counts towards the goal.
+func (g Goal) value(e Effect) int {
+	switch g {
+	case GoalIncome:
+		return e.Income
+	case GoalOffense:
+		return e.Offense
+	case GoalDefense:
+		return e.Defense
+	case GoalCitizens:
+		return e.Citizens
+	}
+	return 0
+}
+
+// State is the starting point of a plan.
+type State struct {
+	Gold          int
+	IncomePerTurn int
+	// Levels holds the current level of each built structure by ID. Missing structures are at level 0.
+	Levels map[string]int
+}
+
+// NewState returns the state of player with the given structure levels and income.
+func NewState(player DarkThroneApi.Player, levels map[string]int, incomePerTurn int) State {
+	return State{Gold: player.Gold, IncomePerTurn: incomePerTurn, Levels: levels}
+}
+
+// Options limits a plan.
+type Options struct {
+	// MaxSteps caps the number of upgrades. Zero means no limit.
+	MaxSteps int
+	// Horizon is the last turn an upgrade may be bought on. Zero means no limit.
+	Horizon int
+}
+
+// Step is one upgrade in a plan.
+type Step struct {
+	StructureID string
+	Level       int
+	Cost        int
+	// Wait is the number of turns spent saving gold before this step; Turn is the turn it is bought on.
+	Wait int
+	Turn int
+	// GoldAfter is the gold left after paying for this step.
+	GoldAfter int
+	Effect    Effect
+}
+
+// Request returns the UpgradeStructure request that performs the step.
+func (s Step) Request() DarkThroneApi.UpgradeStructureRequest {
+	return DarkThroneApi.UpgradeStructureRequest{StructureID: s.StructureID, UpgradeLevel: s.Level}
+}
+
+// Plan is an ordered upgrade schedule.
+type Plan struct {
+	Goal  Goal
+	Steps []Step
+	// Gained is the sum of the effects of every step.
+	Gained Effect
+	// Turns is the turn the last step is bought on.
+	Turns int
+}
+
+// ErrUnknownGoal is returned for goals the planner does not know how to score.
+var ErrUnknownGoal = errors.New("unknown goal")
+
+// Plan returns an upgrade schedule for goal starting from state.
+//
+// The planner is greedy: it repeatedly picks the next level of a structure, together with any
+// prerequisites it still needs, that adds the most goal value per gold, and buys it as soon as the
+// gold on hand plus income allows. Income gained from earlier steps is counted towards later ones.
+// Planning stops when no upgrade adds goal value, the options' limits are reached, or the next
+// upgrade can never be afforded.
+func (c *Catalog) Plan(state State, goal Goal, opts Options) (Plan, error) {
+	if goal.value(Effect{Income: 1, Offense: 1, Defense: 1, Citizens: 1}) == 0 {
+		return Plan{}, fmt.Errorf("%w %q", ErrUnknownGoal, goal)
+	}
+	levels := make(map[string]int, len(state.Levels))
+	for id, level := range state.Levels {
+		if _, ok := c.Structure(id); !ok {
+			return Plan{}, fmt.Errorf("unknown structure %q in state", id)
+		}
+		levels[id] = level
+	}
+	plan := Plan{Goal: goal}
+	gold, income, turn := state.Gold, state.IncomePerTurn, 0
+	for opts.MaxSteps == 0 || len(plan.Steps) < opts.MaxSteps {
+		chain := c.bestChain(levels, goal)
+		if chain == nil {
+			break
+		}
+		for _, step := range chain {
+			if opts.MaxSteps > 0 && len(plan.Steps) >= opts.MaxSteps {
+				return plan, nil
+			}
+			if gold < step.Cost {
+				if income <= 0 {
+					return plan, nil
+				}
+				step.Wait = (step.Cost - gold + income - 1) / income
+			}
+			if opts.Horizon > 0 && turn+step.Wait > opts.Horizon {
+				return plan, nil
+			}
+			turn += step.Wait
+			gold += step.Wait*income - step.Cost
+			income += step.Effect.Income
+			step.Turn = turn
+			step.GoldAfter = gold
+			levels[step.StructureID] = step.Level
+			plan.Steps = append(plan.Steps, step)
+			plan.Gained = plan.Gained.Add(step.Effect)
+			plan.Turns = turn
+		}
+	}
+	return plan, nil
+}
+
+// bestChain returns the prerequisites and next level of the structure whose upgrade adds the most
+// goal value per gold, or nil if no upgrade adds any.
+func (c *Catalog) bestChain(levels map[string]int, goal Goal) []Step {
+	var best []Step
+	var bestValue, bestCost int
+	for _, s := range c.Structures {
+		next := levels[s.ID] + 1
+		if _, ok := s.Level(next); !ok {
+			continue
+		}
+		chain := c.chain(levels, s.ID, next, map[Requirement]bool{})
+		value, cost := 0, 0
+		for _, step := range chain {
+			value += goal.value(step.Effect)
+			cost += step.Cost
+		}
+		if value <= 0 {
+			continue
+		}
+		// value/cost > bestValue/bestCost, compared without division so free upgrades win.
+		if best == nil || value*bestCost > bestValue*cost {
+			best, bestValue, bestCost = chain, value, cost
+		}
+	}
+	return best
+}
+
+// chain returns the steps needed to bring structure id to level, prerequisites first.
+// planned tracks levels already in the chain so shared prerequisites are only added once.
+func (c *Catalog) chain(levels map[string]int, id string, level int, planned map[Requirement]bool) []Step {
+	s, _ := c.Structure(id)
+	var steps []Step
+	for n := levels[id] + 1; n <= level; n++ {
+		key := Requirement{StructureID: id, Level: n}
+		if planned[key] {
+			continue
+		}
+		l, _ := s.Level(n)
+		for _, r := range l.Requires {
+			if levels[r.StructureID] < r.Level {
+				steps = append(steps, c.chain(levels, r.StructureID, r.Level, planned)...)
+			}
+		}
+		planned[key] = true
+		steps = append(steps, Step{StructureID: id, Level: n, Cost: l.Cost, Effect: l.Effect})
+	}
+	return steps
+}
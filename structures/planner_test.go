@@ -0,0 +1,90 @@
+package structures
+
+import (
+	"errors"
+	"testing"
+
+	DarkThroneApi "github.com/Rihoj/DarkThroneApi"
+)
+
+var testCatalog = &Catalog{Structures: []Structure{
+	{ID: "mine", Levels: []Level{
+		{Level: 1, Cost: 100, Effect: Effect{Income: 10}},
+		{Level: 2, Cost: 300, Requires: []Requirement{{StructureID: "housing", Level: 1}}, Effect: Effect{Income: 50}},
+	}},
+	{ID: "housing", Levels: []Level{
+		{Level: 1, Cost: 50, Effect: Effect{Citizens: 5}},
+	}},
+	{ID: "wall", Levels: []Level{
+		{Level: 1, Cost: 200, Effect: Effect{Defense: 100}},
+		{Level: 2, Cost: 400, Effect: Effect{Defense: 100}},
+	}},
+}}
+
+func TestPlan_IncomeWithPrerequisitesAndTiming(t *testing.T) {
+	plan, err := testCatalog.Plan(State{Gold: 100, IncomePerTurn: 10}, GoalIncome, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// mine 1 (10 per 100 gold) first, then housing 1 as the prerequisite of mine 2 (50 per 350 gold).
+	want := []Step{
+		{StructureID: "mine", Level: 1, Cost: 100, Wait: 0, Turn: 0, GoldAfter: 0, Effect: Effect{Income: 10}},
+		{StructureID: "housing", Level: 1, Cost: 50, Wait: 3, Turn: 3, GoldAfter: 10, Effect: Effect{Citizens: 5}},
+		{StructureID: "mine", Level: 2, Cost: 300, Wait: 15, Turn: 18, GoldAfter: 10, Effect: Effect{Income: 50}},
+	}
+	if len(plan.Steps) != len(want) {
+		t.Fatalf("steps = %+v, want %+v", plan.Steps, want)
+	}
+	for i := range want {
+		if plan.Steps[i] != want[i] {
+			t.Errorf("step %d = %+v, want %+v", i, plan.Steps[i], want[i])
+		}
+	}
+	if plan.Turns != 18 || plan.Gained.Income != 60 {
+		t.Errorf("plan = %+v", plan)
+	}
+	if req := plan.Steps[2].Request(); req != (DarkThroneApi.UpgradeStructureRequest{StructureID: "mine", UpgradeLevel: 2}) {
+		t.Errorf("Request() = %+v", req)
+	}
+}
+
+func TestPlan_DefenseSkipsUselessUpgrades(t *testing.T) {
+	plan, err := testCatalog.Plan(State{Gold: 1000, Levels: map[string]int{"wall": 1}}, GoalDefense, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Steps) != 1 || plan.Steps[0].StructureID != "wall" || plan.Steps[0].Level != 2 {
+		t.Errorf("steps = %+v, want only wall 2", plan.Steps)
+	}
+}
+
+func TestPlan_Limits(t *testing.T) {
+	plan, _ := testCatalog.Plan(State{Gold: 100, IncomePerTurn: 10}, GoalIncome, Options{MaxSteps: 2})
+	if len(plan.Steps) != 2 {
+		t.Errorf("MaxSteps: got %d steps", len(plan.Steps))
+	}
+	plan, _ = testCatalog.Plan(State{Gold: 100, IncomePerTurn: 10}, GoalIncome, Options{Horizon: 10})
+	if len(plan.Steps) != 2 || plan.Turns > 10 {
+		t.Errorf("Horizon: got %+v", plan)
+	}
+	plan, _ = testCatalog.Plan(State{Gold: 50}, GoalDefense, Options{})
+	if len(plan.Steps) != 0 {
+		t.Errorf("no income: expected no affordable steps, got %+v", plan.Steps)
+	}
+}
+
+func TestPlan_Errors(t *testing.T) {
+	if _, err := testCatalog.Plan(State{}, Goal("fame"), Options{}); !errors.Is(err, ErrUnknownGoal) {
+		t.Errorf("err = %v, want ErrUnknownGoal", err)
+	}
+	if _, err := testCatalog.Plan(State{Levels: map[string]int{"castle": 1}}, GoalIncome, Options{}); err == nil {
+		t.Error("expected an error for an unknown structure")
+	}
+}
+
+func TestNewState(t *testing.T) {
+	s := NewState(DarkThroneApi.Player{Gold: 500}, map[string]int{"mine": 1}, 20)
+	if s.Gold != 500 || s.IncomePerTurn != 20 || s.Levels["mine"] != 1 {
+		t.Errorf("state = %+v", s)
+	}
+}
@@ -31,5 +31,3 @@ func TestProficiencyPointsResponse_Fields(t *testing.T) {
 		t.Error("unexpected field values")
 	}
 }
-
-// Add more tests for UpgradeStructure and SpendProficiencyPoints as API integration or with mocks.
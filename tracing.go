@@ -0,0 +1,85 @@
+package DarkThroneApi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// tracerName is the instrumentation scope of the client's spans.
+const tracerName = "github.com/Rihoj/DarkThroneApi"
+
+// traceContext propagates W3C trace-context headers on outgoing requests.
+var traceContext = propagation.TraceContext{}
+
+type retryAttemptKey struct{}
+
+// WithRetryAttempt marks requests made with ctx as the given retry attempt (1 for the first retry).
+// The client does not retry on its own; callers that do should set this so spans record the attempt.
+func WithRetryAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, retryAttemptKey{}, attempt)
+}
+
+// retryAttempt returns the attempt set by WithRetryAttempt, or 0.
+func retryAttempt(ctx context.Context) int {
+	attempt, _ := ctx.Value(retryAttemptKey{}).(int)
+	return attempt
+}
+
+// tracer returns the configured tracer, or a no-op tracer when tracing is disabled.
+func (c *ApiRequestConfig) tracer() trace.Tracer {
+	if c == nil || c.TracerProvider == nil {
+		return noop.NewTracerProvider().Tracer(tracerName)
+	}
+	return c.TracerProvider.Tracer(tracerName)
+}
+
+// startSpan starts an internal span for a client operation that makes one or more requests.
+func (d *DarkThroneApi) startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return d.apiConfig.tracer().Start(ctx, "DarkThroneApi."+name)
+}
+
+// endSpan records err on span, if any, and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// startRequestSpan starts the client span for a single API request.
+func (req *ApiRequest[Req, Resp]) startRequestSpan(ctx context.Context) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("darkthrone.endpoint", req.metricsEndpoint()),
+		attribute.String("http.request.method", req.Method),
+	}
+	if attempt := retryAttempt(ctx); attempt > 0 {
+		attrs = append(attrs, attribute.Int("http.request.resend_count", attempt))
+	}
+	return req.Config.tracer().Start(ctx, req.Method+" "+req.metricsEndpoint(),
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// endRequestSpan records the outcome of a request on its span and ends it.
+func endRequestSpan(span trace.Span, err error) {
+	var statusErr *StatusError
+	switch {
+	case err == nil:
+		span.SetAttributes(attribute.Int("http.response.status_code", http.StatusOK))
+	case errors.As(err, &statusErr):
+		span.SetAttributes(attribute.Int("http.response.status_code", statusErr.StatusCode))
+	}
+	endSpan(span, err)
+}
+
+// injectTraceContext adds W3C trace-context headers for the span in ctx to h.
+func injectTraceContext(ctx context.Context, h http.Header) {
+	traceContext.Inject(ctx, propagation.HeaderCarrier(h))
+}
@@ -0,0 +1,128 @@
+package DarkThroneApi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTracedTestApi(t *testing.T, handler http.HandlerFunc) (*DarkThroneApi, *tracetest.InMemoryExporter) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+	api := newTestApi(t, server.URL)
+	api.token = "token"
+	api.apiConfig.TracerProvider = provider
+	return api, exporter
+}
+
+func spanAttr(span tracetest.SpanStub, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestTracing_GetPlayerByIndexHasParentSpan(t *testing.T) {
+	var mu sync.Mutex
+	var traceparents []string
+	api, exporter := newTracedTestApi(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		traceparents = append(traceparents, r.Header.Get("traceparent"))
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/auth/current-user/players":
+			w.Write([]byte(`[{"id":"p1"}]`))
+		default:
+			w.Write([]byte(`{"player":{"id":"p1","name":"Alpha"}}`))
+		}
+	})
+
+	if _, err := api.GetPlayerByIndex(0); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3: %+v", len(spans), spans)
+	}
+	parent := spans[2]
+	if parent.Name != "DarkThroneApi.GetPlayerByIndex" {
+		t.Fatalf("last span = %q, want the GetPlayerByIndex parent", parent.Name)
+	}
+	for _, child := range spans[:2] {
+		if child.Parent.SpanID() != parent.SpanContext.SpanID() {
+			t.Errorf("span %q is not a child of GetPlayerByIndex", child.Name)
+		}
+		if child.SpanKind != trace.SpanKindClient {
+			t.Errorf("span %q kind = %v", child.Name, child.SpanKind)
+		}
+		if v, ok := spanAttr(child, "http.response.status_code"); !ok || v.AsInt64() != 200 {
+			t.Errorf("span %q status attribute = %v", child.Name, v)
+		}
+	}
+	if v, _ := spanAttr(spans[1], "darkthrone.endpoint"); v.AsString() != string(epAssumePlayer) {
+		t.Errorf("endpoint attribute = %q", v.AsString())
+	}
+
+	for i, tp := range traceparents {
+		want := "00-" + parent.SpanContext.TraceID().String() + "-" + spans[i].SpanContext.SpanID().String() + "-01"
+		if tp != want {
+			t.Errorf("request %d traceparent = %q, want %q", i, tp, want)
+		}
+	}
+}
+
+func TestTracing_RecordsErrorStatusAndRetryAttempt(t *testing.T) {
+	api, exporter := newTracedTestApi(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	ctx := WithRetryAttempt(context.Background(), 2)
+	if _, err := callContext[struct{}, Player](ctx, api, epPlayersGet, pathParams{"id": "p1"}, nil, struct{}{}); err == nil {
+		t.Fatal("expected an error")
+	}
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "GET players.get" || span.Status.Code.String() != "Error" {
+		t.Errorf("span = %q with status %v", span.Name, span.Status)
+	}
+	if v, _ := spanAttr(span, "http.response.status_code"); v.AsInt64() != http.StatusBadGateway {
+		t.Errorf("status attribute = %v", v.AsInt64())
+	}
+	if v, _ := spanAttr(span, "http.request.resend_count"); v.AsInt64() != 2 {
+		t.Errorf("resend_count attribute = %v", v.AsInt64())
+	}
+}
+
+func TestTracing_DisabledSendsNoTraceparent(t *testing.T) {
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.Write([]byte(`{"id":"p1"}`))
+	}))
+	defer server.Close()
+	api := newTestApi(t, server.URL)
+	api.token = "token"
+	if _, err := api.FetchPlayerByID("p1"); err != nil {
+		t.Fatal(err)
+	}
+	if traceparent != "" {
+		t.Errorf("traceparent = %q, want none without a TracerProvider", traceparent)
+	}
+}
@@ -0,0 +1,284 @@
+package DarkThroneApi
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// UnitType identifies a kind of unit, such as "worker" or "soldier".
+type UnitType string
+
+// UnitStats are the offense, defense and gold income a unit or army contributes.
+type UnitStats struct {
+	Offense int `json:"offense"`
+	Defense int `json:"defense"`
+	Income  int `json:"income"` // Gold per turn
+}
+
+// Add returns the sum of s and o.
+func (s UnitStats) Add(o UnitStats) UnitStats {
+	return UnitStats{Offense: s.Offense + o.Offense, Defense: s.Defense + o.Defense, Income: s.Income + o.Income}
+}
+
+// Scale returns s multiplied by n.
+func (s UnitStats) Scale(n int) UnitStats {
+	return UnitStats{Offense: s.Offense * n, Defense: s.Defense * n, Income: s.Income * n}
+}
+
+// UnitDefinition describes one unit type of the catalog.
+type UnitDefinition struct {
+	Type  UnitType  `json:"type"`
+	Name  string    `json:"name"`
+	Cost  int       `json:"cost"` // Gold per unit
+	Stats UnitStats `json:"stats"`
+	// Races lists the races that can train the unit. Empty means every race.
+	Races []string `json:"races,omitempty"`
+}
+
+// AvailableTo reports whether a player of race can train the unit. An unknown race is always allowed.
+func (u UnitDefinition) AvailableTo(race string) bool {
+	return race == "" || len(u.Races) == 0 || slices.Contains(u.Races, race)
+}
+
+// UnitCatalog lists the unit types that can be trained.
+type UnitCatalog struct {
+	Units []UnitDefinition `json:"units"`
+}
+
+// Unit returns the definition of unitType.
+func (c *UnitCatalog) Unit(unitType UnitType) (UnitDefinition, bool) {
+	for _, u := range c.Units {
+		if u.Type == unitType {
+			return u, true
+		}
+	}
+	return UnitDefinition{}, false
+}
+
+// Validate checks that unit types are set and unique and that costs are not negative.
+func (c *UnitCatalog) Validate() error {
+	seen := make(map[UnitType]bool, len(c.Units))
+	for _, u := range c.Units {
+		if u.Type == "" {
+			return fmt.Errorf("unit %q has no type", u.Name)
+		}
+		if seen[u.Type] {
+			return fmt.Errorf("duplicate unit type %q", u.Type)
+		}
+		seen[u.Type] = true
+		if u.Cost < 0 {
+			return fmt.Errorf("unit %q has a negative cost", u.Type)
+		}
+	}
+	return nil
+}
+
+// ArmyStats returns the combined stats of units. Unit types missing from the catalog count for nothing.
+func (c *UnitCatalog) ArmyStats(units []Unit) UnitStats {
+	var stats UnitStats
+	for _, u := range units {
+		if def, ok := c.Unit(u.UnitType); ok {
+			stats = stats.Add(def.Stats.Scale(u.Quantity))
+		}
+	}
+	return stats
+}
+
+// defaultUnitCatalog is sample data: the game has not published its units, so the types, costs,
+// stats and races in units.json are illustrative and only serve estimates until a real catalog is set.
+//
+//go:embed units.json
+var defaultUnitCatalog []byte
+
+// DefaultUnitCatalog returns the sample unit catalog bundled with the client. Its units are
+// illustrative, not taken from the game, so requests are never rejected because of it.
+func DefaultUnitCatalog() *UnitCatalog {
+	c, err := LoadUnitCatalog(bytes.NewReader(defaultUnitCatalog))
+	if err != nil {
+		panic(fmt.Sprintf("bundled unit catalog is invalid: %v", err))
+	}
+	return c
+}
+
+// LoadUnitCatalog reads and validates a JSON unit catalog.
+func LoadUnitCatalog(r io.Reader) (*UnitCatalog, error) {
+	var c UnitCatalog
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return nil, fmt.Errorf("decoding unit catalog: %w", err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// UnitCatalog returns the unit catalog last fetched by RefreshUnitCatalog, the one set in Config,
+// or the bundled sample catalog, in that order.
+func (d *DarkThroneApi) UnitCatalog() *UnitCatalog {
+	if catalog, ok := d.trustedUnitCatalog(); ok {
+		return catalog
+	}
+	return DefaultUnitCatalog()
+}
+
+// trustedUnitCatalog returns the catalog fetched from the server or set in Config, if any.
+// Only a trusted catalog is used to reject requests or to update the remembered player's gold.
+func (d *DarkThroneApi) trustedUnitCatalog() (*UnitCatalog, bool) {
+	d.state.mu.Lock()
+	units := d.state.units
+	d.state.mu.Unlock()
+	if units != nil {
+		return units, true
+	}
+	if d.config != nil && d.config.UnitCatalog != nil {
+		return d.config.UnitCatalog, true
+	}
+	return nil, false
+}
+
+// RefreshUnitCatalog fetches the unit catalog from the server and uses it for later estimates and validation.
+// On failure the previous catalog stays in use.
+func (d *DarkThroneApi) RefreshUnitCatalog(ctx context.Context) (*UnitCatalog, error) {
+	catalog, err := d.unitCatalogAPI(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := catalog.Validate(); err != nil {
+		return nil, err
+	}
+	d.state.mu.Lock()
+	d.state.units = &catalog
+	d.state.mu.Unlock()
+	return &catalog, nil
+}
+
+var (
+	// ErrUnknownUnitType is returned for unit types missing from the unit catalog.
+	ErrUnknownUnitType = errors.New("unknown unit type")
+	// ErrInsufficientGold is returned when an order costs more gold than the player has.
+	ErrInsufficientGold = errors.New("insufficient gold")
+	// ErrInsufficientUnits is returned when untraining more units than the player has.
+	ErrInsufficientUnits = errors.New("insufficient units")
+)
+
+// TrainingEstimate is the cost and effect of a training order.
+type TrainingEstimate struct {
+	Cost  int
+	Units int // Units trained; each takes one citizen
+	// Added is the stats the trained units contribute.
+	Added UnitStats
+	// Before and After are the player's army stats and gold around the order.
+	Before, After UnitStats
+	GoldBefore    int
+	GoldAfter     int
+}
+
+// Affordable reports whether the player has enough gold for the order.
+func (e TrainingEstimate) Affordable() bool {
+	return e.GoldAfter >= 0
+}
+
+// EstimateTraining returns the cost of req and the player's stats before and after it.
+// It returns ErrUnknownUnitType for unit types missing from the catalog.
+func (c *UnitCatalog) EstimateTraining(player Player, req TrainUnitsRequest) (TrainingEstimate, error) {
+	estimate := TrainingEstimate{Before: c.ArmyStats(player.Units), GoldBefore: player.Gold}
+	if err := c.checkUnits(req.Units); err != nil {
+		return TrainingEstimate{}, err
+	}
+	for _, u := range req.Units {
+		def, _ := c.Unit(u.UnitType)
+		estimate.Cost += def.Cost * u.Quantity
+		estimate.Units += u.Quantity
+		estimate.Added = estimate.Added.Add(def.Stats.Scale(u.Quantity))
+	}
+	estimate.After = estimate.Before.Add(estimate.Added)
+	estimate.GoldAfter = estimate.GoldBefore - estimate.Cost
+	return estimate, nil
+}
+
+// checkUnits checks that every unit type exists and every quantity is positive.
+func (c *UnitCatalog) checkUnits(units []UnitRequest) error {
+	for _, u := range units {
+		if _, ok := c.Unit(u.UnitType); !ok {
+			return fmt.Errorf("%w %q", ErrUnknownUnitType, u.UnitType)
+		}
+		if u.Quantity <= 0 {
+			return fmt.Errorf("quantity of %s must be positive, got %d", u.UnitType, u.Quantity)
+		}
+	}
+	return nil
+}
+
+// ValidateTraining checks req against the catalog: unit types must exist and be available to the
+// player's race, quantities must be positive and the player must afford the order.
+func (c *UnitCatalog) ValidateTraining(player Player, req TrainUnitsRequest) error {
+	estimate, err := c.EstimateTraining(player, req)
+	if err != nil {
+		return err
+	}
+	for _, u := range req.Units {
+		if def, _ := c.Unit(u.UnitType); !def.AvailableTo(player.Race) {
+			return fmt.Errorf("%s cannot be trained by %s players", u.UnitType, player.Race)
+		}
+	}
+	if !estimate.Affordable() {
+		return fmt.Errorf("%w: order costs %d, player has %d", ErrInsufficientGold, estimate.Cost, player.Gold)
+	}
+	return nil
+}
+
+// ValidateUntraining checks that every unit type in req exists and the player has enough of each.
+func (c *UnitCatalog) ValidateUntraining(player Player, req UntrainUnitsRequest) error {
+	owned := make(map[UnitType]int, len(player.Units))
+	for _, u := range player.Units {
+		owned[u.UnitType] += u.Quantity
+	}
+	if err := c.checkUnits(req.Units); err != nil {
+		return err
+	}
+	for _, u := range req.Units {
+		if u.Quantity > owned[u.UnitType] {
+			return fmt.Errorf("%w: untraining %d %s, player has %d", ErrInsufficientUnits, u.Quantity, u.UnitType, owned[u.UnitType])
+		}
+	}
+	return nil
+}
+
+// EstimateTraining estimates req against the current unit catalog and the last known state of the player.
+// If the player has not been fetched yet, the estimate starts from no army and no gold.
+func (d *DarkThroneApi) EstimateTraining(req TrainUnitsRequest) (TrainingEstimate, error) {
+	player, _ := d.knownPlayer(req.PlayerID)
+	return d.UnitCatalog().EstimateTraining(player, req)
+}
+
+// validateTraining checks req against a trusted unit catalog, and against the player's gold and race if the player has been fetched.
+// Without a trusted catalog nothing is checked locally.
+func (d *DarkThroneApi) validateTraining(req TrainUnitsRequest) error {
+	catalog, ok := d.trustedUnitCatalog()
+	if !ok {
+		return nil
+	}
+	if player, ok := d.knownPlayer(req.PlayerID); ok {
+		return catalog.ValidateTraining(player, req)
+	}
+	return catalog.checkUnits(req.Units)
+}
+
+// validateUntraining checks req against a trusted unit catalog, and against the player's army if the player has been fetched.
+// Without a trusted catalog nothing is checked locally.
+func (d *DarkThroneApi) validateUntraining(req UntrainUnitsRequest) error {
+	catalog, ok := d.trustedUnitCatalog()
+	if !ok {
+		return nil
+	}
+	if player, ok := d.knownPlayer(req.PlayerID); ok {
+		return catalog.ValidateUntraining(player, req)
+	}
+	return catalog.checkUnits(req.Units)
+}
@@ -0,0 +1,215 @@
+package DarkThroneApi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+var testUnitCatalog = &UnitCatalog{Units: []UnitDefinition{
+	{Type: "worker", Cost: 100, Stats: UnitStats{Income: 5}},
+	{Type: "soldier", Cost: 200, Stats: UnitStats{Offense: 3}},
+	{Type: "knight", Cost: 500, Stats: UnitStats{Offense: 10, Defense: 2}, Races: []string{"human"}},
+}}
+
+func TestDefaultUnitCatalog_Valid(t *testing.T) {
+	c := DefaultUnitCatalog()
+	for _, unitType := range []UnitType{"worker", "soldier", "guard"} {
+		if _, ok := c.Unit(unitType); !ok {
+			t.Errorf("bundled catalog has no %s", unitType)
+		}
+	}
+}
+
+func TestLoadUnitCatalog_RejectsInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"duplicate":     `{"units":[{"type":"a"},{"type":"a"}]}`,
+		"missing type":  `{"units":[{"name":"A"}]}`,
+		"negative cost": `{"units":[{"type":"a","cost":-1}]}`,
+		"not JSON":      `units`,
+	} {
+		if _, err := LoadUnitCatalog(strings.NewReader(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestEstimateTraining(t *testing.T) {
+	player := Player{Gold: 1000, Units: []Unit{{UnitType: "worker", Quantity: 2}, {UnitType: "unknown", Quantity: 9}}}
+	estimate, err := testUnitCatalog.EstimateTraining(player, TrainUnitsRequest{Units: []UnitRequest{
+		{UnitType: "worker", Quantity: 1}, {UnitType: "soldier", Quantity: 3},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := TrainingEstimate{
+		Cost: 700, Units: 4,
+		Added:      UnitStats{Offense: 9, Income: 5},
+		Before:     UnitStats{Income: 10},
+		After:      UnitStats{Offense: 9, Income: 15},
+		GoldBefore: 1000, GoldAfter: 300,
+	}
+	if estimate != want {
+		t.Errorf("estimate = %+v, want %+v", estimate, want)
+	}
+	if !estimate.Affordable() {
+		t.Error("estimate should be affordable")
+	}
+	if _, err := testUnitCatalog.EstimateTraining(player, TrainUnitsRequest{Units: []UnitRequest{{UnitType: "dragon", Quantity: 1}}}); !errors.Is(err, ErrUnknownUnitType) {
+		t.Errorf("err = %v, want ErrUnknownUnitType", err)
+	}
+	if _, err := testUnitCatalog.EstimateTraining(player, TrainUnitsRequest{Units: []UnitRequest{{UnitType: "worker", Quantity: 0}}}); err == nil {
+		t.Error("expected an error for a zero quantity")
+	}
+}
+
+func TestValidateTraining(t *testing.T) {
+	order := TrainUnitsRequest{Units: []UnitRequest{{UnitType: "knight", Quantity: 2}}}
+	if err := testUnitCatalog.ValidateTraining(Player{Gold: 1000, Race: "human"}, order); err != nil {
+		t.Errorf("affordable order: %v", err)
+	}
+	if err := testUnitCatalog.ValidateTraining(Player{Gold: 999, Race: "human"}, order); !errors.Is(err, ErrInsufficientGold) {
+		t.Errorf("err = %v, want ErrInsufficientGold", err)
+	}
+	if err := testUnitCatalog.ValidateTraining(Player{Gold: 1000, Race: "elf"}, order); err == nil {
+		t.Error("expected an error for a race-restricted unit")
+	}
+}
+
+func TestValidateUntraining(t *testing.T) {
+	player := Player{Units: []Unit{{UnitType: "soldier", Quantity: 3}}}
+	if err := testUnitCatalog.ValidateUntraining(player, UntrainUnitsRequest{Units: []UnitRequest{{UnitType: "soldier", Quantity: 3}}}); err != nil {
+		t.Error(err)
+	}
+	if err := testUnitCatalog.ValidateUntraining(player, UntrainUnitsRequest{Units: []UnitRequest{{UnitType: "soldier", Quantity: 4}}}); !errors.Is(err, ErrInsufficientUnits) {
+		t.Errorf("err = %v, want ErrInsufficientUnits", err)
+	}
+}
+
+func TestTrainUnits_ValidatesBeforeSending(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"success":true}`))
+	}))
+	defer server.Close()
+	api := newTestApi(t, server.URL)
+	api.token = "t"
+	api.config.UnitCatalog = testUnitCatalog
+
+	if _, err := api.TrainUnits(TrainUnitsRequest{PlayerID: "p1", Units: []UnitRequest{{UnitType: "dragon", Quantity: 1}}}); !errors.Is(err, ErrUnknownUnitType) {
+		t.Errorf("err = %v, want ErrUnknownUnitType", err)
+	}
+	// Gold is only checked once the player has been fetched.
+	order := TrainUnitsRequest{PlayerID: "p1", Units: []UnitRequest{{UnitType: "soldier", Quantity: 2}}}
+	if _, err := api.TrainUnits(order); err != nil {
+		t.Fatal(err)
+	}
+	api.rememberPlayers(Player{ID: "p1", Gold: 300})
+	if _, err := api.TrainUnits(order); !errors.Is(err, ErrInsufficientGold) {
+		t.Errorf("err = %v, want ErrInsufficientGold", err)
+	}
+	if _, err := api.UntrainUnits(UntrainUnitsRequest{PlayerID: "p1", Units: []UnitRequest{{UnitType: "soldier", Quantity: 1}}}); !errors.Is(err, ErrInsufficientUnits) {
+		t.Errorf("err = %v, want ErrInsufficientUnits", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("server calls = %d, want only the valid order", n)
+	}
+
+	estimate, err := api.EstimateTraining(order)
+	if err != nil || estimate.Cost != 400 || estimate.GoldAfter != -100 {
+		t.Errorf("EstimateTraining = %+v, %v", estimate, err)
+	}
+}
+
+func TestTrainUnits_SampleCatalogNeverRejects(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"success":true}`))
+	}))
+	defer server.Close()
+	api := newTestApi(t, server.URL)
+	api.token = "t"
+	api.rememberPlayers(Player{ID: "p1", Gold: 0})
+
+	if _, err := api.TrainUnits(TrainUnitsRequest{PlayerID: "p1", Units: []UnitRequest{{UnitType: "dragon", Quantity: 1}}}); err != nil {
+		t.Errorf("TrainUnits = %v, want the order sent", err)
+	}
+	if _, err := api.UntrainUnits(UntrainUnitsRequest{PlayerID: "p1", Units: []UnitRequest{{UnitType: "dragon", Quantity: 1}}}); err != nil {
+		t.Errorf("UntrainUnits = %v, want the order sent", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("server calls = %d, want 2", n)
+	}
+	if _, ok := api.knownPlayer("p1"); ok {
+		t.Error("gold spent at sample prices should not be remembered")
+	}
+}
+
+func TestMutations_UpdateRememberedPlayer(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"success":true,"balance":50}`))
+	}))
+	defer server.Close()
+	api := newTestApi(t, server.URL)
+	api.token = "t"
+	api.config.UnitCatalog = testUnitCatalog
+	api.rememberPlayers(Player{ID: "p1", Gold: 500})
+
+	order := TrainUnitsRequest{PlayerID: "p1", Units: []UnitRequest{{UnitType: "soldier", Quantity: 2}}}
+	if _, err := api.TrainUnits(order); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.UntrainUnits(UntrainUnitsRequest{PlayerID: "p1", Units: []UnitRequest{{UnitType: "soldier", Quantity: 1}}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.DepositGold(BankDepositRequest{PlayerID: "p1", Amount: 50}); err != nil {
+		t.Fatal(err)
+	}
+	player, _ := api.knownPlayer("p1")
+	if player.Gold != 50 || len(player.Units) != 1 || player.Units[0].Quantity != 1 {
+		t.Errorf("player = %+v, want 50 gold and one soldier", player)
+	}
+	// The second order is checked against the gold left after the first.
+	if _, err := api.TrainUnits(order); !errors.Is(err, ErrInsufficientGold) {
+		t.Errorf("err = %v, want ErrInsufficientGold", err)
+	}
+	if _, err := api.WithdrawGold(BankWithdrawRequest{PlayerID: "p1", Amount: 50}); err != nil {
+		t.Fatal(err)
+	}
+	if player, _ := api.knownPlayer("p1"); player.Gold != 100 {
+		t.Errorf("gold = %d, want 100", player.Gold)
+	}
+	if n := calls.Load(); n != 4 {
+		t.Errorf("server calls = %d, want 4", n)
+	}
+}
+
+func TestRefreshUnitCatalog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/units" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"units":[{"type":"dragon","name":"Dragon","cost":9000,"stats":{"offense":99,"defense":0,"income":0}}]}`))
+	}))
+	defer server.Close()
+	api := newTestApi(t, server.URL)
+	api.token = "t"
+
+	if _, ok := api.UnitCatalog().Unit("worker"); !ok {
+		t.Error("the bundled catalog should be used before a refresh")
+	}
+	if _, err := api.RefreshUnitCatalog(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := api.UnitCatalog().Unit("dragon"); !ok {
+		t.Error("the refreshed catalog is not in use")
+	}
+}
@@ -1,35 +1,11 @@
 package DarkThroneApi
 
 import (
+	"context"
 	"fmt"
+	"time"
 )
 
-// CurrentUserResponse represents the response for the current user API call.
-type CurrentUserResponse struct {
-	Player Player `json:"player"`
-}
-
-// UserPlayersListResponse represents a list of players for the current user.
-type UserPlayersListResponse []Player
-
-// LoginRequest represents the payload for a login request.
-type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
-}
-
-// LoginResponse represents the response from a login request.
-type LoginResponse struct {
-	Session struct {
-		Id                  string  `json:"id"`
-		Email               string  `json:"email"`
-		Player_id           *string `json:"playerID"`
-		Has_confirmed_email bool    `json:"hasConfirmedEmail"`
-		Server_time         string  `json:"serverTime"`
-	} `json:"session"`
-	Token string `json:"token"`
-}
-
 // Login authenticates the user and returns a token.
 // Returns the authentication token or an error if login fails.
 func (d *DarkThroneApi) Login(lr LoginRequest) (string, error) {
@@ -46,14 +22,7 @@ func (d *DarkThroneApi) Login(lr LoginRequest) (string, error) {
 		return "", fmt.Errorf("password not set")
 	}
 
-	req := ApiRequest[LoginRequest, LoginResponse]{
-		Method:   "POST",
-		Endpoint: loginEndpoint,
-		Headers:  map[string]string{},
-		Body:     lr,
-		Config:   d.apiConfig,
-	}
-	response, err := req.DoRequest()
+	response, err := d.loginAPI(context.Background(), lr)
 	if err != nil {
 		logger.Error("Login failed", "error", err)
 		return "", err
@@ -61,29 +30,14 @@ func (d *DarkThroneApi) Login(lr LoginRequest) (string, error) {
 	token := response.Token
 	d.token = token // Store the token in the API instance for future requests
 	logger.Info("Login successful. Token acquired.")
+	d.state.mu.Lock()
+	d.state.account = lr.Email
+	d.state.own = nil
+	d.state.mu.Unlock()
+	d.publish(LoggedIn{Email: lr.Email, At: time.Now()})
 	return token, nil
 }
 
-// RegisterRequest represents the payload for user registration.
-type RegisterRequest struct {
-	Email           string `json:"email"`
-	Password        string `json:"password"`
-	ConfirmPassword string `json:"confirmPassword"`
-	Username        string `json:"username"`
-}
-
-// RegisterResponse represents the response for user registration.
-type RegisterResponse struct {
-	Session struct {
-		Id                  string  `json:"id"`
-		Email               string  `json:"email"`
-		Player_id           *string `json:"playerID"`
-		Has_confirmed_email bool    `json:"hasConfirmedEmail"`
-		Server_time         string  `json:"serverTime"`
-	} `json:"session"`
-	Token string `json:"token"`
-}
-
 // Register registers a new user.
 func (d *DarkThroneApi) Register(req RegisterRequest) (RegisterResponse, error) {
 	logger := d.config.Logger
@@ -110,33 +64,20 @@ func (d *DarkThroneApi) Register(req RegisterRequest) (RegisterResponse, error)
 		return RegisterResponse{}, fmt.Errorf("passwords do not match")
 	}
 
-	apiReq := ApiRequest[RegisterRequest, RegisterResponse]{
-		Method:   "POST",
-		Endpoint: "auth/register",
-		Headers:  map[string]string{"Content-Type": "application/json"},
-		Body:     req,
-		Config:   d.apiConfig,
-	}
-	response, err := apiReq.DoRequest()
+	response, err := d.registerAPI(context.Background(), req)
 	if err != nil {
 		logger.Error("Registration failed", "error", err)
 		return RegisterResponse{}, err
 	}
 	logger.Info("Registration successful.")
+	d.publish(UserRegistered{Email: req.Email, Username: req.Username, At: time.Now()})
 	return response, nil
 }
 
 // GetCurrentUserAPI fetches the current user (not player) from the API.
 // Returns the CurrentUserResponse or an error if the request fails.
 func (d *DarkThroneApi) GetCurrentUserAPI() (CurrentUserResponse, error) {
-	currentUserReq := ApiRequest[struct{}, CurrentUserResponse]{
-		Method:   "GET",
-		Endpoint: currentUserEndpoint,
-		Headers:  map[string]string{},
-		Body:     struct{}{},
-		Config:   d.apiConfig,
-	}
-	return currentUserReq.DoRequest()
+	return d.currentUserAPI(context.Background())
 }
 
 // GetCurrentUser fetches the current authenticated user.
@@ -144,18 +85,13 @@ func (d *DarkThroneApi) GetCurrentUserAPI() (CurrentUserResponse, error) {
 func (d *DarkThroneApi) GetCurrentUser() (CurrentUserResponse, error) {
 	logger := d.config.Logger
 	logger.Info("Fetching current authenticated user...")
-	apiReq := ApiRequest[struct{}, CurrentUserResponse]{
-		Method:   "GET",
-		Endpoint: currentUserEndpoint,
-		Headers:  d.getAuthHeaders(),
-		Body:     struct{}{},
-		Config:   d.apiConfig,
-	}
-	response, err := apiReq.DoRequest()
+	response, err := d.currentUserAPI(context.Background())
 	if err != nil {
 		logger.Error("Failed to fetch current user", "error", err)
 		return CurrentUserResponse{}, err
 	}
+	d.rememberOwn(response.Player)
+	d.recordSnapshots(response.Player)
 	return response, nil
 }
 
@@ -164,18 +100,13 @@ func (d *DarkThroneApi) GetCurrentUser() (CurrentUserResponse, error) {
 func (d *DarkThroneApi) GetPlayersForCurrentUser() ([]Player, error) {
 	logger := d.config.Logger
 	logger.Info("Fetching players for current user...")
-	apiReq := ApiRequest[struct{}, UserPlayersListResponse]{
-		Method:   "GET",
-		Endpoint: playersListEndpoint,
-		Headers:  d.getAuthHeaders(),
-		Body:     struct{}{},
-		Config:   d.apiConfig,
-	}
-	response, err := apiReq.DoRequest()
+	response, err := d.currentUserPlayersAPI(context.Background())
 	if err != nil {
 		logger.Error("Failed to fetch players for user", "error", err)
 		return nil, err
 	}
+	d.rememberOwn(response...)
+	d.recordSnapshots(response...)
 	return response, nil
 }
 
@@ -184,20 +115,14 @@ func (d *DarkThroneApi) GetPlayersForCurrentUser() ([]Player, error) {
 func (d *DarkThroneApi) Logout() error {
 	logger := d.config.Logger
 	logger.Info("Logging out current user...")
-	apiReq := ApiRequest[struct{}, struct{}]{
-		Method:   "POST",
-		Endpoint: "auth/logout",
-		Headers:  d.getAuthHeaders(),
-		Body:     struct{}{},
-		Config:   d.apiConfig,
-	}
-	_, err := apiReq.DoRequest()
+	err := d.logoutAPI(context.Background())
 	if err != nil {
 		logger.Error("Logout failed", "error", err)
 		return err
 	}
 	d.token = "" // Clear token on logout
 	logger.Info("Logout successful.")
+	d.publish(LoggedOut{At: time.Now()})
 	return nil
 }
 
@@ -206,20 +131,14 @@ func (d *DarkThroneApi) Logout() error {
 func (d *DarkThroneApi) AssumePlayer(playerID string) (Player, error) {
 	logger := d.config.Logger
 	logger.Info("Assuming player", "playerID", playerID)
-	payload := map[string]string{"playerID": playerID}
-	apiReq := ApiRequest[map[string]string, CurrentUserResponse]{
-		Method:   "POST",
-		Endpoint: "auth/assume-player",
-		Headers:  d.getAuthHeaders(),
-		Body:     payload,
-		Config:   d.apiConfig,
-	}
-	response, err := apiReq.DoRequest()
+	response, err := d.assumePlayerAPI(context.Background(), assumePlayerRequest{PlayerID: playerID})
 	if err != nil {
 		logger.Error("Failed to assume player", "error", err)
 		return Player{}, err
 	}
 	logger.Info("Player assumed successfully.")
+	d.rememberAssumed(response.Player)
+	d.publish(PlayerAssumed{Player: response.Player, At: time.Now()})
 	return response.Player, nil
 }
 
@@ -228,18 +147,13 @@ func (d *DarkThroneApi) AssumePlayer(playerID string) (Player, error) {
 func (d *DarkThroneApi) UnassumePlayer() error {
 	logger := d.config.Logger
 	logger.Info("Unassuming current player...")
-	apiReq := ApiRequest[struct{}, struct{}]{
-		Method:   "POST",
-		Endpoint: "auth/unassume-player",
-		Headers:  d.getAuthHeaders(),
-		Body:     struct{}{},
-		Config:   d.apiConfig,
-	}
-	_, err := apiReq.DoRequest()
+	err := d.unassumePlayerAPI(context.Background())
 	if err != nil {
 		logger.Error("Unassume player failed", "error", err)
 		return err
 	}
 	logger.Info("Player unassumed successfully.")
+	d.forgetAssumed()
+	d.publish(PlayerUnassumed{At: time.Now()})
 	return nil
 }
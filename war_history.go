@@ -0,0 +1,272 @@
+package DarkThroneApi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+)
+
+// ErrOutcomeWithoutPlayer is returned by the war history fetches for a filter that sets Outcome but not PlayerID.
+var ErrOutcomeWithoutPlayer = errors.New("war history filter sets Outcome without PlayerID")
+
+// WarOutcome is the result of a war history record from one player's point of view.
+type WarOutcome string
+
+const (
+	WarOutcomeVictory WarOutcome = "victory"
+	WarOutcomeDefeat  WarOutcome = "defeat"
+)
+
+// WarHistory represents a war history record.
+type WarHistory struct {
+	ID        string `json:"id"`
+	PlayerID  string `json:"playerId"`
+	Opponent  string `json:"opponent"`
+	Result    string `json:"result"`
+	Timestamp string `json:"timestamp"`
+
+	AttackerID         string    `json:"attackerID"`
+	DefenderID         string    `json:"defenderID"`
+	IsAttackerVictor   bool      `json:"isAttackerVictor"`
+	AttackTurnsUsed    int       `json:"attackTurnsUsed"`
+	GoldStolen         int       `json:"goldStolen"`
+	AttackerStrength   int       `json:"attackerStrength"`
+	DefenderStrength   int       `json:"defenderStrength"`
+	AttackerCasualties int       `json:"attackerCasualties"`
+	DefenderCasualties int       `json:"defenderCasualties"`
+	XPEarned           int       `json:"xpEarned"`
+	CreatedAt          time.Time `json:"createdAt"`
+}
+
+// warTimeLayouts are the timestamp formats accepted in war history, tried in order.
+var warTimeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05Z07:00", "2006-01-02 15:04:05"}
+
+// parseWarTime parses a war history timestamp, returning the zero time if s is empty or in no known format.
+// Times without a zone are taken as UTC.
+func parseWarTime(s string) time.Time {
+	for _, layout := range warTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// UnmarshalJSON decodes w, leaving CreatedAt zero when it is null, empty or not a known time format
+// rather than failing the whole response.
+func (w *WarHistory) UnmarshalJSON(data []byte) error {
+	type plain WarHistory
+	var raw struct {
+		plain
+		CreatedAt json.RawMessage `json:"createdAt"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*w = WarHistory(raw.plain)
+	var createdAt string
+	if json.Unmarshal(raw.CreatedAt, &createdAt) == nil {
+		w.CreatedAt = parseWarTime(createdAt)
+	}
+	return nil
+}
+
+// Time returns when the war took place, falling back to the legacy Timestamp field.
+// It returns the zero time if neither is set or parseable.
+func (w WarHistory) Time() time.Time {
+	if !w.CreatedAt.IsZero() {
+		return w.CreatedAt
+	}
+	return parseWarTime(w.Timestamp)
+}
+
+// Involves reports whether playerID was the attacker or the defender.
+func (w WarHistory) Involves(playerID string) bool {
+	return w.AttackerID == playerID || w.DefenderID == playerID
+}
+
+// OpponentOf returns the other participant from playerID's point of view.
+// It returns an empty string if playerID was not involved.
+func (w WarHistory) OpponentOf(playerID string) string {
+	switch playerID {
+	case w.AttackerID:
+		return w.DefenderID
+	case w.DefenderID:
+		return w.AttackerID
+	}
+	return ""
+}
+
+// OutcomeFor returns whether playerID won or lost the war.
+// It returns an empty outcome if playerID was not involved.
+func (w WarHistory) OutcomeFor(playerID string) WarOutcome {
+	if !w.Involves(playerID) {
+		return ""
+	}
+	if (w.AttackerID == playerID) == w.IsAttackerVictor {
+		return WarOutcomeVictory
+	}
+	return WarOutcomeDefeat
+}
+
+// WarHistoryFilter selects war history records. Zero-valued fields do not filter.
+type WarHistoryFilter struct {
+	Page     int
+	PageSize int
+	From     time.Time
+	To       time.Time
+	// PlayerID is the point of view for OpponentID and Outcome, usually the assumed player.
+	PlayerID   string
+	OpponentID string
+	// Outcome requires PlayerID, since a war is a victory for one side and a defeat for the other.
+	Outcome WarOutcome
+}
+
+// validate rejects filters that cannot be applied.
+func (f WarHistoryFilter) validate() error {
+	if f.Outcome != "" && f.PlayerID == "" {
+		return ErrOutcomeWithoutPlayer
+	}
+	return nil
+}
+
+// Matches reports whether w satisfies every filter except pagination.
+// An Outcome without PlayerID matches nothing.
+func (f WarHistoryFilter) Matches(w WarHistory) bool {
+	at := w.Time()
+	if !f.From.IsZero() && at.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !at.Before(f.To) {
+		return false
+	}
+	if f.OpponentID != "" {
+		if f.PlayerID != "" && w.OpponentOf(f.PlayerID) != f.OpponentID {
+			return false
+		}
+		if f.PlayerID == "" && !w.Involves(f.OpponentID) {
+			return false
+		}
+	}
+	if f.Outcome != "" && (f.PlayerID == "" || w.OutcomeFor(f.PlayerID) != f.Outcome) {
+		return false
+	}
+	return true
+}
+
+// listOptions converts the filter into query parameters for the war-history endpoint.
+func (f WarHistoryFilter) listOptions() ListOptions {
+	filters := map[string]string{
+		"playerId":   f.PlayerID,
+		"opponentId": f.OpponentID,
+		"outcome":    string(f.Outcome),
+	}
+	if !f.From.IsZero() {
+		filters["from"] = f.From.UTC().Format(time.RFC3339)
+	}
+	if !f.To.IsZero() {
+		filters["to"] = f.To.UTC().Format(time.RFC3339)
+	}
+	return ListOptions{Page: f.Page, PageSize: f.PageSize, Filters: filters}
+}
+
+// PaginationMeta describes the position of a page within a paginated list.
+type PaginationMeta struct {
+	Page       int `json:"page"`
+	PageSize   int `json:"pageSize"`
+	TotalItems int `json:"totalItemCount"`
+	TotalPages int `json:"totalPageCount"`
+}
+
+// WarHistoryPage is one page of war history records.
+type WarHistoryPage struct {
+	Items []WarHistory   `json:"items"`
+	Meta  PaginationMeta `json:"meta"`
+}
+
+// FetchWarHistoryByID fetches war history by ID.
+func (d *DarkThroneApi) FetchWarHistoryByID(id string) (WarHistory, error) {
+	response, err := d.warHistoryAPI(context.Background(), id)
+	if err != nil {
+		return WarHistory{}, err
+	}
+	return response, nil
+}
+
+// FetchWarHistory fetches one page of war history matching filter.
+// Filters are sent to the server and re-applied locally, so results are correct even if the server ignores them.
+func (d *DarkThroneApi) FetchWarHistory(filter WarHistoryFilter) (WarHistoryPage, error) {
+	if err := filter.validate(); err != nil {
+		return WarHistoryPage{}, err
+	}
+	response, err := d.warHistoryListAPI(context.Background(), filter.listOptions().Values())
+	if err != nil {
+		return WarHistoryPage{}, err
+	}
+	items := response.Items[:0]
+	for _, w := range response.Items {
+		if filter.Matches(w) {
+			items = append(items, w)
+		}
+	}
+	response.Items = items
+	return response, nil
+}
+
+// FetchAllWarHistory fetches all war history, following pagination until the last page.
+func (d *DarkThroneApi) FetchAllWarHistory() ([]WarHistory, error) {
+	return d.FetchAllWarHistoryMatching(WarHistoryFilter{})
+}
+
+// FetchAllWarHistoryMatching fetches every war history record matching filter across all pages.
+// filter.Page is ignored; filter.PageSize defaults to 100.
+func (d *DarkThroneApi) FetchAllWarHistoryMatching(filter WarHistoryFilter) (_ []WarHistory, err error) {
+	ctx, span := d.startSpan(context.Background(), "FetchAllWarHistoryMatching")
+	defer func() { endSpan(span, err) }()
+	return d.allWarHistory(ctx, filter)
+}
+
+// allWarHistory is FetchAllWarHistoryMatching with a caller-supplied context.
+func (d *DarkThroneApi) allWarHistory(ctx context.Context, filter WarHistoryFilter) ([]WarHistory, error) {
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	if filter.PageSize <= 0 {
+		filter.PageSize = page_size
+	}
+	var all, previous []WarHistory
+	seen := make(map[string]bool)
+	for page := 1; ; page++ {
+		filter.Page = page
+		response, err := d.warHistoryListAPI(ctx, filter.listOptions().Values())
+		if err != nil {
+			return nil, err
+		}
+		// Items without an ID cannot be told apart from earlier ones, so a server that ignores
+		// pagination is also recognised by a page that repeats the previous one.
+		if page > 1 && reflect.DeepEqual(response.Items, previous) {
+			return all, nil
+		}
+		previous = response.Items
+		added := 0
+		for _, w := range response.Items {
+			if w.ID != "" {
+				if seen[w.ID] {
+					continue
+				}
+				seen[w.ID] = true
+			}
+			added++
+			if filter.Matches(w) {
+				all = append(all, w)
+			}
+		}
+		// Stop on the last page, or if the server ignored pagination and repeated a page.
+		if added == 0 || len(response.Items) < filter.PageSize ||
+			(response.Meta.TotalPages > 0 && page >= response.Meta.TotalPages) {
+			return all, nil
+		}
+	}
+}
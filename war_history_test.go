@@ -0,0 +1,122 @@
+package DarkThroneApi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWarHistory_DecodesFullRecord(t *testing.T) {
+	data := `{"id":"w1","attackerID":"a","defenderID":"d","isAttackerVictor":true,"attackTurnsUsed":10,
+		"goldStolen":500,"attackerCasualties":3,"defenderCasualties":7,"createdAt":"2025-06-01T12:00:00Z"}`
+	var w WarHistory
+	if err := json.Unmarshal([]byte(data), &w); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.AttackerID != "a" || w.GoldStolen != 500 || w.DefenderCasualties != 7 || w.Time().Hour() != 12 {
+		t.Errorf("unexpected record: %+v", w)
+	}
+	if w.OutcomeFor("a") != WarOutcomeVictory || w.OutcomeFor("d") != WarOutcomeDefeat || w.OpponentOf("d") != "a" {
+		t.Error("unexpected point-of-view helpers")
+	}
+}
+
+func TestWarHistoryFilter_Matches(t *testing.T) {
+	at := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	w := WarHistory{AttackerID: "me", DefenderID: "them", IsAttackerVictor: false, CreatedAt: at}
+	cases := []struct {
+		filter WarHistoryFilter
+		want   bool
+	}{
+		{WarHistoryFilter{}, true},
+		{WarHistoryFilter{From: at.Add(time.Hour)}, false},
+		{WarHistoryFilter{To: at.Add(time.Hour)}, true},
+		{WarHistoryFilter{PlayerID: "me", OpponentID: "them", Outcome: WarOutcomeDefeat}, true},
+		{WarHistoryFilter{PlayerID: "me", Outcome: WarOutcomeVictory}, false},
+		{WarHistoryFilter{PlayerID: "me", OpponentID: "other"}, false},
+		{WarHistoryFilter{Outcome: WarOutcomeDefeat}, false},
+	}
+	for i, c := range cases {
+		if got := c.filter.Matches(w); got != c.want {
+			t.Errorf("case %d: got %v, want %v", i, got, c.want)
+		}
+	}
+}
+
+func TestFetchWarHistory_RejectsOutcomeWithoutPlayer(t *testing.T) {
+	d := newTestApi(t, "http://unused")
+	d.token = "t"
+	filter := WarHistoryFilter{Outcome: WarOutcomeVictory}
+	if _, err := d.FetchWarHistory(filter); !errors.Is(err, ErrOutcomeWithoutPlayer) {
+		t.Errorf("FetchWarHistory err = %v, want ErrOutcomeWithoutPlayer", err)
+	}
+	if _, err := d.FetchAllWarHistoryMatching(filter); !errors.Is(err, ErrOutcomeWithoutPlayer) {
+		t.Errorf("FetchAllWarHistoryMatching err = %v, want ErrOutcomeWithoutPlayer", err)
+	}
+}
+
+func TestFetchAllWarHistory_FollowsPages(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
+		var items []WarHistory
+		for i := (page - 1) * size; i < min(page*size, 250); i++ {
+			items = append(items, WarHistory{ID: strconv.Itoa(i)})
+		}
+		json.NewEncoder(w).Encode(WarHistoryPage{Items: items, Meta: PaginationMeta{Page: page, TotalPages: 3}})
+	}))
+	defer ts.Close()
+
+	d := newTestApi(t, ts.URL)
+	d.token = "tok"
+	all, err := d.FetchAllWarHistory()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(all) != 250 {
+		t.Errorf("expected 250 records, got %d", len(all))
+	}
+}
+
+func TestWarHistory_DecodesCreatedAtLeniently(t *testing.T) {
+	for raw, want := range map[string]time.Time{
+		`""`:                            {},
+		`null`:                          {},
+		`"yesterday"`:                   {},
+		`"2025-06-01 12:00:00"`:         time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+		`"2025-06-01T12:00:00.5+02:00"`: time.Date(2025, 6, 1, 10, 0, 0, 5e8, time.UTC),
+	} {
+		var w WarHistory
+		if err := json.Unmarshal([]byte(`{"id":"w1","goldStolen":5,"createdAt":`+raw+`}`), &w); err != nil {
+			t.Errorf("%s: unexpected error: %v", raw, err)
+			continue
+		}
+		if !w.CreatedAt.Equal(want) || w.ID != "w1" || w.GoldStolen != 5 {
+			t.Errorf("%s: decoded %+v, want createdAt %v", raw, w, want)
+		}
+	}
+}
+
+func TestFetchAllWarHistory_StopsOnRepeatedPageWithoutIDs(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		size, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
+		json.NewEncoder(w).Encode(WarHistoryPage{Items: make([]WarHistory, size)})
+	}))
+	defer ts.Close()
+
+	d := newTestApi(t, ts.URL)
+	d.token = "tok"
+	all, err := d.FetchAllWarHistory()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requests != 2 || len(all) != page_size {
+		t.Errorf("requests = %d, records = %d, want one page kept and the repeat dropped", requests, len(all))
+	}
+}
@@ -0,0 +1,122 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// StdoutNotifier writes one line per event.
+type StdoutNotifier struct {
+	// W defaults to os.Stdout.
+	W io.Writer
+}
+
+// Notify writes the events.
+func (n StdoutNotifier) Notify(ctx context.Context, events []Event) error {
+	w := n.W
+	if w == nil {
+		w = os.Stdout
+	}
+	for _, e := range events {
+		if _, err := fmt.Fprintf(w, "%s %s\n", e.At.Format("2006-01-02T15:04:05Z07:00"), e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WebhookNotifier POSTs events as JSON, {"events": [...]}, to a URL.
+type WebhookNotifier struct {
+	URL string
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// Notify posts the events to the webhook.
+func (n WebhookNotifier) Notify(ctx context.Context, events []Event) error {
+	return postJSON(ctx, n.Client, n.URL, map[string]any{"events": events})
+}
+
+// discordMessageLimit is the maximum length of a Discord message's content.
+const discordMessageLimit = 2000
+
+// DiscordNotifier posts events to a Discord-compatible webhook, splitting them into messages
+// that fit within Discord's content limit.
+type DiscordNotifier struct {
+	URL string
+	// Username overrides the webhook's default name when set.
+	Username string
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// Notify posts the events as one or more Discord messages.
+func (n DiscordNotifier) Notify(ctx context.Context, events []Event) error {
+	var message strings.Builder
+	flush := func() error {
+		if message.Len() == 0 {
+			return nil
+		}
+		payload := map[string]string{"content": message.String()}
+		if n.Username != "" {
+			payload["username"] = n.Username
+		}
+		message.Reset()
+		return postJSON(ctx, n.Client, n.URL, payload)
+	}
+	for _, e := range events {
+		line := e.String()
+		line = truncate(line, discordMessageLimit-1)
+		if message.Len()+len(line)+1 > discordMessageLimit {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		message.WriteString(line)
+		message.WriteString("\n")
+	}
+	return flush()
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 encoded rune.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// postJSON sends payload to url and treats any non-2xx response as an error.
+func postJSON(ctx context.Context, client *http.Client, url string, payload any) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+var sampleEvent = Event{Kind: EventLevelUp, PlayerID: "r", PlayerName: "Rival", Old: 1, New: 2, At: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)}
+
+func TestStdoutNotifier(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (StdoutNotifier{W: &buf}).Notify(context.Background(), []Event{sampleEvent}); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "2025-06-01T00:00:00Z Rival levelled up from 1 to 2\n" {
+		t.Errorf("unexpected output: %q", got)
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var received struct {
+		Events []Event `json:"events"`
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected content type: %s", r.Header.Get("Content-Type"))
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer ts.Close()
+
+	if err := (WebhookNotifier{URL: ts.URL}).Notify(context.Background(), []Event{sampleEvent}); err != nil {
+		t.Fatal(err)
+	}
+	if len(received.Events) != 1 || received.Events[0].Kind != EventLevelUp {
+		t.Errorf("unexpected payload: %+v", received)
+	}
+}
+
+func TestWebhookNotifier_ErrorStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+	if err := (WebhookNotifier{URL: ts.URL}).Notify(context.Background(), []Event{sampleEvent}); err == nil {
+		t.Error("expected error for non-2xx status")
+	}
+}
+
+func TestDiscordNotifier_SplitsLongMessages(t *testing.T) {
+	var messages []map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		json.NewDecoder(r.Body).Decode(&payload)
+		messages = append(messages, payload)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	events := make([]Event, 100)
+	for i := range events {
+		events[i] = sampleEvent
+	}
+	if err := (DiscordNotifier{URL: ts.URL, Username: "watch"}).Notify(context.Background(), events); err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) < 2 {
+		t.Fatalf("expected the events to be split, got %d messages", len(messages))
+	}
+	lines := 0
+	for _, m := range messages {
+		if len(m["content"]) > discordMessageLimit || m["username"] != "watch" {
+			t.Errorf("invalid message: %d chars, username %q", len(m["content"]), m["username"])
+		}
+		lines += strings.Count(m["content"], "\n")
+	}
+	if lines != 100 {
+		t.Errorf("expected 100 lines, got %d", lines)
+	}
+}
+
+func TestDiscordNotifier_TruncatesOnRuneBoundary(t *testing.T) {
+	var content string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		json.NewDecoder(r.Body).Decode(&payload)
+		content = payload["content"]
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	long := sampleEvent
+	long.PlayerName = strings.Repeat("é", discordMessageLimit)
+	if err := (DiscordNotifier{URL: ts.URL}).Notify(context.Background(), []Event{long}); err != nil {
+		t.Fatal(err)
+	}
+	if len(content) > discordMessageLimit || strings.ContainsRune(content, utf8.RuneError) {
+		t.Errorf("content is %d bytes, valid UTF-8 = %v", len(content), !strings.ContainsRune(content, utf8.RuneError))
+	}
+}
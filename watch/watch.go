@@ -0,0 +1,205 @@
+// Package watch polls a list of rival players and reports notable changes to a Notifier.
+//
+// A Watcher fetches the watched players with FetchAllMatchingIDs on a fixed interval,
+// compares each one with its last snapshot and sends the resulting events, such as a
+// level up or a sudden jump in army size, to the configured Notifier.
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	DarkThroneApi "github.com/Rihoj/DarkThroneApi"
+)
+
+// EventKind identifies the kind of change that was detected.
+type EventKind string
+
+const (
+	EventLevelUp             EventKind = "level_up"
+	EventArmySizeJump        EventKind = "army_size_jump"
+	EventGoldAboveThreshold  EventKind = "gold_above_threshold"
+	EventAttackTurnsRefilled EventKind = "attack_turns_refilled"
+)
+
+// Event is a change detected on a watched player.
+type Event struct {
+	Kind       EventKind `json:"kind"`
+	PlayerID   string    `json:"playerId"`
+	PlayerName string    `json:"playerName"`
+	Old        int       `json:"old"`
+	New        int       `json:"new"`
+	At         time.Time `json:"at"`
+}
+
+// String returns a one-line human readable description of the event.
+func (e Event) String() string {
+	name := e.PlayerName
+	if name == "" {
+		name = e.PlayerID
+	}
+	switch e.Kind {
+	case EventLevelUp:
+		return fmt.Sprintf("%s levelled up from %d to %d", name, e.Old, e.New)
+	case EventArmySizeJump:
+		return fmt.Sprintf("%s army size changed from %d to %d (%+d)", name, e.Old, e.New, e.New-e.Old)
+	case EventGoldAboveThreshold:
+		return fmt.Sprintf("%s has %d gold on hand", name, e.New)
+	case EventAttackTurnsRefilled:
+		return fmt.Sprintf("%s attack turns refilled from %d to %d", name, e.Old, e.New)
+	}
+	return fmt.Sprintf("%s %s: %d -> %d", name, e.Kind, e.Old, e.New)
+}
+
+// Notifier delivers events. Notify is called once per poll that produced events.
+type Notifier interface {
+	Notify(ctx context.Context, events []Event) error
+}
+
+// Fetcher fetches players by ID. *DarkThroneApi.DarkThroneApi satisfies it.
+type Fetcher interface {
+	FetchAllMatchingIDs(ids []string) ([]DarkThroneApi.Player, error)
+}
+
+// Gate pauses the watcher. *DarkThroneApi.HealthMonitor satisfies it.
+type Gate interface {
+	WaitUntilAvailable(ctx context.Context) error
+}
+
+// Config configures a Watcher.
+type Config struct {
+	// PlayerIDs are the rivals to watch.
+	PlayerIDs []string
+	// Interval between polls. Defaults to one minute.
+	Interval time.Duration
+	Notifier Notifier
+	// Store holds the snapshots that polls are compared against. Defaults to an in-memory store.
+	// Use a separate store from the client's Config.Snapshots, or each poll is recorded twice.
+	Store DarkThroneApi.SnapshotStore
+	// ArmySizeJump is the minimum absolute change in army size that is reported. Zero disables the alert.
+	ArmySizeJump int
+	// GoldThreshold reports players whose gold rises above this value. Zero disables the alert.
+	GoldThreshold int
+	// Gate, when set, is waited on before each poll, so the watcher pauses while the API is down.
+	Gate   Gate
+	Logger *slog.Logger
+}
+
+// Watcher polls watched players and reports changes.
+type Watcher struct {
+	fetcher Fetcher
+	config  Config
+}
+
+// New creates a Watcher that fetches players with fetcher.
+func New(fetcher Fetcher, config Config) (*Watcher, error) {
+	if len(config.PlayerIDs) == 0 {
+		return nil, errors.New("no players to watch")
+	}
+	if config.Notifier == nil {
+		return nil, errors.New("a notifier is required")
+	}
+	if config.Interval <= 0 {
+		config.Interval = time.Minute
+	}
+	if config.Store == nil {
+		config.Store = DarkThroneApi.NewMemorySnapshotStore()
+	}
+	return &Watcher{fetcher: fetcher, config: config}, nil
+}
+
+// Run polls on the configured interval until ctx is cancelled. Poll errors are logged and do not stop the watcher.
+func (w *Watcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+	for {
+		if w.config.Gate != nil {
+			if err := w.config.Gate.WaitUntilAvailable(ctx); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Poll(ctx); err != nil && w.config.Logger != nil {
+			w.config.Logger.Error("Watch poll failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll fetches the watched players once, notifies about any changes, and records snapshots.
+// It returns the detected events. Snapshots are saved only after a successful Notify, so when it
+// fails the next poll compares against the same snapshots and reports the changes again.
+func (w *Watcher) Poll(ctx context.Context) ([]Event, error) {
+	started := time.Now()
+	players, err := w.fetcher.FetchAllMatchingIDs(w.config.PlayerIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch watched players: %w", err)
+	}
+
+	var events []Event
+	snapshots := make([]DarkThroneApi.PlayerSnapshot, 0, len(players))
+	for _, current := range players {
+		previous, ok, err := w.lastSnapshot(current.ID, started)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, w.compare(previous, ok, current, started)...)
+		snapshots = append(snapshots, DarkThroneApi.PlayerSnapshot{Player: current, TakenAt: started})
+	}
+	if len(events) > 0 {
+		if err := w.config.Notifier.Notify(ctx, events); err != nil {
+			return events, fmt.Errorf("failed to notify: %w", err)
+		}
+	}
+	if err := w.config.Store.SaveSnapshots(snapshots...); err != nil {
+		return events, fmt.Errorf("failed to save snapshots: %w", err)
+	}
+	return events, nil
+}
+
+// lastSnapshot returns the most recent snapshot of playerID taken before the given time.
+func (w *Watcher) lastSnapshot(playerID string, before time.Time) (DarkThroneApi.Player, bool, error) {
+	history, err := w.config.Store.History(playerID, time.Time{})
+	if err != nil {
+		return DarkThroneApi.Player{}, false, err
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].TakenAt.Before(before) {
+			return history[i].Player, true, nil
+		}
+	}
+	return DarkThroneApi.Player{}, false, nil
+}
+
+// compare returns the events raised by the change from previous to current.
+// Without a previous snapshot only the gold threshold can fire.
+func (w *Watcher) compare(previous DarkThroneApi.Player, hasPrevious bool, current DarkThroneApi.Player, at time.Time) []Event {
+	var events []Event
+	event := func(kind EventKind, before, after int) {
+		events = append(events, Event{Kind: kind, PlayerID: current.ID, PlayerName: current.Name, Old: before, New: after, At: at})
+	}
+
+	if w.config.GoldThreshold > 0 && current.Gold > w.config.GoldThreshold &&
+		(!hasPrevious || previous.Gold <= w.config.GoldThreshold) {
+		event(EventGoldAboveThreshold, previous.Gold, current.Gold)
+	}
+	if !hasPrevious {
+		return events
+	}
+	if current.Level > previous.Level {
+		event(EventLevelUp, previous.Level, current.Level)
+	}
+	if jump := current.ArmySize - previous.ArmySize; w.config.ArmySizeJump > 0 && (jump >= w.config.ArmySizeJump || -jump >= w.config.ArmySizeJump) {
+		event(EventArmySizeJump, previous.ArmySize, current.ArmySize)
+	}
+	if current.AttackTurns > previous.AttackTurns {
+		event(EventAttackTurnsRefilled, previous.AttackTurns, current.AttackTurns)
+	}
+	return events
+}
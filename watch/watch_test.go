@@ -0,0 +1,133 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	DarkThroneApi "github.com/Rihoj/DarkThroneApi"
+)
+
+type fakeFetcher struct {
+	responses [][]DarkThroneApi.Player
+	calls     int
+}
+
+func (f *fakeFetcher) FetchAllMatchingIDs(ids []string) ([]DarkThroneApi.Player, error) {
+	players := f.responses[min(f.calls, len(f.responses)-1)]
+	f.calls++
+	return players, nil
+}
+
+type recordingNotifier struct {
+	events []Event
+	err    error
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, events []Event) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.events = append(n.events, events...)
+	return nil
+}
+
+func TestWatcher_PollDetectsChanges(t *testing.T) {
+	fetcher := &fakeFetcher{responses: [][]DarkThroneApi.Player{
+		{{ID: "r", Name: "Rival", Level: 3, ArmySize: 100, Gold: 10, AttackTurns: 5}},
+		{{ID: "r", Name: "Rival", Level: 4, ArmySize: 160, Gold: 5000, AttackTurns: 20}},
+		{{ID: "r", Name: "Rival", Level: 4, ArmySize: 150, Gold: 6000, AttackTurns: 20}},
+	}}
+	notifier := &recordingNotifier{}
+	w, err := New(fetcher, Config{PlayerIDs: []string{"r"}, Notifier: notifier, ArmySizeJump: 50, GoldThreshold: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if events, _ := w.Poll(ctx); len(events) != 0 {
+		t.Errorf("first poll has no baseline, got %+v", events)
+	}
+	time.Sleep(time.Millisecond)
+	events, err := w.Poll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := map[EventKind]bool{}
+	for _, e := range events {
+		kinds[e.Kind] = true
+	}
+	for _, k := range []EventKind{EventLevelUp, EventArmySizeJump, EventGoldAboveThreshold, EventAttackTurnsRefilled} {
+		if !kinds[k] {
+			t.Errorf("missing %s event in %+v", k, events)
+		}
+	}
+	time.Sleep(time.Millisecond)
+	if events, _ := w.Poll(ctx); len(events) != 0 {
+		t.Errorf("small changes and staying above the threshold must not alert, got %+v", events)
+	}
+	if len(notifier.events) != 4 {
+		t.Errorf("expected 4 notified events, got %d", len(notifier.events))
+	}
+}
+
+func TestWatcher_FailedNotifyIsReportedAgain(t *testing.T) {
+	fetcher := &fakeFetcher{responses: [][]DarkThroneApi.Player{
+		{{ID: "r", Level: 3}},
+		{{ID: "r", Level: 4}},
+	}}
+	notifier := &recordingNotifier{}
+	w, err := New(fetcher, Config{PlayerIDs: []string{"r"}, Notifier: notifier})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	w.Poll(ctx)
+	time.Sleep(time.Millisecond)
+	notifier.err = errors.New("webhook down")
+	if _, err := w.Poll(ctx); err == nil {
+		t.Fatal("expected the notify error")
+	}
+	time.Sleep(time.Millisecond)
+	notifier.err = nil
+	if _, err := w.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(notifier.events) != 1 || notifier.events[0].Kind != EventLevelUp {
+		t.Errorf("events = %+v, want the level up delivered after the failure", notifier.events)
+	}
+}
+
+func TestNew_Validates(t *testing.T) {
+	if _, err := New(&fakeFetcher{}, Config{Notifier: &recordingNotifier{}}); err == nil {
+		t.Error("expected error without players")
+	}
+	if _, err := New(&fakeFetcher{}, Config{PlayerIDs: []string{"a"}}); err == nil {
+		t.Error("expected error without notifier")
+	}
+}
+
+type closedGate struct{ calls int }
+
+func (g *closedGate) WaitUntilAvailable(ctx context.Context) error {
+	g.calls++
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestWatcher_RunWaitsOnGate(t *testing.T) {
+	fetcher := &fakeFetcher{responses: [][]DarkThroneApi.Player{{{ID: "r"}}}}
+	gate := &closedGate{}
+	w, err := New(fetcher, Config{PlayerIDs: []string{"r"}, Notifier: &recordingNotifier{}, Gate: gate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := w.Run(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Run returned %v", err)
+	}
+	if gate.calls != 1 || fetcher.calls != 0 {
+		t.Errorf("gate calls = %d, fetches = %d; the watcher must not poll while paused", gate.calls, fetcher.calls)
+	}
+}